@@ -43,13 +43,16 @@ func ListenToMailChannel(ml models.MailData) {
 		//this read the content in the email template html file
 		emailData, err := ioutil.ReadFile(fmt.Sprintf("./email/%v", ml.MailTemplate))
 		if err != nil {
-			app.ErrorLog.Printf("Error reading mail template content ::", err)
+			app.ErrorLog.Println("Error reading mail template content ::", err)
 		}
 		//converted the byte data to a readable string
 		emailText := string(emailData)
 		emailText = strings.Replace(emailText, "[%body%]", ml.MailContent, 1)
 		email.SetBody(mail.TextHTML, emailText)
 	}
+	for _, attachment := range ml.Attachments {
+		email.Attach(&mail.File{Name: attachment.Name, MimeType: attachment.ContentType, Data: attachment.Data})
+	}
 	err = email.Send(client)
 	if err != nil {
 		log.Println("error sending email")
@@ -12,11 +12,17 @@ import (
 	"github.com/alexedwards/scs/v2"
 
 	"github.com/dev-ayaa/resvbooking/pkg/config"
+	"github.com/dev-ayaa/resvbooking/pkg/currency"
+	"github.com/dev-ayaa/resvbooking/pkg/dates"
 	"github.com/dev-ayaa/resvbooking/pkg/driver"
 	"github.com/dev-ayaa/resvbooking/pkg/handlers"
 	"github.com/dev-ayaa/resvbooking/pkg/helpers"
+	"github.com/dev-ayaa/resvbooking/pkg/holds"
 	"github.com/dev-ayaa/resvbooking/pkg/models"
+	"github.com/dev-ayaa/resvbooking/pkg/payments"
 	"github.com/dev-ayaa/resvbooking/pkg/render"
+	"github.com/dev-ayaa/resvbooking/pkg/storage"
+	"github.com/dev-ayaa/resvbooking/pkg/waitlist"
 )
 
 const portNumber = ":8080"
@@ -31,9 +37,18 @@ var dataSourceName string
 
 func main() {
 
+	//the migrate subcommand brings the schema of the database to its version without starting the server
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		err := migrateCommand(os.Args[2:])
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	db, err := run()
 	if err != nil {
-		log.Fatal("Failed to run the Application........")
+		log.Fatal("Failed to run the Application........ ", err)
 	}
 
 	//Close the Database
@@ -65,16 +80,24 @@ func run() (*driver.DB, error) {
 	gob.Register(models.User{})
 	gob.Register(models.RoomRestriction{})
 	gob.Register(map[string]int{})
+	gob.Register([]models.Reservation{})
+	gob.Register(models.BookingGroup{})
+	gob.Register([]int{})
 
 	//defining flags for the database and binding them to database connection variables
 	inProduction := flag.Bool("inproduction", true, "connecting to the database")
 	useCache := flag.Bool("usecache", true, "using application cache")
-	dbName := flag.String("dbname", "postgres", "database name")
-	dbHost := flag.String("dbhost", "localhost", "database host")
-	dbUser := flag.String("dbuser", "postgres", "database user")
-	dbPassword := flag.String("dbpassword", "dev-ayaa", "database password")
-	dbPort := flag.String("dbport", "5432", "database port number")
-	dbSSL := flag.String("dbssl", "disable", "ssl database settings (disable, prefer, require)")
+	dsn := databaseFlags(flag.CommandLine)
+	uploadDir := flag.String("uploaddir", "./uploads", "directory the uploaded room photos are stored in")
+	baseURL := flag.String("baseurl", "http://localhost:8080", "address of the site used in the links sent by mail")
+	paymentProvider := flag.String("paymentprovider", "", "provider taking the deposits, fake for development")
+	paymentSecret := flag.String("paymentsecret", "", "secret shared with the payment provider to sign its webhooks")
+	propertyCurrency := flag.String("currency", payments.Currency, "currency the rooms are priced and charged in")
+	timezone := flag.String("timezone", "UTC", "timezone of the property, Europe/Paris")
+	checkInTime := flag.String("checkin", "15:00", "time the guests can arrive from")
+	checkOutTime := flag.String("checkout", "11:00", "time the guests leave the room by")
+	maxStayNights := flag.Int("maxnights", 30, "longest stay the guests can book, no limit when 0")
+	bookingHorizon := flag.Int("horizon", 365, "days ahead the guests can book a stay, no limit when 0")
 
 	//Parse flags
 	flag.Parse()
@@ -97,6 +120,28 @@ func run() (*driver.DB, error) {
 	session.Cookie.SameSite = http.SameSiteLaxMode //if the user visit the same sites again
 	session.Cookie.Secure = app.InProduction       // is the application in production or development
 
+	//the room photos uploaded by the admin are kept on the local disk
+	photoStorage, err := storage.NewLocalStorage(*uploadDir, "/uploads")
+	if err != nil {
+		return nil, err
+	}
+	app.Storage = photoStorage
+
+	//the deposits are taken by the provider chosen with the flags, a real gateway is plugged in with payments.NewAdapter
+	app.Payments, err = payments.NewProvider(*paymentProvider, *paymentSecret, app.InProduction)
+	if err != nil {
+		return nil, err
+	}
+	app.Rates = currency.NewRates(*propertyCurrency)
+
+	//the days of the stays, like today, are the ones on the calendar of the property
+	app.Property, err = dates.NewProperty(*timezone, *checkInTime, *checkOutTime)
+	if err != nil {
+		return nil, err
+	}
+	app.MaxStayNights = *maxStayNights
+	app.BookingHorizon = *bookingHorizon
+
 	//Getting the templates cache
 	tc, err := render.TemplateCache()
 	// fmt.Println(tc, err)
@@ -116,7 +161,7 @@ func run() (*driver.DB, error) {
 	log.Println(".........Connecting to the database.........")
 
 	// dataSourceName = "host=localhost port=5432  dbname=postgres user=postgres password=dev-ayaa"
-	dataSourceName = fmt.Sprintf("host=%s port=%s  dbname=%s user=%s password=%s sslmode=%s", *dbHost, *dbPort, *dbName, *dbUser, *dbPassword, *dbSSL)
+	dataSourceName = dsn()
 	db, err := driver.ConnectSqlDb(dataSourceName)
 
 	if err != nil {
@@ -129,6 +174,19 @@ func run() (*driver.DB, error) {
 	handlers.NewHandlers(repo)
 	helpers.NewHelper(&app)
 
+	//the prices are displayed in the other currencies with the stored exchange rates
+	rates, err := repo.DB.AllExchangeRates()
+	if err != nil {
+		log.Println("Error loading the exchange rates", err)
+	}
+	app.Rates.Set(rates)
+
+	//offering the rooms freed by cancellations or released blocks to the waitlisted guests
+	waitlist.NewWatcher(&app, repo.DB, *baseURL).Start(waitlist.Interval)
+
+	//freeing the rooms held by guests who left the reservation form
+	holds.NewSweeper(&app, repo.DB).Start(holds.Interval)
+
 	render.NewTemplates(&app)
 
 	return db, nil
@@ -1,10 +1,59 @@
 package main
 
-import "testing"
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+//testDSN the environment variable naming the PostgreSQL database the application is started on, written as
+//"host=localhost port=5432 dbname=postgres user=postgres password=secret"
+const testDSN = "RESVBOOKING_TEST_DSN"
+
+//databaseArgs returns the database flags of the connection string
+func databaseArgs(dsn string) []string {
+	flags := map[string]string{
+		"host":     "-dbhost",
+		"port":     "-dbport",
+		"dbname":   "-dbname",
+		"user":     "-dbuser",
+		"password": "-dbpassword",
+		"sslmode":  "-dbssl",
+	}
+	var args []string
+	for _, field := range strings.Fields(dsn) {
+		parts := strings.SplitN(field, "=", 2)
+		if flag, ok := flags[parts[0]]; ok && len(parts) == 2 {
+			args = append(args, flag+"="+parts[1])
+		}
+	}
+	return args
+}
 
 func TestRun(t *testing.T) {
-	_, err := run()
+	dsn := os.Getenv(testDSN)
+	if dsn == "" {
+		t.Skipf("%s is not set, the application is not started on a database", testDSN)
+	}
+
+	//the templates are read from the root of the repository
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir("../.."); err != nil {
+		t.Fatal(err)
+	}
+	args := os.Args
+	t.Cleanup(func() {
+		os.Args = args
+		_ = os.Chdir(wd)
+	})
+	os.Args = append([]string{args[0], "-inproduction=false", "-paymentprovider=fake", "-paymentsecret=test",
+		"-uploaddir=" + t.TempDir()}, databaseArgs(dsn)...)
+
+	_, err = run()
 	if err != nil {
-		t.Error("Failed Run Test")
+		t.Error("Failed Run Test", err)
 	}
 }
@@ -26,6 +26,8 @@ func NoSurf(next http.Handler) http.Handler {
 		Secure:   app.InProduction,
 		SameSite: http.SameSiteLaxMode,
 	})
+	//the payment provider signs its webhooks instead of sending a csrf token
+	csrfHandler.ExemptPath("/payments/webhook")
 	return csrfHandler
 }
 
@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+
+	"github.com/dev-ayaa/resvbooking/migrations"
+	"github.com/dev-ayaa/resvbooking/pkg/driver"
+)
+
+//migrateUsage the actions of the migrate subcommand
+const migrateUsage = `usage: resvbooking migrate [database flags] <action>
+
+actions:
+  up            apply every pending migration
+  down [steps]  roll back the last applied migrations, one by default
+  to <version>  apply or roll back the migrations to the version, 0 rolls them all back
+  status        list the migrations and when they were applied
+
+database flags:
+`
+
+//databaseFlags defines the flags of the database connection on the flag set, the function returned builds
+//the data source name once the flags are parsed
+func databaseFlags(fs *flag.FlagSet) func() string {
+	dbName := fs.String("dbname", "postgres", "database name")
+	dbHost := fs.String("dbhost", "localhost", "database host")
+	dbUser := fs.String("dbuser", "postgres", "database user")
+	dbPassword := fs.String("dbpassword", "dev-ayaa", "database password")
+	dbPort := fs.String("dbport", "5432", "database port number")
+	dbSSL := fs.String("dbssl", "disable", "ssl database settings (disable, prefer, require)")
+	return func() string {
+		return fmt.Sprintf("host=%s port=%s  dbname=%s user=%s password=%s sslmode=%s", *dbHost, *dbPort, *dbName, *dbUser, *dbPassword, *dbSSL)
+	}
+}
+
+//migrateCommand runs the migrate subcommand, resvbooking migrate -dbname=postgres up
+func migrateCommand(args []string) error {
+	fs := flag.NewFlagSet("migrate", flag.ExitOnError)
+	dsn := databaseFlags(fs)
+	fs.Usage = func() {
+		fmt.Fprint(fs.Output(), migrateUsage)
+		fs.PrintDefaults()
+	}
+	_ = fs.Parse(args)
+
+	action, err := parseMigrateAction(fs.Args())
+	if err != nil {
+		fs.Usage()
+		return err
+	}
+
+	db, err := driver.NewDatabase(dsn())
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	migrator, err := migrations.NewMigrator(db)
+	if err != nil {
+		return err
+	}
+	return action(migrator, os.Stdout)
+}
+
+//migrateAction an action of the migrate subcommand run by the migrator
+type migrateAction func(migrator *migrations.Migrator, out io.Writer) error
+
+//parseMigrateAction reads the action of the migrate subcommand and its argument
+func parseMigrateAction(args []string) (migrateAction, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("the action of the migrate subcommand is missing")
+	}
+	switch args[0] {
+	case "up":
+		if len(args) == 1 {
+			return func(migrator *migrations.Migrator, out io.Writer) error {
+				done, err := migrator.Up()
+				printMigrations(out, "applied", done, err)
+				return err
+			}, nil
+		}
+	case "down":
+		steps := 1
+		if len(args) == 2 {
+			n, err := strconv.Atoi(args[1])
+			if err != nil || n < 1 {
+				return nil, fmt.Errorf("invalid number of steps %q", args[1])
+			}
+			steps = n
+		}
+		if len(args) <= 2 {
+			return func(migrator *migrations.Migrator, out io.Writer) error {
+				done, err := migrator.Down(steps)
+				printMigrations(out, "rolled back", done, err)
+				return err
+			}, nil
+		}
+	case "to":
+		if len(args) == 2 {
+			version, err := strconv.ParseInt(args[1], 10, 64)
+			if err != nil || version < 0 {
+				return nil, fmt.Errorf("invalid version %q", args[1])
+			}
+			return func(migrator *migrations.Migrator, out io.Writer) error {
+				done, err := migrator.To(version)
+				printMigrations(out, "ran", done, err)
+				return err
+			}, nil
+		}
+	case "status":
+		if len(args) == 1 {
+			return func(migrator *migrations.Migrator, out io.Writer) error {
+				states, err := migrator.Status()
+				if err != nil {
+					return err
+				}
+				for _, state := range states {
+					appliedAt := "pending"
+					if state.Applied() {
+						appliedAt = state.AppliedAt.Format("2006-01-02 15:04:05")
+					}
+					fmt.Fprintf(out, "%-19s  %s\n", appliedAt, state.Migration)
+				}
+				return nil
+			}, nil
+		}
+	default:
+		return nil, fmt.Errorf("unknown migrate action %q", args[0])
+	}
+	return nil, fmt.Errorf("invalid arguments for the migrate action %s", args[0])
+}
+
+//printMigrations lists the migrations run before the action ended, nothing to do when it ran none and succeeded
+func printMigrations(out io.Writer, verb string, done []migrations.Migration, err error) {
+	if len(done) == 0 && err == nil {
+		fmt.Fprintln(out, "the schema is up to date, nothing to do")
+		return
+	}
+	for _, migration := range done {
+		fmt.Fprintf(out, "%s %s\n", verb, migration)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseMigrateAction(t *testing.T) {
+	for _, m := range []struct {
+		testName string
+		args     []string
+		valid    bool
+	}{
+		{"up", []string{"up"}, true},
+		{"down", []string{"down"}, true},
+		{"down-steps", []string{"down", "3"}, true},
+		{"to-version", []string{"to", "20220311072758"}, true},
+		{"to-zero", []string{"to", "0"}, true},
+		{"status", []string{"status"}, true},
+		{"missing-action", nil, false},
+		{"unknown-action", []string{"reset"}, false},
+		{"up-argument", []string{"up", "2"}, false},
+		{"down-zero-steps", []string{"down", "0"}, false},
+		{"down-text-steps", []string{"down", "all"}, false},
+		{"to-missing-version", []string{"to"}, false},
+		{"to-negative-version", []string{"to", "-1"}, false},
+		{"status-argument", []string{"status", "all"}, false},
+	} {
+		action, err := parseMigrateAction(m.args)
+		if m.valid && (err != nil || action == nil) {
+			t.Errorf("Error Testing %s for the migrate action: %v", m.testName, err)
+		}
+		if !m.valid && err == nil {
+			t.Errorf("Error Testing %s for the migrate action, no error", m.testName)
+		}
+	}
+}
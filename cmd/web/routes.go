@@ -21,11 +21,14 @@ func routes(app *config.AppConfig) http.Handler {
 	mux.Get("/", handlers.Repo.HomePage)
 	mux.Get("/about", handlers.Repo.AboutPage)
 	mux.Get("/contact", handlers.Repo.ContactPage)
-	mux.Get("/junior-suite", handlers.Repo.JuniorSuitePage)
-	mux.Get("/premium-suite", handlers.Repo.PremiumSuitePage)
-	mux.Get("/deluxe-suite", handlers.Repo.DeluxeSuitePage)
-	mux.Get("/penthouse-suite", handlers.Repo.PenthousePage)
-	mux.Get("/executive-suite", handlers.Repo.ExecutivePage)
+	mux.Get("/rooms", handlers.Repo.RoomsPage)
+	mux.Get("/rooms/{slug}", handlers.Repo.RoomPage)
+	//the suite pages which used to be hard-coded moved to the room catalogue
+	mux.Get("/junior-suite", http.RedirectHandler("/rooms/junior-suite", http.StatusMovedPermanently).ServeHTTP)
+	mux.Get("/deluxe-suite", http.RedirectHandler("/rooms/deluxe-suite", http.StatusMovedPermanently).ServeHTTP)
+	mux.Get("/premium-suite", http.RedirectHandler("/rooms/premium-suite", http.StatusMovedPermanently).ServeHTTP)
+	mux.Get("/penthouse-suite", http.RedirectHandler("/rooms/penthouse-suite", http.StatusMovedPermanently).ServeHTTP)
+	mux.Get("/executive-suite", http.RedirectHandler("/rooms/executive-suite", http.StatusMovedPermanently).ServeHTTP)
 
 	mux.Get("/check-availability", handlers.Repo.CheckAvailabilityPage)
 	mux.Post("/check-availability", handlers.Repo.PostCheckAvailabilityPage)
@@ -33,11 +36,18 @@ func routes(app *config.AppConfig) http.Handler {
 	mux.Get("/json-availability", handlers.Repo.JsonAvailabilityPage)
 	mux.Post("/json-availability", handlers.Repo.JsonAvailabilityPage)
 
+	mux.Post("/waitlist", handlers.Repo.PostWaitlist)
+	mux.Post("/payments/webhook", handlers.Repo.PaymentWebhook)
+	mux.Get("/waitlist/hold/{token}", handlers.Repo.WaitlistHold)
+
 	mux.Get("/select-available-room/{id}", handlers.Repo.SelectAvailableRoom)
+	mux.Post("/select-available-room", handlers.Repo.PostSelectAvailableRoom)
 
 	mux.Get("/make-reservation", handlers.Repo.MakeReservationPage)
 	mux.Post("/make-reservation", handlers.Repo.PostMakeReservationPage)
 	mux.Get("/make-reservation-data", handlers.Repo.MakeReservationSummary)
+	mux.Post("/display-currency", handlers.Repo.PostDisplayCurrency)
+	mux.Post("/locale", handlers.Repo.PostLocale)
 	mux.Get("/book-room-now", handlers.Repo.BookRoomNow)
 	mux.Get("/login", handlers.Repo.LoginPage)
 	mux.Post("/login", handlers.Repo.PostLoginPage)
@@ -52,9 +62,42 @@ func routes(app *config.AppConfig) http.Handler {
 		mux.Get("/admin-all-reservation", handlers.Repo.AdminAllReservation)
 		mux.Get("/admin-reservation-calendar", handlers.Repo.AdminReservationCalendar)
 		mux.Post("/admin-reservation-calendar", handlers.Repo.PostAdminReservationCalendar)
+		mux.Get("/admin-reservation-planner", handlers.Repo.AdminReservationPlanner)
+		mux.Get("/admin-calendar-data", handlers.Repo.AdminCalendarData)
+		mux.Post("/admin-calendar-move", handlers.Repo.PostAdminCalendarMove)
+		mux.Get("/admin-blocks/{id}", handlers.Repo.AdminBlock)
+		mux.Post("/admin-blocks/{id}", handlers.Repo.PostAdminBlock)
+		mux.Post("/admin-blocks/{id}/delete", handlers.Repo.PostAdminDeleteBlock)
+
+		mux.Get("/admin-import-reservation", handlers.Repo.AdminImportReservation)
+		mux.Post("/admin-import-reservation", handlers.Repo.PostAdminImportReservation)
+		mux.Post("/admin-import-reservation/confirm", handlers.Repo.PostAdminConfirmImportReservation)
+
+		mux.Get("/admin-rooms", handlers.Repo.AdminRooms)
+		mux.Get("/admin-rooms/{id}", handlers.Repo.AdminShowRoom)
+		mux.Post("/admin-rooms/{id}", handlers.Repo.PostAdminShowRoom)
+		mux.Post("/admin-rooms/{id}/delete", handlers.Repo.PostAdminDeleteRoom)
+		mux.Post("/admin-rooms/{id}/photos", handlers.Repo.PostAdminRoomPhoto)
+		mux.Post("/admin-rooms/{id}/photos/update", handlers.Repo.PostAdminRoomPhotos)
+		mux.Post("/admin-rooms/{id}/photos/{photoID}/delete", handlers.Repo.PostAdminDeleteRoomPhoto)
+		mux.Post("/admin-rooms/{id}/rules", handlers.Repo.PostAdminBookingRule)
+		mux.Post("/admin-rooms/{id}/rules/{ruleID}/delete", handlers.Repo.PostAdminDeleteBookingRule)
+
+		mux.Get("/admin-taxes", handlers.Repo.AdminTaxes)
+		mux.Post("/admin-taxes", handlers.Repo.PostAdminTaxRule)
+		mux.Post("/admin-taxes/{id}/delete", handlers.Repo.PostAdminDeleteTaxRule)
+		mux.Get("/admin-promo-codes", handlers.Repo.AdminPromoCodes)
+		mux.Post("/admin-promo-codes", handlers.Repo.PostAdminPromoCode)
+		mux.Post("/admin-promo-codes/{id}/delete", handlers.Repo.PostAdminDeletePromoCode)
+		mux.Get("/admin-currencies", handlers.Repo.AdminCurrencies)
+		mux.Post("/admin-currencies", handlers.Repo.PostAdminExchangeRate)
+		mux.Post("/admin-currencies/import", handlers.Repo.PostAdminImportExchangeRates)
+		mux.Post("/admin-currencies/{currency}/delete", handlers.Repo.PostAdminDeleteExchangeRate)
 
 		mux.Get("/admin-show-reservation/{src}/{id}/show", handlers.Repo.AdminShowReservation)
 		mux.Post("/admin-show-reservation/{src}/{id}", handlers.Repo.PostAdminShowReservation)
+		mux.Post("/admin-cancel-reservation/{src}/{id}", handlers.Repo.PostAdminCancelReservation)
+		mux.Get("/admin-reservation-invoice/{id}", handlers.Repo.AdminReservationInvoice)
 
 		mux.Get("/admin/admin-delete-reservation/{src}/{id}/done", handlers.Repo.AdminDeleteReservation)
 		mux.Get("/admin/admin-process-reservation/{src}/{id}/done", handlers.Repo.AdminProcessReservation)
@@ -64,5 +107,8 @@ func routes(app *config.AppConfig) http.Handler {
 	fileServer := http.FileServer(http.Dir("./static/"))
 	mux.Handle("/static/*", http.StripPrefix("/static", fileServer))
 
+	//the room photos uploaded by the admin
+	mux.Handle("/uploads/*", http.StripPrefix("/uploads", app.Storage))
+
 	return mux
 }
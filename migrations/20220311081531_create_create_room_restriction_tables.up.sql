@@ -0,0 +1,11 @@
+CREATE TABLE room_restriction
+(
+    id             SERIAL PRIMARY KEY,
+    check_in_date  DATE      NOT NULL,
+    check_out_date DATE      NOT NULL,
+    room_id        INTEGER   NOT NULL,
+    reservation_id INTEGER   NOT NULL,
+    restriction_id INTEGER   NOT NULL,
+    created_at     TIMESTAMP NOT NULL,
+    updated_at     TIMESTAMP NOT NULL
+);
@@ -0,0 +1,7 @@
+CREATE TABLE restriction
+(
+    id               SERIAL PRIMARY KEY,
+    restriction_name VARCHAR(255) NOT NULL DEFAULT '',
+    created_at       TIMESTAMP    NOT NULL,
+    updated_at       TIMESTAMP    NOT NULL
+);
@@ -0,0 +1,13 @@
+CREATE TABLE reservation
+(
+    id             SERIAL PRIMARY KEY,
+    first_name     VARCHAR(255) NOT NULL DEFAULT '',
+    last_name      VARCHAR(255) NOT NULL DEFAULT '',
+    email          VARCHAR(255) NOT NULL,
+    phone_number   VARCHAR(255) NOT NULL DEFAULT '',
+    check_in_date  DATE         NOT NULL,
+    check_out_date DATE         NOT NULL,
+    room_id        INTEGER      NOT NULL,
+    created_at     TIMESTAMP    NOT NULL,
+    updated_at     TIMESTAMP    NOT NULL
+);
@@ -0,0 +1 @@
+ALTER TABLE reservation DROP CONSTRAINT reservation_rooms_id_fk;
@@ -0,0 +1,3 @@
+ALTER TABLE room_restriction DROP CONSTRAINT room_restriction_reservation_id_fk;
+ALTER TABLE room_restriction DROP CONSTRAINT room_restriction_restriction_id_fk;
+ALTER TABLE room_restriction DROP CONSTRAINT room_restriction_rooms_id_fk;
@@ -0,0 +1,11 @@
+ALTER TABLE room_restriction
+    ADD CONSTRAINT room_restriction_rooms_id_fk FOREIGN KEY (room_id) REFERENCES rooms (id)
+        ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE room_restriction
+    ADD CONSTRAINT room_restriction_restriction_id_fk FOREIGN KEY (restriction_id) REFERENCES restriction (id)
+        ON DELETE CASCADE ON UPDATE CASCADE;
+
+ALTER TABLE room_restriction
+    ADD CONSTRAINT room_restriction_reservation_id_fk FOREIGN KEY (reservation_id) REFERENCES reservation (id)
+        ON DELETE CASCADE ON UPDATE CASCADE;
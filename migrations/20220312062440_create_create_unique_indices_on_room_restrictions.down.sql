@@ -0,0 +1,3 @@
+DROP INDEX room_restriction_check_in_date_check_out_date_idx;
+DROP INDEX room_restriction_room_id_idx;
+DROP INDEX room_restriction_reservation_id_idx;
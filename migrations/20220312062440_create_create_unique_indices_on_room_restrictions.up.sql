@@ -0,0 +1,3 @@
+CREATE INDEX room_restriction_check_in_date_check_out_date_idx ON room_restriction (check_in_date, check_out_date);
+CREATE INDEX room_restriction_room_id_idx ON room_restriction (room_id);
+CREATE INDEX room_restriction_reservation_id_idx ON room_restriction (reservation_id);
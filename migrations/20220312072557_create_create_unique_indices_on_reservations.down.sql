@@ -0,0 +1,2 @@
+DROP INDEX reservation_email_idx;
+DROP INDEX reservation_last_name_idx;
@@ -0,0 +1,2 @@
+CREATE INDEX reservation_last_name_idx ON reservation (last_name);
+CREATE INDEX reservation_email_idx ON reservation (email);
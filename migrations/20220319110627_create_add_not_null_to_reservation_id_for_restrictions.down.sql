@@ -0,0 +1 @@
+-- the blocks set by the owner have no reservation, the column can't be made not null again once they exist
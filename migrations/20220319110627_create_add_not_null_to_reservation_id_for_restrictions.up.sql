@@ -0,0 +1,2 @@
+-- the blocks set by the owner have no reservation
+ALTER TABLE room_restriction ALTER COLUMN reservation_id DROP NOT NULL;
@@ -0,0 +1 @@
+ALTER TABLE reservation ADD COLUMN processed INTEGER NOT NULL DEFAULT 0;
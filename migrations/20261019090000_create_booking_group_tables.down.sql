@@ -0,0 +1,3 @@
+ALTER TABLE reservation DROP COLUMN booking_group_id;
+
+DROP TABLE booking_group;
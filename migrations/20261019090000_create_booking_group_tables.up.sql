@@ -0,0 +1,15 @@
+CREATE TABLE booking_group
+(
+    id         SERIAL PRIMARY KEY,
+    email      VARCHAR(255) NOT NULL DEFAULT '',
+    created_at TIMESTAMP    NOT NULL,
+    updated_at TIMESTAMP    NOT NULL
+);
+
+ALTER TABLE reservation ADD COLUMN booking_group_id INTEGER;
+
+ALTER TABLE reservation
+    ADD CONSTRAINT reservation_booking_group_id_fk FOREIGN KEY (booking_group_id) REFERENCES booking_group (id)
+        ON DELETE SET NULL ON UPDATE CASCADE;
+
+CREATE INDEX reservation_booking_group_id_idx ON reservation (booking_group_id);
@@ -0,0 +1,5 @@
+ALTER TABLE reservation DROP COLUMN children;
+ALTER TABLE reservation DROP COLUMN adults;
+
+ALTER TABLE rooms DROP COLUMN max_children;
+ALTER TABLE rooms DROP COLUMN max_adults;
@@ -0,0 +1,5 @@
+ALTER TABLE rooms ADD COLUMN max_adults INTEGER NOT NULL DEFAULT 2;
+ALTER TABLE rooms ADD COLUMN max_children INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE reservation ADD COLUMN adults INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE reservation ADD COLUMN children INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,5 @@
+DROP TABLE room_photo;
+
+ALTER TABLE rooms DROP COLUMN amenities;
+ALTER TABLE rooms DROP COLUMN description;
+ALTER TABLE rooms DROP COLUMN slug;
@@ -0,0 +1,20 @@
+ALTER TABLE rooms ADD COLUMN slug VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE rooms ADD COLUMN description TEXT NOT NULL DEFAULT '';
+ALTER TABLE rooms ADD COLUMN amenities TEXT NOT NULL DEFAULT '';
+
+CREATE TABLE room_photo
+(
+    id         SERIAL PRIMARY KEY,
+    room_id    INTEGER      NOT NULL,
+    path       VARCHAR(255) NOT NULL,
+    caption    VARCHAR(255) NOT NULL DEFAULT '',
+    position   INTEGER      NOT NULL DEFAULT 0,
+    created_at TIMESTAMP    NOT NULL,
+    updated_at TIMESTAMP    NOT NULL
+);
+
+ALTER TABLE room_photo
+    ADD CONSTRAINT room_photo_rooms_id_fk FOREIGN KEY (room_id) REFERENCES rooms (id)
+        ON DELETE CASCADE ON UPDATE CASCADE;
+
+CREATE INDEX room_photo_room_id_idx ON room_photo (room_id);
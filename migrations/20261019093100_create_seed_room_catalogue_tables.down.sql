@@ -0,0 +1,5 @@
+DROP INDEX IF EXISTS rooms_slug_idx;
+
+DELETE FROM public.room_photo;
+
+UPDATE public.rooms SET slug = '', description = '', amenities = '';
@@ -0,0 +1,32 @@
+UPDATE public.rooms
+SET slug        = 'junior-suite',
+    description = 'Our Junior Suites at Rest Tavern are a quiet retreat for couples, with a king size bed, a sitting area and a view over the garden.',
+    amenities   = E'King size bed\nSitting area\nGarden view\nFree Wi-Fi\nAir conditioning'
+WHERE room_name = 'Junior Quarter''s';
+
+UPDATE public.rooms
+SET slug        = 'deluxe-suite',
+    description = 'Our Deluxe Quarters at Rest Tavern give a small family room to breathe, with a separate bedroom, a living room and a private balcony.',
+    amenities   = E'Separate bedroom\nLiving room\nPrivate balcony\nFree Wi-Fi\nMini bar'
+WHERE room_name = 'Deluxe suite';
+
+UPDATE public.rooms SET slug = 'room-' || id WHERE slug = '';
+
+INSERT INTO public.room_photo (room_id, path, caption, position, created_at, updated_at)
+SELECT id, p.path, '', p.position, now(), now()
+FROM public.rooms,
+     (VALUES ('/static/images/pexels-pixabay-164595.jpg', 1),
+             ('/static/images/pexels-max-vakhtbovych-6782567.jpg', 2),
+             ('/static/images/pexels-elina-sazonova-1838554.jpg', 3),
+             ('/static/images/pexels-pixabay-271624.jpg', 4)) AS p(path, position)
+WHERE slug = 'junior-suite';
+
+INSERT INTO public.room_photo (room_id, path, caption, position, created_at, updated_at)
+SELECT id, p.path, '', p.position, now(), now()
+FROM public.rooms,
+     (VALUES ('/static/images/pexels-max-vakhtbovych-6970069.jpg', 1),
+             ('/static/images/pexels-max-vakhtbovych-6899433.jpg', 2),
+             ('/static/images/pexels-max-vakhtbovych-6588582.jpg', 3)) AS p(path, position)
+WHERE slug = 'deluxe-suite';
+
+CREATE UNIQUE INDEX rooms_slug_idx ON public.rooms (slug);
@@ -0,0 +1 @@
+ALTER TABLE room_photo DROP COLUMN thumbnail_path;
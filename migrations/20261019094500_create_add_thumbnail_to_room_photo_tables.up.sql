@@ -0,0 +1 @@
+ALTER TABLE room_photo ADD COLUMN thumbnail_path VARCHAR(255) NOT NULL DEFAULT '';
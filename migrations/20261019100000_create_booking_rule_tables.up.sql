@@ -0,0 +1,21 @@
+CREATE TABLE booking_rule
+(
+    id                  SERIAL PRIMARY KEY,
+    room_id             INTEGER   NOT NULL,
+    start_date          DATE      NOT NULL,
+    end_date            DATE      NOT NULL,
+    min_nights          INTEGER   NOT NULL DEFAULT 0,
+    max_nights          INTEGER   NOT NULL DEFAULT 0,
+    closed_to_arrival   BOOLEAN   NOT NULL DEFAULT FALSE,
+    closed_to_departure BOOLEAN   NOT NULL DEFAULT FALSE,
+    lead_days           INTEGER   NOT NULL DEFAULT 0,
+    max_advance_days    INTEGER   NOT NULL DEFAULT 0,
+    created_at          TIMESTAMP NOT NULL,
+    updated_at          TIMESTAMP NOT NULL
+);
+
+ALTER TABLE booking_rule
+    ADD CONSTRAINT booking_rule_rooms_id_fk FOREIGN KEY (room_id) REFERENCES rooms (id)
+        ON DELETE CASCADE ON UPDATE CASCADE;
+
+CREATE INDEX booking_rule_room_id_start_date_end_date_idx ON booking_rule (room_id, start_date, end_date);
@@ -0,0 +1,2 @@
+ALTER TABLE room_restriction DROP COLUMN notes;
+ALTER TABLE room_restriction DROP COLUMN reason;
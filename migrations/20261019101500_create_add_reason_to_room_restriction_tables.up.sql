@@ -0,0 +1,2 @@
+ALTER TABLE room_restriction ADD COLUMN reason VARCHAR(255) NOT NULL DEFAULT '';
+ALTER TABLE room_restriction ADD COLUMN notes TEXT NOT NULL DEFAULT '';
@@ -0,0 +1,25 @@
+CREATE TABLE waitlist
+(
+    id              SERIAL PRIMARY KEY,
+    first_name      VARCHAR(255) NOT NULL,
+    last_name       VARCHAR(255) NOT NULL,
+    email           VARCHAR(255) NOT NULL,
+    check_in_date   DATE         NOT NULL,
+    check_out_date  DATE         NOT NULL,
+    room_id         INTEGER,
+    adults          INTEGER      NOT NULL DEFAULT 1,
+    children        INTEGER      NOT NULL DEFAULT 0,
+    status          VARCHAR(255) NOT NULL DEFAULT 'waiting',
+    token           VARCHAR(255) NOT NULL DEFAULT '',
+    offered_room_id INTEGER,
+    hold_expires_at TIMESTAMP,
+    created_at      TIMESTAMP    NOT NULL,
+    updated_at      TIMESTAMP    NOT NULL
+);
+
+ALTER TABLE waitlist
+    ADD CONSTRAINT waitlist_rooms_id_fk FOREIGN KEY (room_id) REFERENCES rooms (id)
+        ON DELETE CASCADE ON UPDATE CASCADE;
+
+CREATE INDEX waitlist_status_created_at_idx ON waitlist (status, created_at);
+CREATE INDEX waitlist_token_idx ON waitlist (token);
@@ -0,0 +1,2 @@
+DROP INDEX room_restriction_expires_at_idx;
+ALTER TABLE room_restriction DROP COLUMN expires_at;
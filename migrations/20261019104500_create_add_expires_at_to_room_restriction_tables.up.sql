@@ -0,0 +1,2 @@
+ALTER TABLE room_restriction ADD COLUMN expires_at TIMESTAMP;
+CREATE INDEX room_restriction_expires_at_idx ON room_restriction (expires_at);
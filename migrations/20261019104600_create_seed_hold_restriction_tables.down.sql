@@ -0,0 +1,3 @@
+DELETE FROM public.room_restriction WHERE restriction_id = 3;
+
+DELETE FROM public.restriction WHERE id = 3;
@@ -0,0 +1,4 @@
+INSERT INTO public.restriction (id, restriction_name, created_at, updated_at)
+VALUES (3, 'Hold', now(), now());
+
+SELECT setval(pg_get_serial_sequence('public.restriction', 'id'), (SELECT max(id) FROM public.restriction));
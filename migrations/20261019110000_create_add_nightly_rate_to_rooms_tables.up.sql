@@ -0,0 +1 @@
+ALTER TABLE rooms ADD COLUMN nightly_rate INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+UPDATE public.rooms SET nightly_rate = 0;
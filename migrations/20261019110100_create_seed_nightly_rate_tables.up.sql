@@ -0,0 +1,2 @@
+UPDATE public.rooms SET nightly_rate = 12000 WHERE slug = 'junior-suite';
+UPDATE public.rooms SET nightly_rate = 18500 WHERE slug = 'deluxe-suite';
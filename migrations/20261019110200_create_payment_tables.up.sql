@@ -0,0 +1,21 @@
+CREATE TABLE payment
+(
+    id               SERIAL PRIMARY KEY,
+    reservation_id   INTEGER,
+    booking_group_id INTEGER,
+    provider         VARCHAR(255) NOT NULL,
+    reference        VARCHAR(255) NOT NULL DEFAULT '',
+    kind             VARCHAR(255) NOT NULL DEFAULT 'deposit',
+    amount           INTEGER      NOT NULL,
+    currency         VARCHAR(3)   NOT NULL,
+    status           VARCHAR(255) NOT NULL DEFAULT 'pending',
+    created_at       TIMESTAMP    NOT NULL,
+    updated_at       TIMESTAMP    NOT NULL
+);
+
+ALTER TABLE payment
+    ADD CONSTRAINT payment_reservation_id_fk FOREIGN KEY (reservation_id) REFERENCES reservation (id)
+        ON DELETE SET NULL ON UPDATE CASCADE;
+
+CREATE INDEX payment_reservation_id_idx ON payment (reservation_id);
+CREATE INDEX payment_provider_reference_idx ON payment (provider, reference);
@@ -0,0 +1,3 @@
+ALTER TABLE rooms DROP COLUMN cancel_penalty_percent;
+ALTER TABLE rooms DROP COLUMN cancel_penalty;
+ALTER TABLE rooms DROP COLUMN cancel_free_days;
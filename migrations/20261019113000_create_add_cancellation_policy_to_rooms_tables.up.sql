@@ -0,0 +1,3 @@
+ALTER TABLE rooms ADD COLUMN cancel_free_days INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE rooms ADD COLUMN cancel_penalty VARCHAR(255) NOT NULL DEFAULT 'none';
+ALTER TABLE rooms ADD COLUMN cancel_penalty_percent INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,2 @@
+ALTER TABLE reservation DROP COLUMN refund_amount;
+ALTER TABLE reservation DROP COLUMN cancelled_at;
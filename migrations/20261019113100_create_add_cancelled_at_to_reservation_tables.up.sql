@@ -0,0 +1,2 @@
+ALTER TABLE reservation ADD COLUMN cancelled_at TIMESTAMP;
+ALTER TABLE reservation ADD COLUMN refund_amount INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1,16 @@
+CREATE TABLE invoice
+(
+    id             SERIAL PRIMARY KEY,
+    number         INTEGER   NOT NULL,
+    reservation_id INTEGER   NOT NULL,
+    issued_at      TIMESTAMP NOT NULL,
+    created_at     TIMESTAMP NOT NULL,
+    updated_at     TIMESTAMP NOT NULL
+);
+
+ALTER TABLE invoice
+    ADD CONSTRAINT invoice_reservation_id_fk FOREIGN KEY (reservation_id) REFERENCES reservation (id)
+        ON DELETE CASCADE ON UPDATE CASCADE;
+
+CREATE UNIQUE INDEX invoice_number_idx ON invoice (number);
+CREATE UNIQUE INDEX invoice_reservation_id_idx ON invoice (reservation_id);
@@ -0,0 +1,21 @@
+CREATE TABLE tax_rule
+(
+    id         SERIAL PRIMARY KEY,
+    room_id    INTEGER,
+    name       VARCHAR(255) NOT NULL,
+    kind       VARCHAR(255) NOT NULL DEFAULT 'tax',
+    basis      VARCHAR(255) NOT NULL DEFAULT 'percent',
+    per        VARCHAR(255) NOT NULL DEFAULT 'night',
+    rate       INTEGER      NOT NULL DEFAULT 0,
+    amount     INTEGER      NOT NULL DEFAULT 0,
+    start_date DATE         NOT NULL,
+    end_date   DATE,
+    created_at TIMESTAMP    NOT NULL,
+    updated_at TIMESTAMP    NOT NULL
+);
+
+ALTER TABLE tax_rule
+    ADD CONSTRAINT tax_rule_rooms_id_fk FOREIGN KEY (room_id) REFERENCES rooms (id)
+        ON DELETE CASCADE ON UPDATE CASCADE;
+
+CREATE INDEX tax_rule_start_date_end_date_idx ON tax_rule (start_date, end_date);
@@ -0,0 +1,17 @@
+CREATE TABLE reservation_charge
+(
+    id             SERIAL PRIMARY KEY,
+    reservation_id INTEGER      NOT NULL,
+    name           VARCHAR(255) NOT NULL,
+    kind           VARCHAR(255) NOT NULL,
+    quantity       INTEGER      NOT NULL DEFAULT 1,
+    amount         INTEGER      NOT NULL,
+    created_at     TIMESTAMP    NOT NULL,
+    updated_at     TIMESTAMP    NOT NULL
+);
+
+ALTER TABLE reservation_charge
+    ADD CONSTRAINT reservation_charge_reservation_id_fk FOREIGN KEY (reservation_id) REFERENCES reservation (id)
+        ON DELETE CASCADE ON UPDATE CASCADE;
+
+CREATE INDEX reservation_charge_reservation_id_idx ON reservation_charge (reservation_id);
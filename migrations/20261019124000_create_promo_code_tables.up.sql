@@ -0,0 +1,18 @@
+CREATE TABLE promo_code
+(
+    id              SERIAL PRIMARY KEY,
+    code            VARCHAR(20)  NOT NULL,
+    description     VARCHAR(255) NOT NULL DEFAULT '',
+    basis           VARCHAR(255) NOT NULL DEFAULT 'percent',
+    rate            INTEGER      NOT NULL DEFAULT 0,
+    amount          INTEGER      NOT NULL DEFAULT 0,
+    min_nights      INTEGER      NOT NULL DEFAULT 0,
+    max_redemptions INTEGER      NOT NULL DEFAULT 0,
+    redemptions     INTEGER      NOT NULL DEFAULT 0,
+    start_date      DATE         NOT NULL,
+    end_date        DATE,
+    created_at      TIMESTAMP    NOT NULL,
+    updated_at      TIMESTAMP    NOT NULL
+);
+
+CREATE UNIQUE INDEX promo_code_code_idx ON promo_code (code);
@@ -0,0 +1 @@
+DROP TABLE exchange_rate;
@@ -0,0 +1,10 @@
+CREATE TABLE exchange_rate
+(
+    id         SERIAL PRIMARY KEY,
+    currency   VARCHAR(3)     NOT NULL,
+    rate       DECIMAL(18, 6) NOT NULL,
+    created_at TIMESTAMP      NOT NULL,
+    updated_at TIMESTAMP      NOT NULL
+);
+
+CREATE UNIQUE INDEX exchange_rate_currency_idx ON exchange_rate (currency);
@@ -0,0 +1,2 @@
+ALTER TABLE waitlist DROP CONSTRAINT waitlist_room_restriction_id_fk;
+ALTER TABLE waitlist DROP COLUMN hold_id;
@@ -0,0 +1,4 @@
+ALTER TABLE waitlist ADD COLUMN hold_id INTEGER;
+ALTER TABLE waitlist
+    ADD CONSTRAINT waitlist_room_restriction_id_fk FOREIGN KEY (hold_id) REFERENCES room_restriction (id)
+        ON DELETE SET NULL ON UPDATE CASCADE;
@@ -0,0 +1,125 @@
+package migrations
+
+import (
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//files the changes of the schema, a pair of plain SQL files per version named after it,
+//20220311072758_create_create_user_tables.up.sql and 20220311072758_create_create_user_tables.down.sql
+//go:embed *.sql
+var files embed.FS
+
+//filePattern the version, the name and the direction of a migration file
+var filePattern = regexp.MustCompile(`^([0-9]{14})_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+//Migration a change of the schema, the SQL applying it and the SQL rolling it back
+type Migration struct {
+	Version int64
+	Name    string
+	Up      string
+	Down    string
+}
+
+//String returns the version and the name of the migration
+func (m Migration) String() string {
+	return fmt.Sprintf("%d_%s", m.Version, m.Name)
+}
+
+//All returns the migrations embedded in the binary, the oldest first
+func All() ([]Migration, error) {
+	return Load(files)
+}
+
+//Load reads the migration files of the directory, the oldest first. Every version has an up and a down file,
+//the other files are left out
+func Load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return nil, err
+	}
+	byVersion := make(map[int64]*Migration)
+	found := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.IsDir() || path.Ext(entry.Name()) != ".sql" {
+			continue
+		}
+		parts := filePattern.FindStringSubmatch(entry.Name())
+		if parts == nil {
+			return nil, fmt.Errorf("unexpected migration file %s, the files are named <version>_<name>.up.sql and .down.sql", entry.Name())
+		}
+		version, err := strconv.ParseInt(parts[1], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid version of the migration file %s", entry.Name())
+		}
+		content, err := fs.ReadFile(fsys, entry.Name())
+		if err != nil {
+			return nil, err
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: parts[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != parts[2] {
+			return nil, fmt.Errorf("the version %d is used by %s and %s", version, migration.Name, parts[2])
+		}
+		if parts[3] == "up" {
+			migration.Up = string(content)
+		} else {
+			migration.Down = string(content)
+		}
+		found[parts[1]+"."+parts[3]] = true
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for version, migration := range byVersion {
+		for _, direction := range []string{"up", "down"} {
+			if !found[fmt.Sprintf("%014d.%s", version, direction)] {
+				return nil, fmt.Errorf("the migration %s has no %s file", migration, direction)
+			}
+		}
+		if strings.TrimSpace(migration.Up) == "" {
+			return nil, fmt.Errorf("the migration %s has nothing to apply", migration)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+	return migrations, nil
+}
+
+//Plan returns the migrations bringing the schema to the target version, the pending ones up to it to apply
+//oldest first and the applied ones after it to roll back newest first. The target 0 rolls back every migration
+func Plan(migrations []Migration, applied map[int64]bool, target int64) (apply, rollback []Migration) {
+	for _, m := range migrations {
+		if m.Version <= target && !applied[m.Version] {
+			apply = append(apply, m)
+		}
+	}
+	for i := len(migrations) - 1; i >= 0; i-- {
+		if m := migrations[i]; m.Version > target && applied[m.Version] {
+			rollback = append(rollback, m)
+		}
+	}
+	return apply, rollback
+}
+
+//Latest returns the applied migrations to roll back to undo the last steps, newest first
+func Latest(migrations []Migration, applied map[int64]bool, steps int) []Migration {
+	var rollback []Migration
+	for i := len(migrations) - 1; i >= 0 && len(rollback) < steps; i-- {
+		if applied[migrations[i].Version] {
+			rollback = append(rollback, migrations[i])
+		}
+	}
+	return rollback
+}
@@ -0,0 +1,133 @@
+package migrations
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func TestAll(t *testing.T) {
+	migrations, err := All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(migrations) != 36 {
+		t.Errorf("Error Testing the embedded migrations got %d wanted 36", len(migrations))
+	}
+	for i, m := range migrations {
+		if i > 0 && m.Version <= migrations[i-1].Version {
+			t.Errorf("Error Testing the order of the migrations, %s comes after %s", m, migrations[i-1])
+		}
+		if strings.TrimSpace(m.Down) == "" {
+			t.Errorf("Error Testing the migration %s, nothing rolls it back", m)
+		}
+	}
+	if first := migrations[0]; first.String() != "20220311072758_create_create_user_tables" || !strings.Contains(first.Up, "CREATE TABLE users") {
+		t.Errorf("Error Testing the first migration got %s", first)
+	}
+}
+
+// TestAll_Tables every table created by a migration is dropped by its down file
+func TestAll_Tables(t *testing.T) {
+	migrations, err := All()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, m := range migrations {
+		for _, line := range strings.Split(m.Up, "\n") {
+			if strings.HasPrefix(line, "CREATE TABLE ") {
+				table := strings.TrimSpace(strings.TrimPrefix(line, "CREATE TABLE "))
+				if !strings.Contains(m.Down, "DROP TABLE "+table+";") {
+					t.Errorf("Error Testing the migration %s, the table %s isn't dropped", m, table)
+				}
+			}
+		}
+	}
+}
+
+func TestLoad(t *testing.T) {
+	migrations, err := Load(fstest.MapFS{
+		"20300102000000_add_rooms.up.sql":    {Data: []byte("CREATE TABLE rooms ();")},
+		"20300102000000_add_rooms.down.sql":  {Data: []byte("DROP TABLE rooms;")},
+		"20300101000000_add_users.down.sql":  {Data: []byte("DROP TABLE users;")},
+		"20300101000000_add_users.up.sql":    {Data: []byte("CREATE TABLE users ();")},
+		"migrations.go":                      {Data: []byte("package migrations")},
+		"20300103000000_notes/readme.up.sql": {Data: []byte("")},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Migration{
+		{Version: 20300101000000, Name: "add_users", Up: "CREATE TABLE users ();", Down: "DROP TABLE users;"},
+		{Version: 20300102000000, Name: "add_rooms", Up: "CREATE TABLE rooms ();", Down: "DROP TABLE rooms;"},
+	}
+	if !reflect.DeepEqual(migrations, want) {
+		t.Errorf("Error Testing the loaded migrations got %+v", migrations)
+	}
+
+	for _, m := range []struct {
+		testName string
+		files    fstest.MapFS
+	}{
+		{"missing-down", fstest.MapFS{"20300101000000_add_users.up.sql": {Data: []byte("CREATE TABLE users ();")}}},
+		{"missing-up", fstest.MapFS{"20300101000000_add_users.down.sql": {Data: []byte("DROP TABLE users;")}}},
+		{"empty-up", fstest.MapFS{
+			"20300101000000_add_users.up.sql":   {Data: []byte(" \n")},
+			"20300101000000_add_users.down.sql": {Data: []byte("DROP TABLE users;")},
+		}},
+		{"misnamed", fstest.MapFS{"add_users.up.sql": {Data: []byte("CREATE TABLE users ();")}}},
+		{"fizz-name", fstest.MapFS{"20300101000000_add_users.postgres.up.sql": {Data: []byte("CREATE TABLE users ();")}}},
+		{"two-names", fstest.MapFS{
+			"20300101000000_add_users.up.sql":    {Data: []byte("CREATE TABLE users ();")},
+			"20300101000000_add_guests.down.sql": {Data: []byte("DROP TABLE users;")},
+		}},
+	} {
+		if _, err := Load(m.files); err == nil {
+			t.Errorf("Error Testing %s for loading the migrations, no error", m.testName)
+		}
+	}
+}
+
+func TestPlan(t *testing.T) {
+	migrations := []Migration{{Version: 1, Name: "a"}, {Version: 2, Name: "b"}, {Version: 3, Name: "c"}, {Version: 4, Name: "d"}}
+	names := func(ms []Migration) string {
+		var list []string
+		for _, m := range ms {
+			list = append(list, m.Name)
+		}
+		return strings.Join(list, ",")
+	}
+	for _, m := range []struct {
+		testName     string
+		applied      map[int64]bool
+		target       int64
+		wantApply    string
+		wantRollback string
+	}{
+		{"empty-database", nil, 4, "a,b,c,d", ""},
+		{"up-to-date", map[int64]bool{1: true, 2: true, 3: true, 4: true}, 4, "", ""},
+		{"missed-migration", map[int64]bool{1: true, 3: true}, 4, "b,d", ""},
+		{"to-version", map[int64]bool{1: true}, 2, "b", ""},
+		{"back-to-version", map[int64]bool{1: true, 2: true, 3: true, 4: true}, 2, "", "d,c"},
+		{"everything-back", map[int64]bool{1: true, 2: true, 3: true}, 0, "", "c,b,a"},
+		{"both-ways", map[int64]bool{1: true, 4: true}, 3, "b,c", "d"},
+		{"unknown-applied-version", map[int64]bool{1: true, 99: true}, 1, "", ""},
+	} {
+		apply, rollback := Plan(migrations, m.applied, m.target)
+		if names(apply) != m.wantApply || names(rollback) != m.wantRollback {
+			t.Errorf("Error Testing %s for the plan got apply %q and rollback %q", m.testName, names(apply), names(rollback))
+		}
+	}
+
+	applied := map[int64]bool{1: true, 2: true, 4: true}
+	if got := names(Latest(migrations, applied, 2)); got != "d,b" {
+		t.Errorf("Error Testing the last two migrations got %q", got)
+	}
+	if got := names(Latest(migrations, applied, 10)); got != "d,b,a" {
+		t.Errorf("Error Testing more steps than migrations got %q", got)
+	}
+	if got := Latest(migrations, nil, 1); len(got) != 0 {
+		t.Errorf("Error Testing the rollback of an empty database got %v", got)
+	}
+}
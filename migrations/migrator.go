@@ -0,0 +1,224 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+//Table the table the versions of the applied migrations are recorded in
+const Table = "schema_migrations"
+
+//lockID the advisory lock held while the schema changes, the instances deployed together migrate one at a time
+const lockID = 7305220311
+
+//Migrator applies and rolls back the migrations on the database, each one in its own transaction
+type Migrator struct {
+	DB         *sql.DB
+	Migrations []Migration
+}
+
+//State a migration and the time it was applied, zero while it is pending
+type State struct {
+	Migration
+	AppliedAt time.Time
+}
+
+//Applied returns true when the migration was applied
+func (s State) Applied() bool {
+	return !s.AppliedAt.IsZero()
+}
+
+//NewMigrator returns the migrator of the database with the migrations embedded in the binary
+func NewMigrator(db *sql.DB) (*Migrator, error) {
+	migrations, err := All()
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{DB: db, Migrations: migrations}, nil
+}
+
+//Status returns every migration, the oldest first, with the time it was applied
+func (m *Migrator) Status() ([]State, error) {
+	var states []State
+	err := m.locked(func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := appliedAt(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range m.Migrations {
+			states = append(states, State{Migration: migration, AppliedAt: applied[migration.Version]})
+		}
+		return nil
+	})
+	return states, err
+}
+
+//Up applies the pending migrations and returns them
+func (m *Migrator) Up() ([]Migration, error) {
+	if len(m.Migrations) == 0 {
+		return nil, nil
+	}
+	return m.To(m.Migrations[len(m.Migrations)-1].Version)
+}
+
+//Down rolls back the last applied migrations, steps of them, and returns them
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	var done []Migration
+	err := m.locked(func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := appliedAt(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, migration := range Latest(m.Migrations, isApplied(applied), steps) {
+			if err := run(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+//To applies the pending migrations up to the version and rolls back the ones after it, it returns the migrations
+//run. The version is the one of a migration or 0 to roll them all back
+func (m *Migrator) To(version int64) ([]Migration, error) {
+	known := version == 0
+	for _, migration := range m.Migrations {
+		known = known || migration.Version == version
+	}
+	if !known {
+		return nil, fmt.Errorf("no migration has the version %d", version)
+	}
+
+	var done []Migration
+	err := m.locked(func(ctx context.Context, conn *sql.Conn) error {
+		applied, err := appliedAt(ctx, conn)
+		if err != nil {
+			return err
+		}
+		apply, rollback := Plan(m.Migrations, isApplied(applied), version)
+		for _, migration := range rollback {
+			if err := run(ctx, conn, migration, false); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		for _, migration := range apply {
+			if err := run(ctx, conn, migration, true); err != nil {
+				return err
+			}
+			done = append(done, migration)
+		}
+		return nil
+	})
+	return done, err
+}
+
+//locked runs the function on a connection holding the lock of the migrations, the table of the versions
+//is created first
+func (m *Migrator) locked(fn func(ctx context.Context, conn *sql.Conn) error) error {
+	ctx := context.Background()
+	conn, err := m.DB.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	_, err = conn.ExecContext(ctx, `select pg_advisory_lock($1)`, lockID)
+	if err != nil {
+		return fmt.Errorf("cannot lock the migrations: %v", err)
+	}
+	defer conn.ExecContext(ctx, `select pg_advisory_unlock($1)`, lockID)
+
+	err = createTable(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return fn(ctx, conn)
+}
+
+//createTable creates the table of the versions. A database migrated by soda has its versions in schema_migration,
+//they are copied the first time so its migrations aren't applied again
+func createTable(ctx context.Context, conn *sql.Conn) error {
+	_, err := conn.ExecContext(ctx, `create table if not exists `+Table+` (
+                      version    bigint primary key,
+                      name       varchar(255) not null,
+                      applied_at timestamp not null)`)
+	if err != nil {
+		return fmt.Errorf("cannot create the %s table: %v", Table, err)
+	}
+
+	var soda sql.NullString
+	err = conn.QueryRowContext(ctx, `select to_regclass('schema_migration')::text`).Scan(&soda)
+	if err != nil || !soda.Valid {
+		return err
+	}
+	_, err = conn.ExecContext(ctx, `insert into `+Table+` (version, name, applied_at)
+              select cast(version as bigint), '', now() from schema_migration
+              where version ~ '^[0-9]{14}$' and not exists (select 1 from `+Table+`)`)
+	if err != nil {
+		return fmt.Errorf("cannot copy the versions of schema_migration: %v", err)
+	}
+	return nil
+}
+
+//appliedAt returns the time every applied migration was applied at by version
+func appliedAt(ctx context.Context, conn *sql.Conn) (map[int64]time.Time, error) {
+	rows, err := conn.QueryContext(ctx, `select version, applied_at from `+Table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	applied := make(map[int64]time.Time)
+	for rows.Next() {
+		var version int64
+		var at time.Time
+		if err := rows.Scan(&version, &at); err != nil {
+			return nil, err
+		}
+		applied[version] = at
+	}
+	return applied, rows.Err()
+}
+
+//isApplied returns the applied versions
+func isApplied(applied map[int64]time.Time) map[int64]bool {
+	versions := make(map[int64]bool, len(applied))
+	for version := range applied {
+		versions[version] = true
+	}
+	return versions
+}
+
+//run applies or rolls back the migration and records it in one transaction, a failing migration leaves
+//the schema as it was
+func run(ctx context.Context, conn *sql.Conn, migration Migration, up bool) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	statements, record := migration.Down, `delete from `+Table+` where version = $1`
+	if up {
+		statements = migration.Up
+		record = `insert into ` + Table + ` (version, name, applied_at) values ($1, $2, $3)`
+	}
+	_, err = tx.ExecContext(ctx, statements)
+	if err != nil {
+		return fmt.Errorf("migration %s failed: %v", migration, err)
+	}
+	if up {
+		_, err = tx.ExecContext(ctx, record, migration.Version, migration.Name, time.Now())
+	} else {
+		_, err = tx.ExecContext(ctx, record, migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("cannot record the migration %s: %v", migration, err)
+	}
+	return tx.Commit()
+}
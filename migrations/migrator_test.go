@@ -0,0 +1,112 @@
+package migrations
+
+import (
+	"database/sql"
+	"os"
+	"testing"
+
+	_ "github.com/jackc/pgx/v4/stdlib"
+)
+
+//testDSN the environment variable naming the empty PostgreSQL database the migrations are run on, the
+//database is left empty again
+const testDSN = "RESVBOOKING_TEST_DSN"
+
+//testMigrator returns the migrator of the test database, the test is skipped when no database is configured
+func testMigrator(t *testing.T) *Migrator {
+	dsn := os.Getenv(testDSN)
+	if dsn == "" {
+		t.Skipf("%s is not set, the migrations are not run on a database", testDSN)
+	}
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		db.Close()
+	})
+	m, err := NewMigrator(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return m
+}
+
+//appliedCount returns how many migrations the database has applied
+func appliedCount(t *testing.T, m *Migrator) int {
+	states, err := m.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	count := 0
+	for _, state := range states {
+		if state.Applied() {
+			count++
+		}
+	}
+	return count
+}
+
+//tableExists returns true when the table is in the database
+func tableExists(t *testing.T, m *Migrator, table string) bool {
+	var name sql.NullString
+	err := m.DB.QueryRow(`select to_regclass($1)::text`, table).Scan(&name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return name.Valid
+}
+
+func TestMigrator_Database(t *testing.T) {
+	m := testMigrator(t)
+	if count := appliedCount(t, m); count != 0 {
+		t.Fatalf("Error Testing the migrations, the database of %s already has %d migrations applied", testDSN, count)
+	}
+	t.Cleanup(func() {
+		if _, err := m.To(0); err != nil {
+			t.Errorf("Error Testing the migrations, the database can't be emptied: %v", err)
+		}
+		if _, err := m.DB.Exec(`drop table if exists ` + Table); err != nil {
+			t.Errorf("Error Testing the migrations, the %s table can't be dropped: %v", Table, err)
+		}
+	})
+	all := len(m.Migrations)
+
+	done, err := m.Up()
+	if err != nil {
+		t.Fatalf("Error Testing applying the migrations: %v", err)
+	}
+	if len(done) != all || appliedCount(t, m) != all || !tableExists(t, m, "waitlist") {
+		t.Fatalf("Error Testing applying the migrations, %d of %d applied", appliedCount(t, m), all)
+	}
+	if done, err = m.Up(); err != nil || len(done) != 0 {
+		t.Errorf("Error Testing applying the migrations again got %d run, %v", len(done), err)
+	}
+
+	//half of them are rolled back, the newest first
+	middle := m.Migrations[all/2-1]
+	done, err = m.To(middle.Version)
+	if err != nil {
+		t.Fatalf("Error Testing migrating to %s: %v", middle, err)
+	}
+	if len(done) != all-all/2 || done[0].Version != m.Migrations[all-1].Version || appliedCount(t, m) != all/2 {
+		t.Errorf("Error Testing migrating to %s got %d rolled back and %d applied", middle, len(done), appliedCount(t, m))
+	}
+
+	done, err = m.Down(all)
+	if err != nil {
+		t.Fatalf("Error Testing rolling back the migrations: %v", err)
+	}
+	if len(done) != all/2 || appliedCount(t, m) != 0 || tableExists(t, m, "reservation") {
+		t.Errorf("Error Testing rolling back the migrations got %d rolled back and %d applied", len(done), appliedCount(t, m))
+	}
+
+	//the schema rolled back can be built again
+	done, err = m.Up()
+	if err != nil {
+		t.Fatalf("Error Testing applying the migrations again: %v", err)
+	}
+	if len(done) != all || appliedCount(t, m) != all || !tableExists(t, m, "reservation") {
+		t.Errorf("Error Testing applying the migrations again, %d of %d applied", appliedCount(t, m), all)
+	}
+}
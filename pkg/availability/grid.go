@@ -0,0 +1,129 @@
+package availability
+
+import (
+	"sort"
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+//GridDays the number of nights shown on each side of the requested stay
+const GridDays = 7
+
+//SearchDays how far before and after the requested stay alternative dates are searched for
+const SearchDays = 14
+
+//MaxSuggestions the number of alternative date ranges offered to the guest
+const MaxSuggestions = 3
+
+//Cell a night of a room in the availability grid
+type Cell struct {
+	Night     time.Time
+	Occupied  bool
+	Requested bool
+}
+
+//Row the nights of a single room in the availability grid
+type Row struct {
+	Room  models.Room
+	Cells []Cell
+}
+
+//Grid the room-by-night availability around the requested stay
+type Grid struct {
+	Nights []time.Time
+	Rows   []Row
+}
+
+//Suggestion an alternative stay of the same length with the rooms free for all of its nights
+type Suggestion struct {
+	CheckInDate  time.Time
+	CheckOutDate time.Time
+	Rooms        []models.Room
+}
+
+//Window returns the first and the last (not included) night to fetch from the database so the grid and
+//the suggestions can be computed from a single query
+func Window(checkInDate, checkOutDate time.Time) (time.Time, time.Time) {
+	return checkInDate.AddDate(0, 0, -SearchDays), checkOutDate.AddDate(0, 0, SearchDays)
+}
+
+//BuildGrid lays out the occupied nights of every room from GridDays before the check-in date up to
+//GridDays after the check-out date
+func BuildGrid(rooms []models.RoomNights, checkInDate, checkOutDate time.Time) Grid {
+	var grid Grid
+	start := checkInDate.AddDate(0, 0, -GridDays)
+	end := checkOutDate.AddDate(0, 0, GridDays)
+
+	for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+		grid.Nights = append(grid.Nights, d)
+	}
+
+	for _, rn := range rooms {
+		row := Row{Room: rn.Room}
+		for _, night := range grid.Nights {
+			row.Cells = append(row.Cells, Cell{
+				Night:     night,
+				Occupied:  rn.Occupied[night.Format("2006-01-02")],
+				Requested: !night.Before(checkInDate) && night.Before(checkOutDate),
+			})
+		}
+		grid.Rows = append(grid.Rows, row)
+	}
+	return grid
+}
+
+//Suggest returns the nearest stays of the same length as the requested one, starting no earlier than
+//the earliest date, for which at least one room is free and allowed by its booking rules. The earliest
+//date is also the day the lead time and advance window of the rules are counted from
+func Suggest(rooms []models.RoomNights, rules map[int][]models.BookingRule, checkInDate, checkOutDate, earliest time.Time) []Suggestion {
+	var suggestions []Suggestion
+	nights := int(checkOutDate.Sub(checkInDate).Hours() / 24)
+	if nights <= 0 {
+		return suggestions
+	}
+
+	var offsets []int
+	for offset := -SearchDays; offset <= SearchDays; offset++ {
+		if offset != 0 {
+			offsets = append(offsets, offset)
+		}
+	}
+	//nearest first, a later stay wins over an earlier one at the same distance
+	sort.SliceStable(offsets, func(i, j int) bool {
+		a, b := abs(offsets[i]), abs(offsets[j])
+		if a != b {
+			return a < b
+		}
+		return offsets[i] > offsets[j]
+	})
+
+	for _, offset := range offsets {
+		start := checkInDate.AddDate(0, 0, offset)
+		if start.Before(earliest) {
+			continue
+		}
+		end := start.AddDate(0, 0, nights)
+
+		var free []models.Room
+		for _, rn := range rooms {
+			if rn.IsFree(start, end) && len(Violations(rules[rn.Room.ID], start, end, earliest)) == 0 {
+				free = append(free, rn.Room)
+			}
+		}
+		if len(free) > 0 {
+			suggestions = append(suggestions, Suggestion{CheckInDate: start, CheckOutDate: end, Rooms: free})
+		}
+		if len(suggestions) == MaxSuggestions {
+			break
+		}
+	}
+	return suggestions
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
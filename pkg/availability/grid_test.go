@@ -0,0 +1,90 @@
+package availability
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+func day(d int) time.Time {
+	return time.Date(2022, 9, d, 0, 0, 0, 0, time.UTC)
+}
+
+func occupied(from, to int) map[string]bool {
+	nights := make(map[string]bool)
+	for d := day(from); d.Before(day(to)); d = d.AddDate(0, 0, 1) {
+		nights[d.Format("2006-01-02")] = true
+	}
+	return nights
+}
+
+var testRooms = []models.RoomNights{
+	{Room: models.Room{ID: 1, RoomName: "Deluxe suite"}, Occupied: occupied(8, 13)},
+	{Room: models.Room{ID: 2, RoomName: "Junior Quarter's"}, Occupied: occupied(5, 11)},
+}
+
+func TestBuildGrid(t *testing.T) {
+	grid := BuildGrid(testRooms, day(10), day(12))
+
+	if len(grid.Nights) != 2+2*GridDays {
+		t.Fatalf("Error Testing the grid nights got %d wanted %d", len(grid.Nights), 2+2*GridDays)
+	}
+	if len(grid.Rows) != 2 {
+		t.Fatalf("Error Testing the grid rows got %d wanted 2", len(grid.Rows))
+	}
+	cell := grid.Rows[0].Cells[GridDays]
+	if !cell.Night.Equal(day(10)) || !cell.Requested || !cell.Occupied {
+		t.Errorf("Error Testing the first requested night got %+v", cell)
+	}
+	cell = grid.Rows[1].Cells[GridDays+1]
+	if !cell.Requested || cell.Occupied {
+		t.Errorf("Error Testing the free requested night got %+v", cell)
+	}
+	if grid.Rows[0].Cells[0].Requested {
+		t.Error("Error Testing a night outside the stay should not be requested")
+	}
+}
+
+var suggestTests = []struct {
+	testName     string
+	checkIn      time.Time
+	checkOut     time.Time
+	earliest     time.Time
+	correctFirst time.Time
+	correctRooms int
+	correctCount int
+}{
+	{"nearest later stay", day(10), day(12), day(1), day(11), 1, MaxSuggestions},
+	{"nearest earlier stay is skipped in the past", day(8), day(9), day(8), day(11), 1, MaxSuggestions},
+	{"nearest earlier stay", day(8), day(9), day(1), day(7), 1, MaxSuggestions},
+	{"no nights", day(10), day(10), day(1), time.Time{}, 0, 0},
+}
+
+func TestSuggest(t *testing.T) {
+	for _, s := range suggestTests {
+		suggestions := Suggest(testRooms, nil, s.checkIn, s.checkOut, s.earliest)
+		if len(suggestions) != s.correctCount {
+			t.Errorf("Error Testing %s got %d suggestions wanted %d", s.testName, len(suggestions), s.correctCount)
+			continue
+		}
+		if s.correctCount == 0 {
+			continue
+		}
+		first := suggestions[0]
+		if !first.CheckInDate.Equal(s.correctFirst) {
+			t.Errorf("Error Testing %s got first suggestion %v wanted %v", s.testName, first.CheckInDate, s.correctFirst)
+		}
+		if first.CheckOutDate.Sub(first.CheckInDate) != s.checkOut.Sub(s.checkIn) {
+			t.Errorf("Error Testing %s the suggested stay has a different length", s.testName)
+		}
+		if len(first.Rooms) != s.correctRooms {
+			t.Errorf("Error Testing %s got %d free rooms wanted %d", s.testName, len(first.Rooms), s.correctRooms)
+		}
+		for _, sg := range suggestions {
+			if sg.CheckInDate.Before(s.earliest) {
+				t.Errorf("Error Testing %s suggested a stay before %v", s.testName, s.earliest)
+			}
+		}
+	}
+}
@@ -0,0 +1,59 @@
+package availability
+
+import (
+	"github.com/dev-ayaa/resvbooking/pkg/i18n"
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+//Guests the adults and children staying in one of the rooms booked together
+type Guests struct {
+	Adults   int
+	Children int
+}
+
+//Holds returns true when the rooms together can hold the guests, a group searching for several rooms
+//finds the rooms no one of which holds them all
+func Holds(rooms []models.Room, adults, children int) bool {
+	var maxAdults, places int
+	for _, room := range rooms {
+		maxAdults += room.MaxAdults
+		places += room.MaxAdults + room.MaxChildren
+	}
+	return adults <= maxAdults && adults+children <= places
+}
+
+//SplitGuests shares the guests between the rooms booked together, in the order of the rooms. Every room
+//gets an adult, the other adults fill the adult places and the children the places left. It returns an
+//error when the rooms can't hold the guests
+func SplitGuests(rooms []models.Room, adults, children int) ([]Guests, error) {
+	if adults < len(rooms) {
+		return nil, i18n.NewMessage("every room needs an adult, %d rooms were selected for %d adults", len(rooms), adults)
+	}
+	for _, room := range rooms {
+		if room.MaxAdults < 1 {
+			return nil, i18n.NewMessage("%s has no place for an adult", room.RoomName)
+		}
+	}
+	if !Holds(rooms, adults, children) {
+		return nil, i18n.NewMessage("the selected rooms can't hold %d adults and %d children", adults, children)
+	}
+
+	shares := make([]Guests, len(rooms))
+	leftAdults, leftChildren := adults-len(rooms), children
+	for i, room := range rooms {
+		shares[i].Adults = 1 + minInt(leftAdults, room.MaxAdults-1)
+		leftAdults -= shares[i].Adults - 1
+	}
+	for i, room := range rooms {
+		shares[i].Children = minInt(leftChildren, room.MaxAdults+room.MaxChildren-shares[i].Adults)
+		leftChildren -= shares[i].Children
+	}
+	return shares, nil
+}
+
+func minInt(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
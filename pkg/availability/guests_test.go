@@ -0,0 +1,52 @@
+package availability
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+var double = models.Room{RoomName: "Double", MaxAdults: 2, MaxChildren: 1}
+var single = models.Room{RoomName: "Single", MaxAdults: 1}
+var cot = models.Room{RoomName: "Cot", MaxChildren: 1}
+
+var splitTests = []struct {
+	testName string
+	rooms    []models.Room
+	adults   int
+	children int
+	shares   []Guests
+}{
+	{"one room", []models.Room{double}, 2, 1, []Guests{{2, 1}}},
+	{"children take adult places", []models.Room{double}, 1, 2, []Guests{{1, 2}}},
+	{"family in two rooms", []models.Room{double, double}, 3, 2, []Guests{{2, 1}, {1, 1}}},
+	{"every room gets an adult", []models.Room{double, single}, 2, 0, []Guests{{1, 0}, {1, 0}}},
+	{"children fill the places left", []models.Room{single, double}, 3, 1, []Guests{{1, 0}, {2, 1}}},
+	{"too many adults", []models.Room{double, single}, 4, 0, nil},
+	{"too many guests", []models.Room{double, single}, 3, 2, nil},
+	{"more rooms than adults", []models.Room{double, double}, 1, 2, nil},
+	{"room without adult places", []models.Room{double, cot}, 2, 1, nil},
+}
+
+func TestSplitGuests(t *testing.T) {
+	for _, s := range splitTests {
+		shares, err := SplitGuests(s.rooms, s.adults, s.children)
+		if s.shares == nil && err == nil {
+			t.Errorf("Error Testing %s for splitting the guests, no error", s.testName)
+		}
+		if s.shares != nil && (err != nil || !reflect.DeepEqual(shares, s.shares)) {
+			t.Errorf("Error Testing %s for splitting the guests got %v %v", s.testName, shares, err)
+		}
+	}
+}
+
+func TestHolds(t *testing.T) {
+	rooms := []models.Room{double, single}
+	if !Holds(rooms, 3, 1) || Holds(rooms, 4, 0) || Holds(rooms, 2, 3) {
+		t.Errorf("Error Testing the combined capacity of the rooms")
+	}
+	if !Holds(nil, 0, 0) || Holds(nil, 1, 0) {
+		t.Errorf("Error Testing the capacity of no room")
+	}
+}
@@ -0,0 +1,92 @@
+package availability
+
+import (
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/i18n"
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+//Exclusion a room free for the stay which the booking rules don't allow to book
+type Exclusion struct {
+	Room    models.Room
+	Reasons []i18n.Message
+}
+
+//Violations returns why the stay breaks the booking rules of a room, nothing when the room may be booked.
+//The nights, closed-to-arrival, lead time and advance window of the rules covering the arrival date apply,
+//closed-to-departure of the rules covering the departure date. When several rules cover a date the
+//strictest one wins
+func Violations(rules []models.BookingRule, checkInDate, checkOutDate, today time.Time) []i18n.Message {
+	var reasons []i18n.Message
+	var minNights, maxNights, leadDays, maxAdvanceDays int
+	var closedToArrival, closedToDeparture bool
+
+	for _, rule := range rules {
+		if rule.Covers(checkInDate) {
+			minNights = maxInt(minNights, rule.MinNights)
+			maxNights = minLimit(maxNights, rule.MaxNights)
+			leadDays = maxInt(leadDays, rule.LeadDays)
+			maxAdvanceDays = minLimit(maxAdvanceDays, rule.MaxAdvanceDays)
+			closedToArrival = closedToArrival || rule.ClosedToArrival
+		}
+		if rule.Covers(checkOutDate) {
+			closedToDeparture = closedToDeparture || rule.ClosedToDeparture
+		}
+	}
+
+	arrival := checkInDate.Format("2006-01-02")
+	nights := int(checkOutDate.Sub(checkInDate).Hours() / 24)
+	daysAhead := int(checkInDate.Sub(today).Hours() / 24)
+
+	if closedToArrival {
+		reasons = append(reasons, i18n.NewMessage("arrivals are closed on %s", arrival))
+	}
+	if closedToDeparture {
+		reasons = append(reasons, i18n.NewMessage("departures are closed on %s", checkOutDate.Format("2006-01-02")))
+	}
+	if minNights > 0 && nights < minNights {
+		reasons = append(reasons, i18n.NewMessage("stays arriving on %s must be at least %d nights", arrival, minNights))
+	}
+	if maxNights > 0 && nights > maxNights {
+		reasons = append(reasons, i18n.NewMessage("stays arriving on %s can't be longer than %d nights", arrival, maxNights))
+	}
+	if leadDays > 0 && daysAhead < leadDays {
+		reasons = append(reasons, i18n.NewMessage("stays arriving on %s must be booked at least %d days ahead", arrival, leadDays))
+	}
+	if maxAdvanceDays > 0 && daysAhead > maxAdvanceDays {
+		reasons = append(reasons, i18n.NewMessage("stays arriving on %s can't be booked more than %d days ahead", arrival, maxAdvanceDays))
+	}
+	return reasons
+}
+
+//ApplyRules splits the rooms free for the stay between the rooms which may be booked and the ones the
+//booking rules exclude, the rules are keyed by room id
+func ApplyRules(rooms []models.Room, rules map[int][]models.BookingRule, checkInDate, checkOutDate, today time.Time) ([]models.Room, []Exclusion) {
+	var bookable []models.Room
+	var excluded []Exclusion
+	for _, room := range rooms {
+		reasons := Violations(rules[room.ID], checkInDate, checkOutDate, today)
+		if len(reasons) > 0 {
+			excluded = append(excluded, Exclusion{Room: room, Reasons: reasons})
+			continue
+		}
+		bookable = append(bookable, room)
+	}
+	return bookable, excluded
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+//minLimit returns the smallest of two limits where 0 means no limit
+func minLimit(a, b int) int {
+	if a == 0 || (b != 0 && b < a) {
+		return b
+	}
+	return a
+}
@@ -0,0 +1,76 @@
+package availability
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+var testRules = []models.BookingRule{
+	{RoomID: 1, StartDate: day(1), EndDate: day(15), MinNights: 2, MaxNights: 7},
+	{RoomID: 1, StartDate: day(10), EndDate: day(12), MinNights: 3},
+	{RoomID: 1, StartDate: day(20), EndDate: day(20), ClosedToArrival: true},
+	{RoomID: 1, StartDate: day(22), EndDate: day(22), ClosedToDeparture: true},
+	{RoomID: 1, StartDate: day(25), EndDate: day(30), LeadDays: 3, MaxAdvanceDays: 10},
+}
+
+var violationTests = []struct {
+	testName       string
+	checkIn        time.Time
+	checkOut       time.Time
+	today          time.Time
+	correctReasons []string
+}{
+	{"allowed stay", day(2), day(5), day(1), nil},
+	{"too short", day(2), day(3), day(1), []string{"must be at least 2 nights"}},
+	{"strictest minimum wins", day(11), day(13), day(1), []string{"must be at least 3 nights"}},
+	{"too long", day(2), day(10), day(1), []string{"can't be longer than 7 nights"}},
+	{"closed to arrival", day(20), day(21), day(1), []string{"arrivals are closed on 2022-09-20"}},
+	{"closed to departure", day(21), day(22), day(1), []string{"departures are closed on 2022-09-22"}},
+	{"departure rule ignores arrivals", day(22), day(23), day(1), nil},
+	{"lead time", day(26), day(27), day(24), []string{"must be booked at least 3 days ahead"}},
+	{"advance window", day(26), day(27), day(10), []string{"can't be booked more than 10 days ahead"}},
+	{"within the booking window", day(26), day(27), day(20), nil},
+	{"no rules after the range", time.Date(2022, 10, 5, 0, 0, 0, 0, time.UTC), time.Date(2022, 10, 6, 0, 0, 0, 0, time.UTC), day(1), nil},
+}
+
+func TestViolations(t *testing.T) {
+	for _, v := range violationTests {
+		reasons := Violations(testRules, v.checkIn, v.checkOut, v.today)
+		if len(reasons) != len(v.correctReasons) {
+			t.Errorf("Error Testing %s got reasons %q wanted %q", v.testName, reasons, v.correctReasons)
+			continue
+		}
+		for i := range reasons {
+			if !strings.Contains(reasons[i].Error(), v.correctReasons[i]) {
+				t.Errorf("Error Testing %s got reason %q wanted %q", v.testName, reasons[i], v.correctReasons[i])
+			}
+		}
+	}
+}
+
+func TestApplyRules(t *testing.T) {
+	rooms := []models.Room{{ID: 1, RoomName: "Deluxe suite"}, {ID: 2, RoomName: "Junior Quarter's"}}
+	rules := map[int][]models.BookingRule{1: testRules}
+
+	bookable, excluded := ApplyRules(rooms, rules, day(2), day(3), day(1))
+	if len(bookable) != 1 || bookable[0].ID != 2 {
+		t.Errorf("Error Testing the bookable rooms got %+v", bookable)
+	}
+	if len(excluded) != 1 || excluded[0].Room.ID != 1 || len(excluded[0].Reasons) != 1 {
+		t.Errorf("Error Testing the excluded rooms got %+v", excluded)
+	}
+}
+
+func TestSuggest_Rules(t *testing.T) {
+	//the junior quarter's is free from the 11th but doesn't take arrivals that day
+	rules := map[int][]models.BookingRule{
+		2: {{RoomID: 2, StartDate: day(11), EndDate: day(11), ClosedToArrival: true}},
+	}
+	suggestions := Suggest(testRooms, rules, day(10), day(12), day(1))
+	if len(suggestions) == 0 || !suggestions[0].CheckInDate.Equal(day(12)) {
+		t.Errorf("Error Testing the suggestions with booking rules got %+v", suggestions)
+	}
+}
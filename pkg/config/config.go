@@ -2,7 +2,11 @@ package config
 
 import (
 	"github.com/alexedwards/scs/v2"
+	"github.com/dev-ayaa/resvbooking/pkg/currency"
+	"github.com/dev-ayaa/resvbooking/pkg/dates"
 	"github.com/dev-ayaa/resvbooking/pkg/models"
+	"github.com/dev-ayaa/resvbooking/pkg/payments"
+	"github.com/dev-ayaa/resvbooking/pkg/storage"
 	"html/template"
 	"log"
 )
@@ -19,4 +23,13 @@ type AppConfig struct {
 	InProduction bool
 	Session      *scs.SessionManager
 	MailChannel  chan models.MailData
+	Storage      storage.Storage
+	Payments     payments.Provider
+	//Rates the currency of the property and the exchange rates the prices are displayed with
+	Rates *currency.Rates
+	//Property the timezone of the property the dates are read in and its check-in and check-out times
+	Property *dates.Property
+	//MaxStayNights the longest stay and BookingHorizon the days ahead a stay can be booked, no limit when 0
+	MaxStayNights  int
+	BookingHorizon int
 }
@@ -0,0 +1,158 @@
+package currency
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"math"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+//codePattern the ISO 4217 codes of the currencies
+var codePattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+//ValidCode returns true when the code looks like a currency code, EUR or GBP
+func ValidCode(code string) bool {
+	return codePattern.MatchString(code)
+}
+
+//ParseRate reads an exchange rate, it must be a positive and finite number
+func ParseRate(value string) (float64, error) {
+	rate, err := strconv.ParseFloat(strings.TrimSpace(value), 64)
+	if err != nil || rate <= 0 || math.IsNaN(rate) || math.IsInf(rate, 0) {
+		return 0, fmt.Errorf("invalid rate %q", value)
+	}
+	return rate, nil
+}
+
+//Format returns the amount in cents followed by its currency, "108.45 EUR"
+func Format(cents int, code string) string {
+	return models.FormatAmount(cents) + " " + code
+}
+
+//Rates the exchange rates from the currency of the property, the rooms are priced and charged in the
+//base currency and the rates only change how the prices are displayed. The pages read them on every
+//request so they are kept in memory and replaced whenever the table of the rates changes
+type Rates struct {
+	mu    sync.RWMutex
+	base  string
+	rates map[string]float64
+}
+
+//NewRates returns the rates of the base currency, with no other currency until they are set
+func NewRates(base string) *Rates {
+	return &Rates{base: base, rates: make(map[string]float64)}
+}
+
+//Base returns the currency of the property
+func (r *Rates) Base() string {
+	return r.base
+}
+
+//Set replaces the rates with the stored ones, a rate of the base currency is ignored
+func (r *Rates) Set(rates []models.ExchangeRate) {
+	byCode := make(map[string]float64)
+	for _, rate := range rates {
+		if rate.Currency != r.base && rate.Rate > 0 {
+			byCode[rate.Currency] = rate.Rate
+		}
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.rates = byCode
+}
+
+//Known returns true when prices can be displayed in the currency
+func (r *Rates) Known(code string) bool {
+	if code == r.base {
+		return true
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	_, ok := r.rates[code]
+	return ok
+}
+
+//Codes returns the currencies prices can be displayed in, the base currency first
+func (r *Rates) Codes() []string {
+	r.mu.RLock()
+	var codes []string
+	for code := range r.rates {
+		codes = append(codes, code)
+	}
+	r.mu.RUnlock()
+	sort.Strings(codes)
+	return append([]string{r.base}, codes...)
+}
+
+//Convert returns the amount in cents of the base currency in the currency, rounded to the cent. An
+//unknown currency leaves the amount in the base currency, the currency used is returned
+func (r *Rates) Convert(cents int, code string) (int, string) {
+	r.mu.RLock()
+	rate, ok := r.rates[code]
+	r.mu.RUnlock()
+	if !ok {
+		return cents, r.base
+	}
+	return int(math.Round(float64(cents) * rate)), code
+}
+
+//Format returns the amount in cents of the base currency displayed in the currency
+func (r *Rates) Format(cents int, code string) string {
+	return Format(r.Convert(cents, code))
+}
+
+//ParseRates reads a file of exchange rates, a "currency,rate" header followed by a line per currency
+//with the value of one unit of the base currency in it
+func ParseRates(rd io.Reader) ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+
+	reader := csv.NewReader(rd)
+	reader.TrimLeadingSpace = true
+	reader.FieldsPerRecord = 2
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return rates, fmt.Errorf("the rates file is empty")
+	}
+	if err != nil {
+		return rates, err
+	}
+	if !strings.EqualFold(header[0], "currency") || !strings.EqualFold(header[1], "rate") {
+		return rates, fmt.Errorf("the rates file must start with the header currency,rate")
+	}
+
+	seen := make(map[string]bool)
+	for line := 2; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return rates, err
+		}
+		code := strings.ToUpper(strings.TrimSpace(record[0]))
+		if !ValidCode(code) {
+			return rates, fmt.Errorf("line %d: invalid currency %q", line, record[0])
+		}
+		if seen[code] {
+			return rates, fmt.Errorf("line %d: the rate of %s is repeated", line, code)
+		}
+		seen[code] = true
+		rate, err := ParseRate(record[1])
+		if err != nil {
+			return rates, fmt.Errorf("line %d: the rate of %s must be a positive number", line, code)
+		}
+		rates = append(rates, models.ExchangeRate{Currency: code, Rate: rate})
+	}
+	if len(rates) == 0 {
+		return rates, fmt.Errorf("the rates file has no rate")
+	}
+	return rates, nil
+}
@@ -0,0 +1,69 @@
+package currency
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+func TestRates(t *testing.T) {
+	r := NewRates("USD")
+	r.Set([]models.ExchangeRate{{Currency: "EUR", Rate: 0.92}, {Currency: "GBP", Rate: 0.786}, {Currency: "USD", Rate: 2}})
+
+	if codes := r.Codes(); strings.Join(codes, ",") != "USD,EUR,GBP" {
+		t.Errorf("Error Testing the currencies got %v", codes)
+	}
+	if !r.Known("USD") || !r.Known("EUR") || r.Known("JPY") {
+		t.Error("Error Testing the known currencies")
+	}
+	for _, m := range []struct {
+		cents int
+		code  string
+		want  string
+	}{
+		{12000, "USD", "120.00 USD"},
+		{12000, "EUR", "110.40 EUR"},
+		{12345, "GBP", "97.03 GBP"},
+		{12000, "JPY", "120.00 USD"},
+		{12000, "", "120.00 USD"},
+	} {
+		if got := r.Format(m.cents, m.code); got != m.want {
+			t.Errorf("Error Testing %d in %q expected %s got %s", m.cents, m.code, m.want, got)
+		}
+	}
+}
+
+func TestParseRate(t *testing.T) {
+	if rate, err := ParseRate(" 0.92 "); err != nil || rate != 0.92 {
+		t.Errorf("Error Testing a valid rate got %v %v", rate, err)
+	}
+	for _, value := range []string{"", "slim", "0", "-1", "NaN", "nan", "Inf", "+Inf", "-Inf", "infinity"} {
+		if _, err := ParseRate(value); err == nil {
+			t.Errorf("Error Testing the rate %q, no error", value)
+		}
+	}
+}
+
+func TestParseRates(t *testing.T) {
+	rates, err := ParseRates(strings.NewReader("currency,rate\neur, 0.92\nGBP,0.786\n"))
+	if err != nil || len(rates) != 2 || rates[0].Currency != "EUR" || rates[1].Rate != 0.786 {
+		t.Errorf("Error Testing a valid rates file got %+v %v", rates, err)
+	}
+
+	for name, file := range map[string]string{
+		"empty":         "",
+		"no-header":     "EUR,0.92\n",
+		"no-rate":       "currency,rate\n",
+		"invalid-code":  "currency,rate\nEURO,0.92\n",
+		"negative-rate": "currency,rate\nEUR,-1\n",
+		"nan-rate":      "currency,rate\nEUR,NaN\n",
+		"infinite-rate": "currency,rate\nEUR,+Inf\n",
+		"repeated":      "currency,rate\nEUR,0.92\nEUR,0.93\n",
+		"missing-field": "currency,rate\nEUR\n",
+	} {
+		if _, err := ParseRates(strings.NewReader(file)); err == nil {
+			t.Errorf("Error Testing the %s rates file, no error", name)
+		}
+	}
+}
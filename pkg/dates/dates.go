@@ -0,0 +1,130 @@
+package dates
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	//the zones are embedded so the property timezone loads on hosts without a timezone database
+	_ "time/tzdata"
+)
+
+//Layout the dates typed in the forms and shown in the pages, 2006-01-02
+const Layout = "2006-01-02"
+
+//The dates of the stays are calendar days, they are kept as the midnight of the day in UTC whatever the
+//timezone of the property so they are compared with each other and stored in the date columns without
+//moving to the day before or after. The instants, like the time a reservation is made, are real times
+//and only become a day through the timezone of the property
+
+//Parse reads a calendar day typed as 2006-01-02
+func Parse(value string) (time.Time, error) {
+	return time.Parse(Layout, strings.TrimSpace(value))
+}
+
+//Day returns the calendar day of the time in its own location
+func Day(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
+
+//Clock a time of the day, the check-in from 15:00
+type Clock struct {
+	Hour   int
+	Minute int
+}
+
+//ParseClock reads a time of the day typed as 15:04
+func ParseClock(value string) (Clock, error) {
+	t, err := time.Parse("15:04", strings.TrimSpace(value))
+	if err != nil {
+		return Clock{}, fmt.Errorf("invalid time of the day %q, use the format HH:MM", value)
+	}
+	return Clock{Hour: t.Hour(), Minute: t.Minute()}, nil
+}
+
+//String returns the time of the day as 15:04
+func (c Clock) String() string {
+	return fmt.Sprintf("%02d:%02d", c.Hour, c.Minute)
+}
+
+//Property the timezone of the property and the times the guests arrive from and leave by
+type Property struct {
+	location *time.Location
+	checkIn  Clock
+	checkOut Clock
+	clock    func() time.Time
+}
+
+//NewProperty returns the property in the named timezone, "Europe/Paris", with its check-in and check-out times
+func NewProperty(zone, checkIn, checkOut string) (*Property, error) {
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		return nil, fmt.Errorf("unknown timezone %q", zone)
+	}
+	in, err := ParseClock(checkIn)
+	if err != nil {
+		return nil, err
+	}
+	out, err := ParseClock(checkOut)
+	if err != nil {
+		return nil, err
+	}
+	return &Property{location: location, checkIn: in, checkOut: out}, nil
+}
+
+//Location returns the timezone of the property
+func (p *Property) Location() *time.Location {
+	return p.location
+}
+
+//CheckIn returns the time the guests can arrive from
+func (p *Property) CheckIn() Clock {
+	return p.checkIn
+}
+
+//CheckOut returns the time the guests leave the room by
+func (p *Property) CheckOut() Clock {
+	return p.checkOut
+}
+
+//Date returns the calendar day of the instant at the property
+func (p *Property) Date(instant time.Time) time.Time {
+	return Day(instant.In(p.location))
+}
+
+//SetClock replaces the clock the current instant is read from, the wall clock when nil
+func (p *Property) SetClock(clock func() time.Time) {
+	p.clock = clock
+}
+
+//Now returns the current instant
+func (p *Property) Now() time.Time {
+	if p.clock != nil {
+		return p.clock()
+	}
+	return time.Now()
+}
+
+//Today returns the current calendar day at the property
+func (p *Property) Today() time.Time {
+	return p.Date(p.Now())
+}
+
+//Local returns the instant on the clock of the property
+func (p *Property) Local(instant time.Time) time.Time {
+	return instant.In(p.location)
+}
+
+//CheckInAt returns the instant the guests arriving on the day can get in the room
+func (p *Property) CheckInAt(day time.Time) time.Time {
+	return p.at(day, p.checkIn)
+}
+
+//CheckOutAt returns the instant the guests leaving on the day give the room back
+func (p *Property) CheckOutAt(day time.Time) time.Time {
+	return p.at(day, p.checkOut)
+}
+
+func (p *Property) at(day time.Time, clock Clock) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), clock.Hour, clock.Minute, 0, 0, p.location)
+}
@@ -0,0 +1,92 @@
+package dates
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	day, err := Parse(" 2030-01-10 ")
+	if err != nil || !day.Equal(time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Error Testing the parsed day got %v %v", day, err)
+	}
+	if _, err := Parse("10/01/2030"); err == nil {
+		t.Error("Error Testing an invalid day, no error")
+	}
+}
+
+func TestParseClock(t *testing.T) {
+	clock, err := ParseClock("09:30")
+	if err != nil || clock != (Clock{Hour: 9, Minute: 30}) || clock.String() != "09:30" {
+		t.Errorf("Error Testing the clock got %v %v", clock, err)
+	}
+	for _, value := range []string{"", "25:00", "3pm"} {
+		if _, err := ParseClock(value); err == nil {
+			t.Errorf("Error Testing the invalid clock %q, no error", value)
+		}
+	}
+}
+
+func TestNewProperty(t *testing.T) {
+	if _, err := NewProperty("Mars/Olympus", "15:00", "11:00"); err == nil {
+		t.Error("Error Testing an unknown timezone, no error")
+	}
+	if _, err := NewProperty("UTC", "15h", "11:00"); err == nil {
+		t.Error("Error Testing an invalid check-in time, no error")
+	}
+}
+
+//TestProperty_Date the day at the property differs from the day in UTC around midnight
+func TestProperty_Date(t *testing.T) {
+	tokyo, err := NewProperty("Asia/Tokyo", "15:00", "11:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	honolulu, err := NewProperty("Pacific/Honolulu", "15:00", "11:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	instant := time.Date(2030, 1, 10, 20, 0, 0, 0, time.UTC)
+
+	if got := tokyo.Date(instant); !got.Equal(time.Date(2030, 1, 11, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Error Testing the day in Tokyo got %v", got)
+	}
+	if got := honolulu.Date(instant); !got.Equal(time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Error Testing the day in Honolulu got %v", got)
+	}
+	if got := honolulu.Date(instant.Add(12 * time.Hour)); !got.Equal(time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Error Testing the day in Honolulu after midnight in UTC got %v", got)
+	}
+	if got := Day(time.Date(2030, 1, 10, 23, 30, 0, 0, tokyo.Location())); !got.Equal(time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Error Testing the calendar day of a local time got %v", got)
+	}
+}
+
+func TestProperty_CheckInAt(t *testing.T) {
+	p, err := NewProperty("Europe/Paris", "15:00", "11:30")
+	if err != nil {
+		t.Fatal(err)
+	}
+	day := time.Date(2030, 7, 10, 0, 0, 0, 0, time.UTC)
+
+	if got := p.CheckInAt(day); !got.Equal(time.Date(2030, 7, 10, 13, 0, 0, 0, time.UTC)) {
+		t.Errorf("Error Testing the check-in instant got %v", got)
+	}
+	if got := p.CheckOutAt(day); !got.Equal(time.Date(2030, 7, 10, 9, 30, 0, 0, time.UTC)) {
+		t.Errorf("Error Testing the check-out instant got %v", got)
+	}
+	if got := p.Local(time.Date(2030, 7, 10, 22, 30, 0, 0, time.UTC)).Format("2006-01-02 15:04"); got != "2030-07-11 00:30" {
+		t.Errorf("Error Testing the local time got %v", got)
+	}
+}
+
+func TestProperty_Today(t *testing.T) {
+	p, err := NewProperty("America/New_York", "15:00", "11:00")
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.SetClock(func() time.Time { return time.Date(2030, 1, 11, 3, 0, 0, 0, time.UTC) })
+	if got := p.Today(); !got.Equal(time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("Error Testing today in New York got %v", got)
+	}
+}
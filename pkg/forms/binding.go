@@ -0,0 +1,177 @@
+package forms
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//The structs are bound to the forms through the tags of their fields, the name of the form field and the rules
+//its value follows:
+//
+//	FirstName string `form:"first-name" rules:"required,min=3,max=255"`
+//
+//The rules are required, min=3 and max=255 for the number of characters, range=1:4 for a whole number,
+//email, phone, localphone, date, oneof=percent|first_night and sameas=password
+
+//Bind checks the tagged fields of the form with their rules and copies the valid values into the struct dst
+//points to. The rules run first so the struct gets the values they normalise, like the phone numbers, and a
+//field left empty or invalid keeps the value it had. The error feedbacks are kept in the form for re-rendering,
+//the error returned is a struct which can't be bound, a mistake of the code
+func (f *Form) Bind(dst interface{}) error {
+	target := reflect.ValueOf(dst)
+	if target.Kind() != reflect.Ptr || target.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("cannot bind the form to %T, a pointer to a struct is expected", dst)
+	}
+	target = target.Elem()
+	for i := 0; i < target.NumField(); i++ {
+		field := target.Type().Field(i)
+		formField := field.Tag.Get("form")
+		if formField == "" || formField == "-" {
+			continue
+		}
+		value := target.Field(i)
+		if !value.CanSet() || !bindable(value.Type()) {
+			return fmt.Errorf("cannot bind the form field %s to %s of type %s", formField, field.Name, value.Type())
+		}
+		rules, err := parseRules(field.Tag.Get("rules"))
+		if err != nil {
+			return fmt.Errorf("cannot bind the form field %s to %s: %v", formField, field.Name, err)
+		}
+
+		if !f.Check(formField, rules...) || strings.TrimSpace(f.Get(formField)) == "" {
+			continue
+		}
+		switch value.Kind() {
+		case reflect.String:
+			value.SetString(strings.TrimSpace(f.Get(formField)))
+		case reflect.Int:
+			if n := f.Int(formField); f.Error.Get(formField) == "" {
+				value.SetInt(int64(n))
+			}
+		default:
+			if day := f.Date(formField); f.Error.Get(formField) == "" {
+				value.Set(reflect.ValueOf(day))
+			}
+		}
+	}
+	return nil
+}
+
+//bindable returns true for the types the form values are read into, the text, the whole numbers and the days
+func bindable(t reflect.Type) bool {
+	return t.Kind() == reflect.String || t.Kind() == reflect.Int || t == reflect.TypeOf(time.Time{})
+}
+
+//parseRules reads the rules of a field tag, "required,min=3"
+func parseRules(tag string) ([]Rule, error) {
+	var rules []Rule
+	for _, spec := range strings.Split(tag, ",") {
+		spec = strings.TrimSpace(spec)
+		if spec == "" {
+			continue
+		}
+		name, arg := spec, ""
+		if i := strings.Index(spec, "="); i >= 0 {
+			name, arg = spec[:i], spec[i+1:]
+		}
+		switch name {
+		case "required":
+			rules = append(rules, Required())
+		case "email":
+			rules = append(rules, Email())
+		case "phone":
+			rules = append(rules, Phone())
+		case "localphone":
+			rules = append(rules, LocalPhone())
+		case "date":
+			rules = append(rules, Date())
+		case "min", "max":
+			n, err := strconv.Atoi(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid rule %q, a number of characters is expected", spec)
+			}
+			if name == "min" {
+				rules = append(rules, MinLength(n))
+			} else {
+				rules = append(rules, MaxLength(n))
+			}
+		case "range":
+			bounds := strings.SplitN(arg, ":", 2)
+			if len(bounds) != 2 {
+				return nil, fmt.Errorf("invalid rule %q, use range=min:max", spec)
+			}
+			min, minErr := strconv.Atoi(bounds[0])
+			max, maxErr := strconv.Atoi(bounds[1])
+			if minErr != nil || maxErr != nil || min > max {
+				return nil, fmt.Errorf("invalid rule %q, use range=min:max", spec)
+			}
+			rules = append(rules, IntRange(min, max))
+		case "oneof":
+			if arg == "" {
+				return nil, fmt.Errorf("invalid rule %q, the choices are missing", spec)
+			}
+			rules = append(rules, OneOf(strings.Split(arg, "|")...))
+		case "sameas":
+			if arg == "" {
+				return nil, fmt.Errorf("invalid rule %q, the other field is missing", spec)
+			}
+			rules = append(rules, SameAs(arg))
+		default:
+			return nil, fmt.Errorf("unknown rule %q", spec)
+		}
+	}
+	return rules, nil
+}
+
+//ParseJSON reads a JSON object into form values so it is bound and checked like a form, the keys are the names
+//of the form fields. The numbers and booleans become their text, a null is a field left empty
+func ParseJSON(body io.Reader) (url.Values, error) {
+	var object map[string]interface{}
+	err := json.NewDecoder(body).Decode(&object)
+	if err == io.EOF {
+		return url.Values{}, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read the JSON body: %v", err)
+	}
+
+	values := url.Values{}
+	for key, value := range object {
+		switch v := value.(type) {
+		case nil:
+		case string:
+			values.Set(key, v)
+		case float64:
+			values.Set(key, strconv.FormatFloat(v, 'f', -1, 64))
+		case bool:
+			values.Set(key, strconv.FormatBool(v))
+		default:
+			return nil, fmt.Errorf("cannot read the JSON field %s, a single value is expected", key)
+		}
+	}
+	return values, nil
+}
+
+//ParseRequest returns the values sent with the request, the JSON object of a JSON body else the form and the
+//query values
+func ParseRequest(rq *http.Request) (url.Values, error) {
+	mediaType, _, _ := mime.ParseMediaType(rq.Header.Get("Content-Type"))
+	if mediaType == "application/json" {
+		if rq.Body == nil {
+			return url.Values{}, nil
+		}
+		return ParseJSON(rq.Body)
+	}
+	if err := rq.ParseForm(); err != nil {
+		return nil, err
+	}
+	return rq.Form, nil
+}
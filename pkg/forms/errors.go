@@ -18,3 +18,12 @@ func (e errors) Get(formField string) string {
 	//return the value in the slices of string
 	return e[formField][0]
 }
+
+//Messages returns the first error feedback of every invalid form field, the way the JSON clients get them
+func (e errors) Messages() map[string]string {
+	messages := make(map[string]string, len(e))
+	for formField := range e {
+		messages[formField] = e.Get(formField)
+	}
+	return messages
+}
@@ -1,31 +1,53 @@
 package forms
 
 import (
-	"fmt"
 	"github.com/asaskevich/govalidator"
+	"github.com/dev-ayaa/resvbooking/pkg/dates"
+	"github.com/dev-ayaa/resvbooking/pkg/i18n"
 	"net/http"
 	"net/url"
+	"regexp"
+	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 )
 
+//promoCodePattern the promo codes are letters, digits and dashes
+var promoCodePattern = regexp.MustCompile(`^[A-Za-z0-9-]{3,20}$`)
+
 type Form struct {
 	url.Values // data typed in the form
 	Error      errors
+	Locale     string // the locale the error feedbacks are written in, english when empty
 }
 
 // NewForm Initialize a form of type struct
 func NewForm(data url.Values) *Form {
 	return &Form{
-		data,
-		errors(map[string][]string{}),
+		Values: data,
+		Error:  errors(map[string][]string{}),
 	}
 }
 
+//NewLocalizedForm Initialize a form whose error feedbacks are translated in the locale
+func NewLocalizedForm(data url.Values, locale string) *Form {
+	form := NewForm(data)
+	form.Locale = locale
+	return form
+}
+
+//AddError sets the error feedback of the form field in the locale of the form, the message is the english
+//one of the catalogue and is formatted with the arguments
+func (f *Form) AddError(formField, message string, args ...interface{}) {
+	f.Error.Set(formField, i18n.Translate(f.Locale, message, args...))
+}
+
 func (f *Form) Require(formField ...string) {
 	for _, field := range formField {
 		value := f.Get(field)
 		if strings.TrimSpace(value) == "" {
-			f.Error.Set(field, "This field can't be blank")
+			f.AddError(field, "This field can't be blank")
 		}
 	}
 }
@@ -35,13 +57,52 @@ func (f *Form) HasForm(formField string) bool {
 	/*This to check if the form input has value or not*/
 	checkForm := f.Get(formField)
 	if checkForm == "" {
-		f.Error.Set(formField, "this field cannot be blank")
+		f.AddError(formField, "this field cannot be blank")
 		return false
 	}
 	return true
 
 }
 
+//Int returns the whole number typed in the form field, a value which isn't one sets the error feedback
+//of the field unless it already has one and returns 0
+func (f *Form) Int(formField string) int {
+	n, err := strconv.Atoi(strings.TrimSpace(f.Get(formField)))
+	if err != nil {
+		f.addFirstError(formField, "Enter a whole number")
+		return 0
+	}
+	return n
+}
+
+//Int64 returns the whole number typed in the form field like Int, for the numbers too large for an int
+func (f *Form) Int64(formField string) int64 {
+	n, err := strconv.ParseInt(strings.TrimSpace(f.Get(formField)), 10, 64)
+	if err != nil {
+		f.addFirstError(formField, "Enter a whole number")
+		return 0
+	}
+	return n
+}
+
+//Date returns the day typed in the form field as 2006-01-02, a value which isn't one sets the error feedback
+//of the field unless it already has one and returns the zero time
+func (f *Form) Date(formField string) time.Time {
+	day, err := dates.Parse(f.Get(formField))
+	if err != nil {
+		f.addFirstError(formField, "Invalid date, use the format YYYY-MM-DD")
+		return time.Time{}
+	}
+	return day
+}
+
+//addFirstError sets the error feedback of the field when the rules didn't set one already
+func (f *Form) addFirstError(formField, message string, args ...interface{}) {
+	if f.Error.Get(formField) == "" {
+		f.AddError(formField, message, args...)
+	}
+}
+
 // FormValid Validate the forms values
 func (f *Form) FormValid() bool {
 
@@ -49,10 +110,10 @@ func (f *Form) FormValid() bool {
 }
 
 // ValidLenCharacter This check for valid minimum length of input character in the form field
-func (f *Form) ValidLenCharacter(formField string, CharLen int, rq *http.Request) bool {
-	fd := rq.Form.Get(formField)
-	if len(fd) < CharLen {
-		f.Error.Set(formField, fmt.Sprintf("This field must have at least %d character long", CharLen))
+func (f *Form) ValidLenCharacter(formField string, CharLen int) bool {
+	fd := f.Get(formField)
+	if utf8.RuneCountInString(fd) < CharLen {
+		f.AddError(formField, "This field must have at least %d character long", CharLen)
 		return false
 	}
 	return true
@@ -63,7 +124,7 @@ func (f *Form) ValidEmail(formField string) bool {
 	checkEMail := f.Get(formField)
 	//use Govalidator for email
 	if !govalidator.IsEmail(checkEMail) {
-		f.Error.Set(formField, "Invalid Email Address")
+		f.AddError(formField, "Invalid Email Address")
 		return false
 	}
 	return true
@@ -90,9 +151,60 @@ func (f *Form) ValidEmail(formField string) bool {
 func (f *Form) ValidPassword(formField string, minLen int, rq *http.Request) bool {
 	CheckPassword := f.Get(formField)
 	if len(CheckPassword) < minLen {
-		f.Error.Set(formField, "Weak Password : Enter a Minimium of 10 character")
+		f.AddError(formField, "Weak Password : Enter a Minimium of 10 character")
+		return false
+	}
+	return true
+
+}
+
+//ValidPromoCode check the promo code typed in the form, the field is optional
+func (f *Form) ValidPromoCode(formField string) bool {
+	code := strings.TrimSpace(f.Get(formField))
+	if code != "" && !promoCodePattern.MatchString(code) {
+		f.AddError(formField, "Invalid promo code")
 		return false
 	}
 	return true
+}
 
+//StayLimits the stays the guests can book, Today is the current day at the property. MaxNights is the longest
+//stay and MaxDaysAhead how far from today the check-in can be, a limit of 0 is no limit
+type StayLimits struct {
+	Today        time.Time
+	MaxNights    int
+	MaxDaysAhead int
+}
+
+//ValidDateRange checks the stay typed in the check-in and check-out fields and returns its dates. The check-in
+//is a day from today on within the days ahead of the limits, the check-out follows it within the longest stay
+func (f *Form) ValidDateRange(checkInField, checkOutField string, limits StayLimits) (time.Time, time.Time, bool) {
+	checkIn, inErr := dates.Parse(f.Get(checkInField))
+	if inErr != nil {
+		f.AddError(checkInField, "Invalid date, use the format YYYY-MM-DD")
+	}
+	checkOut, outErr := dates.Parse(f.Get(checkOutField))
+	if outErr != nil {
+		f.AddError(checkOutField, "Invalid date, use the format YYYY-MM-DD")
+	}
+	if inErr != nil || outErr != nil {
+		return checkIn, checkOut, false
+	}
+
+	valid := true
+	if checkIn.Before(limits.Today) {
+		f.AddError(checkInField, "The check-in date can't be in the past")
+		valid = false
+	} else if limits.MaxDaysAhead > 0 && checkIn.After(limits.Today.AddDate(0, 0, limits.MaxDaysAhead)) {
+		f.AddError(checkInField, "Stays can be booked at most %d days ahead", limits.MaxDaysAhead)
+		valid = false
+	}
+	if !checkOut.After(checkIn) {
+		f.AddError(checkOutField, "Check-out date must be after the check-in date")
+		valid = false
+	} else if limits.MaxNights > 0 && checkOut.After(checkIn.AddDate(0, 0, limits.MaxNights)) {
+		f.AddError(checkOutField, "A stay can be at most %d nights", limits.MaxNights)
+		valid = false
+	}
+	return checkIn, checkOut, valid
 }
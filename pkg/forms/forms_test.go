@@ -4,7 +4,11 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
+	"reflect"
+	"regexp"
+	"strings"
 	"testing"
+	"time"
 )
 
 //var formField string
@@ -95,7 +99,7 @@ func TestForm_ValidEmail(t *testing.T) {
 func TestForm_ValidLenCharacter(t *testing.T) {
 	rq := httptest.NewRequest("POST", "/", nil)
 	form := NewForm(rq.PostForm)
-	validChar := form.ValidLenCharacter("firstname", 5, rq)
+	validChar := form.ValidLenCharacter("firstname", 5)
 	if validChar && form.FormValid() {
 		t.Error("Error No data in the form field yet @Valid_Len_Character")
 	}
@@ -104,7 +108,7 @@ func TestForm_ValidLenCharacter(t *testing.T) {
 	postForm.Add("firstname", "Yusuf")
 	form = NewForm(rq.PostForm)
 	rq.PostForm = postForm
-	validChar = form.ValidLenCharacter("firstname", 5, rq)
+	validChar = form.ValidLenCharacter("firstname", 5)
 	if form.FormValid() != validChar {
 		t.Error("Error Input Character must be 5 or greater than 5 @Valid_Len_Character")
 	}
@@ -132,3 +136,311 @@ func TestForm_ValidPassword(t *testing.T) {
 		t.Error("Error invalid Password @Valid_password Testing")
 	}
 }
+
+func TestForm_ValidPromoCode(t *testing.T) {
+	for _, m := range []struct {
+		code  string
+		valid bool
+	}{
+		{"", true},
+		{"SUMMER-25", true},
+		{"ab", false},
+		{"SUMMER 25", false},
+		{"<script>", false},
+	} {
+		form := NewForm(url.Values{"promo_code": {m.code}})
+		if form.ValidPromoCode("promo_code") != m.valid || form.FormValid() != m.valid {
+			t.Errorf("Error Validating the promo code %q expected %v @Valid_Promo_Code", m.code, m.valid)
+		}
+	}
+}
+
+func TestForm_Localized(t *testing.T) {
+	rq := httptest.NewRequest("POST", "/", nil)
+	rq.Form = url.Values{"email": {"guest"}, "first-name": {"Al"}}
+
+	form := NewLocalizedForm(rq.Form, "fr")
+	form.Require("last-name")
+	form.ValidEmail("email")
+	form.ValidLenCharacter("first-name", 3)
+	form.AddError("promo_code", "This promo code needs a stay of at least %d nights", 2)
+
+	for field, want := range map[string]string{
+		"last-name":  "Ce champ est obligatoire",
+		"email":      "Adresse e-mail invalide",
+		"first-name": "Ce champ doit comporter au moins 3 caractères",
+		"promo_code": "Ce code promo nécessite un séjour d'au moins 2 nuits",
+	} {
+		if got := form.Error.Get(field); got != want {
+			t.Errorf("Error Testing the french feedback of %s got %q wanted %q @Localized", field, got, want)
+		}
+	}
+
+	form = NewForm(rq.Form)
+	form.ValidEmail("email")
+	if got := form.Error.Get("email"); got != "Invalid Email Address" {
+		t.Errorf("Error Testing the english feedback got %q @Localized", got)
+	}
+}
+
+func TestForm_ValidDateRange(t *testing.T) {
+	limits := StayLimits{Today: time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC), MaxNights: 30, MaxDaysAhead: 365}
+	for _, m := range []struct {
+		testName string
+		checkIn  string
+		checkOut string
+		valid    bool
+		field    string
+	}{
+		{"valid-stay", "2030-01-10", "2030-01-12", true, ""},
+		{"longest-stay", "2030-01-10", "2030-02-09", true, ""},
+		{"invalid-check-in", "10/01/2030", "2030-01-12", false, "check-in"},
+		{"missing-check-out", "2030-01-10", "", false, "check-out"},
+		{"past-check-in", "2030-01-09", "2030-01-12", false, "check-in"},
+		{"reversed-dates", "2030-01-12", "2030-01-12", false, "check-out"},
+		{"too-long-stay", "2030-01-10", "2030-02-10", false, "check-out"},
+		{"too-far-ahead", "2031-01-11", "2031-01-12", false, "check-in"},
+	} {
+		form := NewForm(url.Values{"check-in": {m.checkIn}, "check-out": {m.checkOut}})
+		checkIn, checkOut, valid := form.ValidDateRange("check-in", "check-out", limits)
+		if valid != m.valid || form.FormValid() != m.valid {
+			t.Errorf("Error Testing %s for the date range expected %v @Valid_Date_Range", m.testName, m.valid)
+		}
+		if m.field != "" && form.Error.Get(m.field) == "" {
+			t.Errorf("Error Testing %s for the date range, no error on %s @Valid_Date_Range", m.testName, m.field)
+		}
+		if m.valid && (checkIn.Format("2006-01-02") != m.checkIn || checkOut.Format("2006-01-02") != m.checkOut) {
+			t.Errorf("Error Testing %s for the date range got %v to %v @Valid_Date_Range", m.testName, checkIn, checkOut)
+		}
+	}
+
+	form := NewLocalizedForm(url.Values{"check-in": {"2030-01-10"}, "check-out": {"2030-03-01"}}, "fr")
+	form.ValidDateRange("check-in", "check-out", limits)
+	if got := form.Error.Messages(); len(got) != 1 || got["check-out"] != "Un séjour dure au plus 30 nuits" {
+		t.Errorf("Error Testing the messages of the date range got %v @Valid_Date_Range", got)
+	}
+}
+
+func TestForm_Check(t *testing.T) {
+	pattern := regexp.MustCompile(`^[A-Z]{3}$`)
+	for _, m := range []struct {
+		testName string
+		value    string
+		rules    []Rule
+		valid    bool
+		message  string
+	}{
+		{"required-blank", " ", []Rule{Required(), MinLength(3)}, false, "This field can't be blank"},
+		{"optional-blank", "", []Rule{MinLength(3), Email(), Phone()}, true, ""},
+		{"min-length", "Al", []Rule{Required(), MinLength(3)}, false, "This field must have at least 3 character long"},
+		{"min-length-runes", "Zoé", []Rule{MinLength(3), MaxLength(3)}, true, ""},
+		{"max-length", "Graham", []Rule{MaxLength(5)}, false, "This field must have at most 5 characters"},
+		{"int-range", "4", []Rule{IntRange(1, 4)}, true, ""},
+		{"int-out-of-range", "5", []Rule{IntRange(1, 4)}, false, "Enter a whole number from 1 to 4"},
+		{"not-an-int", "two", []Rule{IntRange(1, 4)}, false, "Enter a whole number from 1 to 4"},
+		{"pattern", "eur", []Rule{Matches(pattern, "Invalid currency")}, false, "Invalid currency"},
+		{"date", "2030-01-32", []Rule{Date()}, false, "Invalid date, use the format YYYY-MM-DD"},
+		{"one-of", "monthly", []Rule{OneOf("percent", "first_night")}, false, "Choose one of percent, first_night"},
+		{"email", "guest@", []Rule{Email()}, false, "Invalid Email Address"},
+		{"first-broken-rule", "x", []Rule{MinLength(3), OneOf("abc")}, false, "This field must have at least 3 character long"},
+	} {
+		form := NewForm(url.Values{"field": {m.value}})
+		if form.Check("field", m.rules...) != m.valid || form.Error.Get("field") != m.message || len(form.Error["field"]) > 1 {
+			t.Errorf("Error Testing %s for the rules got %v @Check", m.testName, form.Error["field"])
+		}
+	}
+
+	form := NewForm(url.Values{"password": {"secret-one"}, "confirm": {"secret-two"}})
+	if form.Check("confirm", SameAs("password")) || form.Error.Get("confirm") != "The values typed don't match" {
+		t.Error("Error Testing the fields typed twice @Check")
+	}
+
+	form = NewLocalizedForm(url.Values{"field": {"Graham"}}, "fr")
+	form.Check("field", MaxLength(5))
+	if got := form.Error.Get("field"); got != "Ce champ doit comporter au plus 5 caractères" {
+		t.Errorf("Error Testing the french feedback of a rule got %q @Check", got)
+	}
+}
+
+func TestForm_Phone(t *testing.T) {
+	for _, m := range []struct {
+		value  string
+		number string
+	}{
+		{"+2349047583219", "+2349047583219"},
+		{"(+234) 904-758-3219", "+2349047583219"},
+		{"00 33 6 12 34 56 78", "+33612345678"},
+		{"+33.6.12.34.56.78", "+33612345678"},
+		{"0612345678", ""},
+		{"+0612345678", ""},
+		{"+12", ""},
+		{"+1234567890123456", ""},
+		{"+33 6 CALL ME", ""},
+	} {
+		form := NewForm(url.Values{"phone": {m.value}})
+		valid := form.Check("phone", Phone())
+		if valid != (m.number != "") {
+			t.Errorf("Error Testing the phone number %q got valid %v @Phone", m.value, valid)
+		}
+		if valid && form.Get("phone") != m.number {
+			t.Errorf("Error Testing the phone number %q got %q wanted %q @Phone", m.value, form.Get("phone"), m.number)
+		}
+	}
+	//a form without values has nothing to rewrite
+	if !NewForm(nil).Check("phone", Phone()) {
+		t.Error("Error Testing the phone number of an empty form @Phone")
+	}
+}
+
+func TestForm_LocalPhone(t *testing.T) {
+	for _, m := range []struct {
+		value  string
+		number string
+	}{
+		{"(+234) 904-758-3219", "+2349047583219"},
+		{"09088765312", "09088765312"},
+		{"0908 876 5312", "0908 876 5312"},
+		{"12345", ""},
+		{"090 CALL ME", ""},
+	} {
+		form := NewForm(url.Values{"phone": {m.value}})
+		valid := form.Check("phone", LocalPhone())
+		if valid != (m.number != "") {
+			t.Errorf("Error Testing the phone number %q got valid %v @LocalPhone", m.value, valid)
+		}
+		if valid && form.Get("phone") != m.number {
+			t.Errorf("Error Testing the phone number %q got %q wanted %q @LocalPhone", m.value, form.Get("phone"), m.number)
+		}
+	}
+}
+
+func TestForm_Int(t *testing.T) {
+	form := NewForm(url.Values{"adults": {" 2 "}, "children": {"two"}})
+	if got := form.Int("adults"); got != 2 || !form.FormValid() {
+		t.Errorf("Error Testing the whole number got %d %v @Int", got, form.Error)
+	}
+	if got := form.Int("children"); got != 0 || form.Error.Get("children") != "Enter a whole number" {
+		t.Errorf("Error Testing the invalid whole number got %d %v @Int", got, form.Error)
+	}
+
+	form = NewForm(url.Values{})
+	form.Require("room_id")
+	form.Int("room_id")
+	if len(form.Error["room_id"]) != 1 || form.Error.Get("room_id") != "This field can't be blank" {
+		t.Errorf("Error Testing the blank whole number got %v @Int", form.Error)
+	}
+}
+
+func TestForm_Int64(t *testing.T) {
+	form := NewForm(url.Values{"version": {"1648808000000000000"}, "block": {"1.5"}})
+	if got := form.Int64("version"); got != 1648808000000000000 || !form.FormValid() {
+		t.Errorf("Error Testing the large whole number got %d %v @Int64", got, form.Error)
+	}
+	if got := form.Int64("block"); got != 0 || form.Error.Get("block") != "Enter a whole number" {
+		t.Errorf("Error Testing the invalid whole number got %d %v @Int64", got, form.Error)
+	}
+}
+
+func TestForm_Date(t *testing.T) {
+	form := NewForm(url.Values{"start_date": {"2030-01-10"}, "end_date": {"10/01/2030"}})
+	if got := form.Date("start_date"); !got.Equal(time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)) || !form.FormValid() {
+		t.Errorf("Error Testing the date got %v %v @Date", got, form.Error)
+	}
+	if got := form.Date("end_date"); !got.IsZero() || form.Error.Get("end_date") != "Invalid date, use the format YYYY-MM-DD" {
+		t.Errorf("Error Testing the invalid date got %v %v @Date", got, form.Error)
+	}
+}
+
+type guest struct {
+	Name     string    `form:"name" rules:"required,min=3"`
+	Phone    string    `form:"phone" rules:"phone"`
+	Adults   int       `form:"adults" rules:"range=1:4"`
+	Arrival  time.Time `form:"arrival"`
+	Penalty  string    `form:"penalty" rules:"oneof=percent|first_night"`
+	Internal string
+}
+
+func TestForm_Bind(t *testing.T) {
+	form := NewForm(url.Values{"name": {" Graham "}, "phone": {"(+20) 229 028 844"}, "adults": {"2"},
+		"arrival": {"2030-01-10"}, "penalty": {"percent"}, "Internal": {"kept"}})
+	bound := guest{Internal: "unchanged"}
+	if err := form.Bind(&bound); err != nil || !form.FormValid() {
+		t.Fatalf("Error Testing the binding got %v %v @Bind", err, form.Error)
+	}
+	want := guest{Name: "Graham", Phone: "+20229028844", Adults: 2, Arrival: time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC),
+		Penalty: "percent", Internal: "unchanged"}
+	if bound != want {
+		t.Errorf("Error Testing the binding got %+v @Bind", bound)
+	}
+
+	//the invalid and empty fields keep their values
+	form = NewForm(url.Values{"name": {"Al"}, "adults": {"9"}, "arrival": {"10/01/2030"}, "penalty": {""}})
+	bound = guest{Name: "Graham", Adults: 1, Penalty: "percent"}
+	if err := form.Bind(&bound); err != nil {
+		t.Fatal(err)
+	}
+	if bound.Name != "Graham" || bound.Adults != 1 || !bound.Arrival.IsZero() || bound.Penalty != "percent" {
+		t.Errorf("Error Testing the binding of invalid fields got %+v @Bind", bound)
+	}
+	for field, want := range map[string]string{
+		"name":    "This field must have at least 3 character long",
+		"adults":  "Enter a whole number from 1 to 4",
+		"arrival": "Invalid date, use the format YYYY-MM-DD",
+	} {
+		if got := form.Error.Get(field); got != want {
+			t.Errorf("Error Testing the feedback of %s got %q @Bind", field, got)
+		}
+	}
+}
+
+func TestForm_Bind_Invalid(t *testing.T) {
+	form := NewForm(url.Values{})
+	for _, dst := range []interface{}{
+		guest{},
+		new(string),
+		&struct {
+			Rate float64 `form:"rate"`
+		}{},
+		&struct {
+			Name string `form:"name" rules:"min=three"`
+		}{},
+		&struct {
+			Name string `form:"name" rules:"capitalised"`
+		}{},
+		&struct {
+			name string `form:"name"`
+		}{},
+	} {
+		if err := form.Bind(dst); err == nil {
+			t.Errorf("Error Testing the binding of %T, no error @Bind", dst)
+		}
+	}
+}
+
+func TestParseRequest(t *testing.T) {
+	rq := httptest.NewRequest("POST", "/?room_id=1", strings.NewReader(`{"check-in": "2030-01-10", "adults": 2, "pets": false, "note": null}`))
+	rq.Header.Set("Content-Type", "application/json; charset=utf-8")
+	values, err := ParseRequest(rq)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := (url.Values{"check-in": {"2030-01-10"}, "adults": {"2"}, "pets": {"false"}}); !reflect.DeepEqual(values, want) {
+		t.Errorf("Error Testing the JSON values got %v", values)
+	}
+
+	rq = httptest.NewRequest("POST", "/?room_id=1", strings.NewReader("check-in=2030-01-10"))
+	rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	values, err = ParseRequest(rq)
+	if err != nil || values.Get("check-in") != "2030-01-10" || values.Get("room_id") != "1" {
+		t.Errorf("Error Testing the form values got %v %v", values, err)
+	}
+
+	for _, body := range []string{`{"rooms": [1, 2]}`, `[1, 2]`, `{"check-in":`} {
+		if _, err := ParseJSON(strings.NewReader(body)); err == nil {
+			t.Errorf("Error Testing the invalid JSON %s, no error", body)
+		}
+	}
+	if values, err := ParseJSON(strings.NewReader("")); err != nil || len(values) != 0 {
+		t.Errorf("Error Testing an empty JSON body got %v %v", values, err)
+	}
+}
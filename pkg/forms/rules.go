@@ -0,0 +1,175 @@
+package forms
+
+import (
+	"github.com/asaskevich/govalidator"
+	"github.com/dev-ayaa/resvbooking/pkg/dates"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+//Rule checks the value typed in a form field and sets its error feedback, it returns false when the value breaks it.
+//Every rule but Required lets an empty value through so the optional fields are checked only when they are filled
+type Rule func(f *Form, formField string) bool
+
+//Check runs the rules of the form field in their order, it stops at the first rule the value breaks so the field
+//gets a single error feedback
+func (f *Form) Check(formField string, rules ...Rule) bool {
+	for _, rule := range rules {
+		if !rule(f, formField) {
+			return false
+		}
+	}
+	return true
+}
+
+//filled returns the rule checking the value of the filled fields only
+func filled(valid func(value string) bool, message string, args ...interface{}) Rule {
+	return func(f *Form, formField string) bool {
+		value := strings.TrimSpace(f.Get(formField))
+		if value == "" || valid(value) {
+			return true
+		}
+		f.AddError(formField, message, args...)
+		return false
+	}
+}
+
+//Required the field can't be left blank
+func Required() Rule {
+	return func(f *Form, formField string) bool {
+		if strings.TrimSpace(f.Get(formField)) == "" {
+			f.AddError(formField, "This field can't be blank")
+			return false
+		}
+		return true
+	}
+}
+
+//MinLength the value has at least the number of characters
+func MinLength(n int) Rule {
+	return filled(func(value string) bool {
+		return utf8.RuneCountInString(value) >= n
+	}, "This field must have at least %d character long", n)
+}
+
+//MaxLength the value has at most the number of characters
+func MaxLength(n int) Rule {
+	return filled(func(value string) bool {
+		return utf8.RuneCountInString(value) <= n
+	}, "This field must have at most %d characters", n)
+}
+
+//IntRange the value is a whole number from min to max
+func IntRange(min, max int) Rule {
+	return filled(func(value string) bool {
+		n, err := strconv.Atoi(value)
+		return err == nil && n >= min && n <= max
+	}, "Enter a whole number from %d to %d", min, max)
+}
+
+//Matches the value matches the pattern, the message is the english error feedback of the catalogue
+func Matches(pattern *regexp.Regexp, message string) Rule {
+	return filled(pattern.MatchString, message)
+}
+
+//Email the value is an email address
+func Email() Rule {
+	return filled(govalidator.IsEmail, "Invalid Email Address")
+}
+
+//Date the value is a day typed as 2006-01-02
+func Date() Rule {
+	return filled(func(value string) bool {
+		_, err := dates.Parse(value)
+		return err == nil
+	}, "Invalid date, use the format YYYY-MM-DD")
+}
+
+//OneOf the value is one of the choices
+func OneOf(choices ...string) Rule {
+	return filled(func(value string) bool {
+		for _, choice := range choices {
+			if value == choice {
+				return true
+			}
+		}
+		return false
+	}, "Choose one of %s", strings.Join(choices, ", "))
+}
+
+//SameAs the value is the one typed in the other field, the password typed twice
+func SameAs(otherField string) Rule {
+	return func(f *Form, formField string) bool {
+		if f.Get(formField) != f.Get(otherField) {
+			f.AddError(formField, "The values typed don't match")
+			return false
+		}
+		return true
+	}
+}
+
+//Phone the value is a phone number in its international format, the field is rewritten in the E.164
+//format so the handlers read the normalised number
+func Phone() Rule {
+	return func(f *Form, formField string) bool {
+		value := f.Get(formField)
+		if strings.TrimSpace(value) == "" {
+			return true
+		}
+		number, ok := NormalizePhone(value)
+		if !ok {
+			f.AddError(formField, "Invalid phone number, use the international format like +2349047583219")
+			return false
+		}
+		if f.Values != nil {
+			f.Set(formField, number)
+		}
+		return true
+	}
+}
+
+//LocalPhone the value is a phone number in its international format, rewritten in the E.164 format, or a
+//number without its country code like the ones stored before the numbers were checked, kept as typed
+func LocalPhone() Rule {
+	return func(f *Form, formField string) bool {
+		value := f.Get(formField)
+		if strings.TrimSpace(value) == "" {
+			return true
+		}
+		if number, ok := NormalizePhone(value); ok {
+			if f.Values != nil {
+				f.Set(formField, number)
+			}
+			return true
+		}
+		if !localPattern.MatchString(phoneSeparators.Replace(strings.TrimSpace(value))) {
+			f.AddError(formField, "Invalid phone number, use the digits of the number or the international format like +2349047583219")
+			return false
+		}
+		return true
+	}
+}
+
+//phoneSeparators the characters the guests group the digits of their number with
+var phoneSeparators = strings.NewReplacer(" ", "", "-", "", ".", "", "(", "", ")", "")
+
+//localPattern the digits of a number dialled without its country code
+var localPattern = regexp.MustCompile(`^[0-9]{6,15}$`)
+
+//e164Pattern a plus, the country code and the number, at most 15 digits and no leading zero
+var e164Pattern = regexp.MustCompile(`^\+[1-9][0-9]{6,14}$`)
+
+//NormalizePhone returns the phone number in the E.164 format, "(+234) 904-758-3219" is +2349047583219.
+//The number starts with a plus or with 00, a number without its country code can't be normalised
+func NormalizePhone(value string) (string, bool) {
+	number := phoneSeparators.Replace(strings.TrimSpace(value))
+	if strings.HasPrefix(number, "00") {
+		number = "+" + strings.TrimPrefix(number, "00")
+	}
+	if !e164Pattern.MatchString(number) {
+		return "", false
+	}
+	return number, true
+}
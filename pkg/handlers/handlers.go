@@ -1,20 +1,37 @@
 package handlers
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"log"
 	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
+	"github.com/dev-ayaa/resvbooking/pkg/availability"
 	"github.com/dev-ayaa/resvbooking/pkg/config"
+	"github.com/dev-ayaa/resvbooking/pkg/currency"
+	"github.com/dev-ayaa/resvbooking/pkg/dates"
 	"github.com/dev-ayaa/resvbooking/pkg/driver"
 	"github.com/dev-ayaa/resvbooking/pkg/forms"
 	"github.com/dev-ayaa/resvbooking/pkg/helpers"
+	"github.com/dev-ayaa/resvbooking/pkg/holds"
+	"github.com/dev-ayaa/resvbooking/pkg/i18n"
+	"github.com/dev-ayaa/resvbooking/pkg/importer"
+	"github.com/dev-ayaa/resvbooking/pkg/invoice"
 	"github.com/dev-ayaa/resvbooking/pkg/models"
+	"github.com/dev-ayaa/resvbooking/pkg/payments"
+	"github.com/dev-ayaa/resvbooking/pkg/photos"
+	"github.com/dev-ayaa/resvbooking/pkg/pricing"
 	"github.com/dev-ayaa/resvbooking/pkg/render"
+	"github.com/dev-ayaa/resvbooking/pkg/storage"
 	"github.com/dev-ayaa/resvbooking/repository"
 	"github.com/dev-ayaa/resvbooking/repository/dbRepository"
 	"github.com/go-chi/chi"
@@ -28,6 +45,18 @@ type Repository struct {
 
 var Repo *Repository
 
+//dashboardPeriods the number of days the dashboard statistics are computed over
+var dashboardPeriods = []int{7, 30, 90}
+
+//slugPattern the form of the room slugs used in the /rooms/{slug} urls
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+//roomIDPattern the ids of the rooms picked in the forms
+var roomIDPattern = regexp.MustCompile(`^[1-9][0-9]*$`)
+
+//maxImportFileSize the largest csv file accepted by the reservation and exchange rate imports
+const maxImportFileSize = 10 << 20
+
 // NewRepository  create a new repository
 func NewRepository(a *config.AppConfig, db *driver.DB) *Repository {
 	return &Repository{App: a,
@@ -75,7 +104,9 @@ func (rp *Repository) ContactPage(wr http.ResponseWriter, rq *http.Request) {
 //CheckAvailabilityPage : This is a Get request handler which only render the check availability page
 func (rp *Repository) CheckAvailabilityPage(wr http.ResponseWriter, rq *http.Request) {
 
-	err := render.Template(wr, "check-availability.page.tmpl", &models.TemplateData{}, rq)
+	err := render.Template(wr, "check-availability.page.tmpl", &models.TemplateData{
+		Form: forms.NewLocalizedForm(nil, helpers.Locale(rq)),
+	}, rq)
 	if err != nil {
 		return
 	}
@@ -87,78 +118,293 @@ func (rp *Repository) PostCheckAvailabilityPage(wr http.ResponseWriter, rq *http
 
 	err := rq.ParseForm()
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "error cannot parse check availability form")
+		rp.putMessage(rq, "errors", "error cannot parse check availability form")
 		http.Redirect(wr, rq, "/", http.StatusSeeOther)
 		return
 	}
 
 	data := make(map[string]interface{})
 
-	//getting the posted value from the form with respect to the field
-	checkIn := rq.Form.Get("check-in")
-	checkOut := rq.Form.Get("check-out")
+	//the stay is checked before anything is searched, the guest fixes the dates in the form
+	form := forms.NewLocalizedForm(rq.PostForm, helpers.Locale(rq))
+	checkInDate, checkOutDate, validDates := form.ValidDateRange("check-in", "check-out", rp.stayLimits())
+	if !validDates {
+		wr.WriteHeader(http.StatusUnprocessableEntity)
+		_ = render.Template(wr, "check-availability.page.tmpl", &models.TemplateData{
+			Form: form,
+		}, rq)
+		return
+	}
+	checkIn := form.Get("check-in")
+	checkOut := form.Get("check-out")
+
+	adults, children, err := parseGuests(rq.Form)
+	if err != nil {
+		rp.putMessage(rq, "errors", err.Error())
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
 
-	//Converting the date in string format to time.Time format
-	dateLayout := "2006-01-02"
-	checkInDate, err := time.Parse(dateLayout, checkIn)
+	rooms, err := rp.DB.SearchForAvailableRoom(checkInDate, checkOutDate)
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "cannot parse check-in-date")
+		rp.putMessage(rq, "errors", "No available room to reserve")
 		http.Redirect(wr, rq, "/", http.StatusSeeOther)
 		return
 	}
-	checkOutDate, err := time.Parse(dateLayout, checkOut)
+
+	//the occupied nights around the requested dates for the grid and the alternative dates
+	startDate, endDate := availability.Window(checkInDate, checkOutDate)
+	allRoomNights, err := rp.DB.RoomOccupiedNights(startDate, endDate)
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "cannot parse check-out-date")
+		rp.putMessage(rq, "errors", "cannot get the availability of the rooms")
 		http.Redirect(wr, rq, "/", http.StatusSeeOther)
 		return
 	}
+	//only the rooms large enough for the guests are shown, every room when the guests need several of them
+	var roomNights []models.RoomNights
+	for _, rn := range allRoomNights {
+		if rn.Room.Fits(adults, children) {
+			roomNights = append(roomNights, rn)
+		}
+	}
+	if len(roomNights) == 0 {
+		roomNights = allRoomNights
+	}
 
-	rooms, err := rp.DB.SearchForAvailableRoom(checkInDate, checkOutDate)
+	//the rules of the whole window are fetched so the alternative dates follow them too
+	rules, err := rp.DB.BookingRules(startDate, endDate)
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "No available room to reserve")
+		rp.putMessage(rq, "errors", "cannot get the booking rules of the rooms")
 		http.Redirect(wr, rq, "/", http.StatusSeeOther)
 		return
 	}
-	// for _, room := range rooms {
-	// 	rp.App.InfoLog.Println("Rooms Available :: ", room)
-	// }
+	today := rp.App.Property.Today()
+	rooms, excluded := availability.ApplyRules(rooms, rules, checkInDate, checkOutDate, today)
+
+	//the guests may book several of the rooms together, none is shown when all of them can't hold the guests
+	if !availability.Holds(rooms, adults, children) {
+		rooms = nil
+	}
+	severalRooms := len(rooms) > 0
+	for _, room := range rooms {
+		severalRooms = severalRooms && !room.Fits(adults, children)
+	}
+	data["several_rooms"] = severalRooms
 
 	if len(rooms) == 0 {
-		// rp.App.InfoLog.Println("NO AVAILABLE ROOMS")
-		rp.App.Session.Put(rq.Context(), "errors", "No availale rooms")
-		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
-		return
+		data["suggestions"] = availability.Suggest(roomNights, rules, checkInDate, checkOutDate, today)
 	}
 	data["rooms"] = rooms
+	data["excluded"] = excluded
+	data["grid"] = availability.BuildGrid(roomNights, checkInDate, checkOutDate)
+	IntData := map[string]int{"adults": adults, "children": children}
 
 	resv := models.Reservation{
 		CheckInDate:  checkInDate,
 		CheckOutDate: checkOutDate,
+		Adults:       adults,
+		Children:     children,
 	}
 
-	//After checking for available room by date and store it in session
+	//After checking for available room by date and store it in session, only the rooms found can be selected
+	var availableRooms []int
+	for _, room := range rooms {
+		availableRooms = append(availableRooms, room.ID)
+	}
+	rp.releaseHolds(rq)
 	rp.App.Session.Put(rq.Context(), "reservation", resv)
+	rp.App.Session.Put(rq.Context(), "available_rooms", availableRooms)
+	rp.App.Session.Remove(rq.Context(), "waitlist_entry")
 
+	StringData := map[string]string{"check-in": checkIn, "check-out": checkOut}
 	render.Template(wr, "select-available-room.page.tmpl", &models.TemplateData{
-		Data: data,
+		Data:       data,
+		IntData:    IntData,
+		StringData: StringData,
 	}, rq)
 
 }
 
+//PostWaitlist adds the guest to the waitlist of sold-out dates, a room is offered by mail as soon as
+//one frees up
+func (rp *Repository) PostWaitlist(wr http.ResponseWriter, rq *http.Request) {
+	err := rq.ParseForm()
+	if err != nil {
+		rp.putMessage(rq, "errors", "error cannot parse the waitlist form")
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
+
+	form := forms.NewLocalizedForm(rq.PostForm, helpers.Locale(rq))
+	form.Check("first-name", forms.Required(), forms.MinLength(3), forms.MaxLength(255))
+	form.Check("last-name", forms.Required(), forms.MinLength(3), forms.MaxLength(255))
+	form.Check("email", forms.Required(), forms.Email())
+
+	entry := models.WaitlistEntry{
+		FirstName: strings.TrimSpace(form.Get("first-name")),
+		LastName:  strings.TrimSpace(form.Get("last-name")),
+		Email:     strings.TrimSpace(form.Get("email")),
+	}
+	entry.CheckInDate, entry.CheckOutDate, _ = form.ValidDateRange("check-in", "check-out", rp.stayLimits())
+	entry.Adults, entry.Children, err = parseGuests(rq.PostForm)
+	if err != nil {
+		form.AddError("adults", err.Error())
+	}
+	if form.Check("room_id", forms.Matches(roomIDPattern, "Invalid room")) && form.Get("room_id") != "" {
+		entry.RoomID = form.Int("room_id")
+	}
+
+	if !form.FormValid() {
+		problems := formProblems(form, "first-name", "last-name", "email", "check-in", "check-out", "adults", "room_id")
+		rp.putMessage(rq, "errors", "cannot join the waitlist, %s", problems)
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
+
+	_, err = rp.DB.InsertWaitlistEntry(entry)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	rp.putMessage(rq, "flash", "You are on the waitlist from %s to %s, we will email %s as soon as a room frees up",
+		entry.CheckInDate.Format("2006-01-02"), entry.CheckOutDate.Format("2006-01-02"), entry.Email)
+	http.Redirect(wr, rq, "/", http.StatusSeeOther)
+}
+
+//WaitlistHold opens the reservation form for the room offered to a waitlisted guest, the link can be used
+//until the hold expires or the room is booked
+func (rp *Repository) WaitlistHold(wr http.ResponseWriter, rq *http.Request) {
+	entry, err := rp.DB.GetWaitlistEntryByToken(chi.URLParam(rq, "token"))
+	if errors.Is(err, sql.ErrNoRows) {
+		rp.putMessage(rq, "errors", "This hold link is not valid")
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	if entry.Status == models.WaitlistClaimed {
+		rp.putMessage(rq, "errors", "This hold link was already used to book the room")
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
+	if entry.Status != models.WaitlistOffered || entry.HoldExpiresAt.Before(time.Now()) {
+		rp.putMessage(rq, "errors", "This hold link expired, the room was offered to the next guest")
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
+
+	resv := models.Reservation{
+		FirstName:    entry.FirstName,
+		LastName:     entry.LastName,
+		Email:        entry.Email,
+		CheckInDate:  entry.CheckInDate,
+		CheckOutDate: entry.CheckOutDate,
+		Adults:       entry.Adults,
+		Children:     entry.Children,
+		RoomID:       entry.OfferedRoomID,
+		Room:         entry.Room,
+	}
+	//the room was held for the guest when it was offered, an offer without its hold holds the room again
+	if entry.HoldID > 0 {
+		rp.releaseHolds(rq)
+		rp.App.Session.Put(rq.Context(), "holds", []int{entry.HoldID})
+		rp.App.Session.Put(rq.Context(), "hold_expires_at", entry.HoldExpiresAt.Unix())
+	} else {
+		err = rp.holdRooms(rq, resv, []int{resv.RoomID})
+		if err != nil {
+			rp.putMessage(rq, "errors", "cannot hold the room, %s", err)
+			http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+			return
+		}
+	}
+	rp.App.Session.Put(rq.Context(), "reservation", resv)
+	rp.App.Session.Put(rq.Context(), "waitlist_entry", entry.ID)
+	rp.App.Session.Remove(rq.Context(), "reservation_rooms")
+	http.Redirect(wr, rq, "/make-reservation", http.StatusSeeOther)
+}
+
 //create a json struct interfaces
 type ResponseJSON struct {
 	RoomID       string `json:"room_id"`
 	Ok           bool   `json:"ok"`
 	CheckInDate  string `json:"check_in_date"`
 	CheckOutDate string `json:"check_out_date"`
+	Adults       int    `json:"adults"`
+	Children     int    `json:"children"`
 	Message      string `json:"message"`
+	//Errors the invalid fields of the request and why
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+//parseGuests reads the number of adults and children from the form, one adult and no child
+//when the fields are left empty
+func parseGuests(form url.Values) (int, int, error) {
+	adults, children := 1, 0
+	var err error
+	if value := form.Get("adults"); value != "" {
+		adults, err = strconv.Atoi(value)
+		if err != nil || adults < 1 {
+			return 0, 0, i18n.NewMessage("enter a valid number of adults")
+		}
+	}
+	if value := form.Get("children"); value != "" {
+		children, err = strconv.Atoi(value)
+		if err != nil || children < 0 {
+			return 0, 0, i18n.NewMessage("enter a valid number of children")
+		}
+	}
+	return adults, children, nil
+}
+
+//stayLimits returns the stays the guests can book from today at the property
+func (rp *Repository) stayLimits() forms.StayLimits {
+	return forms.StayLimits{
+		Today:        rp.App.Property.Today(),
+		MaxNights:    rp.App.MaxStayNights,
+		MaxDaysAhead: rp.App.BookingHorizon,
+	}
+}
+
+//fieldErrors joins the error feedbacks of the fields in their order
+func fieldErrors(form *forms.Form, fields ...string) string {
+	var problems []string
+	for _, field := range fields {
+		if message := form.Error.Get(field); message != "" {
+			problems = append(problems, message)
+		}
+	}
+	return strings.Join(problems, ", ")
+}
+
+//putMessage stores the flash or the error the next page shows to the guest in the locale of the guest, the
+//message is the english one of the catalogue. The errors among the arguments are translated too
+func (rp *Repository) putMessage(rq *http.Request, key, message string, args ...interface{}) {
+	locale := helpers.Locale(rq)
+	for i, arg := range args {
+		if err, ok := arg.(error); ok {
+			args[i] = i18n.TranslateError(locale, err)
+		}
+	}
+	rp.App.Session.Put(rq.Context(), key, i18n.Translate(locale, message, args...))
+}
+
+//availabilityRequest the room and the guests the availability is asked for, from the form of the room page or
+//a JSON body with the same fields. The stay is checked against the limits of the property
+type availabilityRequest struct {
+	CheckIn  string `form:"check-in"`
+	CheckOut string `form:"check-out"`
+	RoomID   int    `form:"room_id" rules:"required"`
+	Adults   int    `form:"adults" rules:"range=1:99"`
+	Children int    `form:"children" rules:"range=0:99"`
 }
 
 // JsonAvailabilityPage  handler Function
 func (rp *Repository) JsonAvailabilityPage(wr http.ResponseWriter, rq *http.Request) {
 
 	//for server error during connection
-	err := rq.ParseForm()
+	values, err := forms.ParseRequest(rq)
 	if err != nil {
 		myResp := ResponseJSON{
 			RoomID:  "",
@@ -172,25 +418,67 @@ func (rp *Repository) JsonAvailabilityPage(wr http.ResponseWriter, rq *http.Requ
 		return
 
 	}
-	cid := rq.Form.Get("check-in")
-	cod := rq.Form.Get("check-out")
-
-	layout := "2006-01-02"
 
-	CheckInDate, _ := time.Parse(layout, cid)
-	CheckOutDate, _ := time.Parse(layout, cod)
-
-	roomID, _ := strconv.Atoi(rq.Form.Get("room_id"))
+	//the dates, the room and the guests are checked before the room is looked up, the client gets the reason
+	//of every invalid field. One adult and no child when the guests are left out
+	query := availabilityRequest{Adults: 1}
+	form := forms.NewLocalizedForm(values, helpers.Locale(rq))
+	err = form.Bind(&query)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	cid, cod, roomID, adults, children := query.CheckIn, query.CheckOut, query.RoomID, query.Adults, query.Children
+	CheckInDate, CheckOutDate, _ := form.ValidDateRange("check-in", "check-out", rp.stayLimits())
+	if !form.FormValid() {
+		myResp := ResponseJSON{
+			RoomID:       form.Get("room_id"),
+			Ok:           false,
+			CheckInDate:  form.Get("check-in"),
+			CheckOutDate: form.Get("check-out"),
+			Message:      fieldErrors(form, "check-in", "check-out", "room_id", "adults", "children"),
+			Errors:       form.Error.Messages(),
+		}
+		output, _ := json.MarshalIndent(myResp, "", "     ")
+		wr.Header().Set("Content-type", "application/json")
+		wr.WriteHeader(http.StatusUnprocessableEntity)
+		wr.Write(output)
+		return
+	}
 
 	isRoomAvailable, err := rp.DB.SearchRoomAvailabileByRoomID(roomID, CheckInDate, CheckOutDate)
 
+	message := ""
+	if isRoomAvailable && err == nil {
+		room, roomErr := rp.DB.GetRooms(roomID)
+		if roomErr != nil {
+			isRoomAvailable = false
+			message = "invalid room id"
+		} else if !room.Fits(adults, children) {
+			isRoomAvailable = false
+			message = fmt.Sprintf("the room holds at most %d adults and %d children", room.MaxAdults, room.MaxChildren)
+		} else if rules, ruleErr := rp.DB.BookingRules(CheckInDate, CheckOutDate); ruleErr != nil {
+			isRoomAvailable = false
+			message = "cannot get the booking rules of the room"
+		} else if reasons := availability.Violations(rules[roomID], CheckInDate, CheckOutDate, rp.App.Property.Today()); len(reasons) > 0 {
+			isRoomAvailable = false
+			var problems []string
+			for _, reason := range reasons {
+				problems = append(problems, reason.In(form.Locale))
+			}
+			message = strings.Join(problems, ", ")
+		}
+	}
+
 	//For the database
 	myResp := ResponseJSON{
 		RoomID:       strconv.Itoa(roomID),
 		Ok:           isRoomAvailable,
 		CheckInDate:  cid,
 		CheckOutDate: cod,
-		Message:      "",
+		Adults:       adults,
+		Children:     children,
+		Message:      message,
 	}
 
 	//Creating a Json file from struct type
@@ -221,102 +509,241 @@ func (rp *Repository) JsonAvailabilityPage(wr http.ResponseWriter, rq *http.Requ
 func (rp *Repository) SelectAvailableRoom(wr http.ResponseWriter, rq *http.Request) {
 	roomID, err := strconv.Atoi(chi.URLParam(rq, "id"))
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "cannot get room id from the URL")
+		rp.putMessage(rq, "errors", "cannot get room id from the URL")
 		return
 	}
 	resv, ok := rp.App.Session.Get(rq.Context(), "reservation").(models.Reservation)
 	if !ok {
-		rp.App.Session.Put(rq.Context(), "errors", "cannot get stored data from the reservation database")
+		rp.putMessage(rq, "errors", "cannot get stored data from the reservation database")
 		return
 	}
 	resv.RoomID = roomID
+	err = rp.searchedRooms(rq, []int{roomID})
+	if err == nil {
+		_, _, err = rp.selectedRooms(resv, []int{roomID})
+	}
+	if err != nil {
+		rp.releaseHolds(rq)
+		rp.putMessage(rq, "errors", "cannot select the room, %s", err)
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
+	err = rp.holdRooms(rq, resv, []int{roomID})
+	if err != nil {
+		rp.putMessage(rq, "errors", "cannot hold the room, %s", err)
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
 	rp.App.Session.Put(rq.Context(), "reservation", resv)
+	rp.App.Session.Remove(rq.Context(), "reservation_rooms")
 	http.Redirect(wr, rq, "/make-reservation", http.StatusSeeOther)
 }
 
-//BookRoomNow : this handler help the user to book room of their choice right from the
-//by checking for availability before signing up to reserve the room choosen
-func (rp Repository) BookRoomNow(wr http.ResponseWriter, rq *http.Request) {
-
-	var resv models.Reservation
-	room_id, err := strconv.Atoi(rq.URL.Query().Get("room_id"))
-	cid := rq.URL.Query().Get("check_in_date")
-	cod := rq.URL.Query().Get("check_out_date")
+//holdRooms holds the rooms for the stay while the guest fills the reservation form, the holds of a
+//previous selection are released first. When one of the rooms can't be held none is
+func (rp *Repository) holdRooms(rq *http.Request, resv models.Reservation, roomIDs []int) error {
+	rp.releaseHolds(rq)
+
+	expiresAt := holds.ExpiresAt(time.Now())
+	var holdIDs []int
+	for _, roomID := range roomIDs {
+		holdID, err := rp.DB.InsertHold(models.RoomRestriction{
+			RoomID:       roomID,
+			CheckInDate:  resv.CheckInDate,
+			CheckOutDate: resv.CheckOutDate,
+			ExpiresAt:    expiresAt,
+		})
+		if err != nil {
+			if err := rp.DB.ReleaseHolds(holdIDs); err != nil {
+				rp.App.ErrorLog.Println(err)
+			}
+			return err
+		}
+		holdIDs = append(holdIDs, holdID)
+	}
+	rp.App.Session.Put(rq.Context(), "holds", holdIDs)
+	rp.App.Session.Put(rq.Context(), "hold_expires_at", expiresAt.Unix())
+	return nil
+}
 
-	dateLayout := "2006-01-02"
-	checkInDate, err := time.Parse(dateLayout, cod)
-	checkOutDate, err := time.Parse(dateLayout, cid)
+//searchedRooms returns an error when one of the rooms wasn't found available by the last search of the guest,
+//the rooms left out for their capacity or the booking rules can't be selected either
+func (rp *Repository) searchedRooms(rq *http.Request, roomIDs []int) error {
+	available, _ := rp.App.Session.Get(rq.Context(), "available_rooms").([]int)
+	for _, roomID := range roomIDs {
+		found := false
+		for _, id := range available {
+			found = found || id == roomID
+		}
+		if !found {
+			return i18n.NewMessage("room %d is not available for the stay searched", roomID)
+		}
+	}
+	return nil
+}
 
-	//err = errors.New("Error getting data from book-now URL")
+//selectedRooms returns the rooms selected for the stay and the share of the guests staying in each of them,
+//the rooms together must hold the guests of the reservation
+func (rp *Repository) selectedRooms(resv models.Reservation, roomIDs []int) ([]models.Room, []availability.Guests, error) {
+	var rooms []models.Room
+	for _, roomID := range roomIDs {
+		room, err := rp.DB.GetRooms(roomID)
+		if err != nil {
+			return nil, nil, i18n.NewMessage("unknown room %d", roomID)
+		}
+		rooms = append(rooms, room)
+	}
+	shares, err := availability.SplitGuests(rooms, resv.Adults, resv.Children)
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "Error getting data from book-now URL")
+		return nil, nil, err
+	}
+	return rooms, shares, nil
+}
+
+//releaseHolds frees the rooms held for the guest of the session
+func (rp *Repository) releaseHolds(rq *http.Request) {
+	rp.App.Session.Remove(rq.Context(), "hold_expires_at")
+	holdIDs, ok := rp.App.Session.Pop(rq.Context(), "holds").([]int)
+	if !ok || len(holdIDs) == 0 {
 		return
 	}
+	if err := rp.DB.ReleaseHolds(holdIDs); err != nil {
+		rp.App.ErrorLog.Println(err)
+	}
+}
 
-	room, err := rp.DB.GetRooms(room_id)
+//PostSelectAvailableRoom : This allow the user to select several of the available rooms and reserve
+//them together under one booking
+func (rp *Repository) PostSelectAvailableRoom(wr http.ResponseWriter, rq *http.Request) {
+	err := rq.ParseForm()
 	if err != nil {
-		helpers.ServerSideError(wr, err)
+		rp.putMessage(rq, "errors", "cannot parse the selected rooms")
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
+	resv, ok := rp.App.Session.Get(rq.Context(), "reservation").(models.Reservation)
+	if !ok {
+		rp.putMessage(rq, "errors", "cannot get stored data from the reservation database")
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
 		return
 	}
-	resv.Room.RoomName = room.RoomName
-	resv.RoomID = room_id
-	resv.CheckInDate = checkInDate
-	resv.CheckOutDate = checkOutDate
-	rp.App.Session.Put(rq.Context(), "reservation", resv)
-	http.Redirect(wr, rq, "/make-reservation", http.StatusSeeOther)
 
-}
+	var roomIDs []int
+	seen := make(map[int]bool)
+	for _, value := range rq.Form["room_id"] {
+		roomID, err := strconv.Atoi(value)
+		if err != nil || seen[roomID] {
+			continue
+		}
+		seen[roomID] = true
+		roomIDs = append(roomIDs, roomID)
+	}
+	if len(roomIDs) == 0 {
+		rp.putMessage(rq, "errors", "select at least one room")
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
 
-//JuniorSuitePage  handler function
-func (rp *Repository) JuniorSuitePage(wr http.ResponseWriter, rq *http.Request) {
+	//the rooms selected together must have been found by the search and hold its guests
+	err = rp.searchedRooms(rq, roomIDs)
+	if err == nil {
+		_, _, err = rp.selectedRooms(resv, roomIDs)
+	}
+	if err != nil {
+		rp.releaseHolds(rq)
+		rp.putMessage(rq, "errors", "cannot select the rooms, %s", err)
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
 
-	err := render.Template(wr, "junior.page.tmpl", &models.TemplateData{}, rq)
+	resv.RoomID = roomIDs[0]
+	err = rp.holdRooms(rq, resv, roomIDs)
 	if err != nil {
+		rp.putMessage(rq, "errors", "cannot hold the selected rooms, %s", err)
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
 		return
 	}
+	rp.App.Session.Put(rq.Context(), "reservation", resv)
+	rp.App.Session.Put(rq.Context(), "reservation_rooms", roomIDs)
+	http.Redirect(wr, rq, "/make-reservation", http.StatusSeeOther)
 }
 
-//PremiumSuitePage handler function
-func (rp *Repository) PremiumSuitePage(wr http.ResponseWriter, rq *http.Request) {
+//BookRoomNow : this handler help the user to book room of their choice right from the
+//by checking for availability before signing up to reserve the room choosen
+func (rp *Repository) BookRoomNow(wr http.ResponseWriter, rq *http.Request) {
 
-	err := render.Template(wr, "premium.page.tmpl", &models.TemplateData{}, rq)
+	var resv models.Reservation
+	//the link of the room page carries the room in id and the stay in s and e
+	form := forms.NewLocalizedForm(rq.URL.Query(), helpers.Locale(rq))
+	checkInDate, checkOutDate, validDates := form.ValidDateRange("s", "e", rp.stayLimits())
+	room_id, err := strconv.Atoi(form.Get("id"))
 	if err != nil {
+		form.AddError("id", "Invalid room")
+	}
+	if !validDates || err != nil {
+		rp.putMessage(rq, "errors", "cannot book the room, %s", fieldErrors(form, "id", "s", "e"))
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
 		return
 	}
-}
-
-//DeluxeSuitePage handler function
-func (rp *Repository) DeluxeSuitePage(wr http.ResponseWriter, rq *http.Request) {
 
-	err := render.Template(wr, "deluxe.page.tmpl", &models.TemplateData{}, rq)
+	room, err := rp.DB.GetRooms(room_id)
 	if err != nil {
+		helpers.ServerSideError(wr, err)
 		return
 	}
-}
+	resv.Room.RoomName = room.RoomName
+	resv.RoomID = room_id
+	resv.CheckInDate = checkInDate
+	resv.CheckOutDate = checkOutDate
+	err = rp.holdRooms(rq, resv, []int{room_id})
+	if err != nil {
+		rp.putMessage(rq, "errors", "cannot hold the room, %s", err)
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "reservation", resv)
+	rp.App.Session.Remove(rq.Context(), "reservation_rooms")
+	http.Redirect(wr, rq, "/make-reservation", http.StatusSeeOther)
 
-//PenthousePage handler function
-func (rp *Repository) PenthousePage(wr http.ResponseWriter, rq *http.Request) {
+}
 
-	err := render.Template(wr, "penthouse.page.tmpl", &models.TemplateData{}, rq)
+//RoomsPage lists the rooms of the catalogue
+func (rp *Repository) RoomsPage(wr http.ResponseWriter, rq *http.Request) {
+	rooms, err := rp.DB.AllRoom()
 	if err != nil {
+		helpers.ServerSideError(wr, err)
 		return
 	}
+	data := make(map[string]interface{})
+	data["rooms"] = rooms
+	_ = render.Template(wr, "rooms.page.tmpl", &models.TemplateData{
+		Data: data,
+	}, rq)
 }
 
-//ExecutivePage handler function
-func (rp *Repository) ExecutivePage(wr http.ResponseWriter, rq *http.Request) {
-
-	err := render.Template(wr, "executive.page.tmpl", &models.TemplateData{}, rq)
+//RoomPage shows the description, amenities, photos and capacity of the room with the slug
+func (rp *Repository) RoomPage(wr http.ResponseWriter, rq *http.Request) {
+	room, err := rp.DB.GetRoomBySlug(chi.URLParam(rq, "slug"))
+	if errors.Is(err, sql.ErrNoRows) {
+		helpers.ClientSideError(wr, http.StatusNotFound)
+		return
+	}
 	if err != nil {
+		helpers.ServerSideError(wr, err)
 		return
 	}
+	data := make(map[string]interface{})
+	data["room"] = room
+	_ = render.Template(wr, "room.page.tmpl", &models.TemplateData{
+		Data: data,
+	}, rq)
 }
 
 //MakeReservationPage handlers function
 func (rp *Repository) MakeReservationPage(wr http.ResponseWriter, rq *http.Request) {
 	resv, ok := rp.App.Session.Get(rq.Context(), "reservation").(models.Reservation)
 	if !ok {
-		rp.App.Session.Put(rq.Context(), "errors", "Error linking with Session: No data in session")
+		rp.putMessage(rq, "errors", "Error linking with Session: No data in session")
 		http.Redirect(wr, rq, "/", http.StatusTemporaryRedirect)
 		//helpers.ServerSideError(wr, errors.New("error linking with sessions"))
 		return
@@ -324,7 +751,7 @@ func (rp *Repository) MakeReservationPage(wr http.ResponseWriter, rq *http.Reque
 
 	room, err := rp.DB.GetRooms(resv.RoomID)
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "Error Getting the valid room id")
+		rp.putMessage(rq, "errors", "Error Getting the valid room id")
 		http.Redirect(wr, rq, "/", http.StatusTemporaryRedirect)
 		return
 		//helpers.ServerSideError(wr, err)
@@ -334,21 +761,66 @@ func (rp *Repository) MakeReservationPage(wr http.ResponseWriter, rq *http.Reque
 
 	data := make(map[string]interface{})
 	stringData := make(map[string]string)
+	quote, err := rp.quoteStay(room, resv.CheckInDate, resv.CheckOutDate)
+	if err != nil {
+		rp.putMessage(rq, "errors", "Error cannot price the stay")
+		http.Redirect(wr, rq, "/", http.StatusTemporaryRedirect)
+		return
+	}
+	charges := quote.Breakdown()
+	cancellation := []string{room.Cancellation.Terms(resv.CheckInDate)}
+
+	//several rooms selected to be booked together
+	if roomIDs, ok := rp.App.Session.Get(rq.Context(), "reservation_rooms").([]int); ok && len(roomIDs) > 1 {
+		var rooms []models.Room
+		charges = nil
+		cancellation = nil
+		for _, roomID := range roomIDs {
+			room, err := rp.DB.GetRooms(roomID)
+			if err != nil {
+				rp.putMessage(rq, "errors", "Error Getting the valid room id")
+				http.Redirect(wr, rq, "/", http.StatusTemporaryRedirect)
+				return
+			}
+			rooms = append(rooms, room)
+			quote, err := rp.quoteStay(room, resv.CheckInDate, resv.CheckOutDate)
+			if err != nil {
+				rp.putMessage(rq, "errors", "Error cannot price the stay")
+				http.Redirect(wr, rq, "/", http.StatusTemporaryRedirect)
+				return
+			}
+			charges = append(charges, quote.Breakdown()...)
+			cancellation = append(cancellation, room.RoomName+": "+room.Cancellation.Terms(resv.CheckInDate))
+		}
+		data["rooms"] = rooms
+	}
+	data["cancellation"] = cancellation
+	total := pricing.Total(charges)
+	intData := make(map[string]int)
+	if deposit := payments.Deposit(total); deposit > 0 {
+		data["charges"] = charges
+		intData["total"] = total
+		intData["deposit"] = deposit
+	}
 
 	checkInDate := resv.CheckInDate.Format("2006-01-02")
 	checkOutDate := resv.CheckOutDate.Format("2006-01-02")
 
 	stringData["check-in"] = checkInDate
 	stringData["check-out"] = checkOutDate
+	if expiresAt, ok := rp.App.Session.Get(rq.Context(), "hold_expires_at").(int64); ok {
+		stringData["hold_expires_at"] = time.Unix(expiresAt, 0).UTC().Format(time.RFC3339)
+	}
 
 	data["reservation"] = resv
 
 	rp.App.Session.Put(rq.Context(), "reservation", resv)
 
 	render.Template(wr, "make-reservation.page.tmpl", &models.TemplateData{
-		Form:       forms.NewForm(nil),
+		Form:       forms.NewLocalizedForm(nil, helpers.Locale(rq)),
 		Data:       data,
 		StringData: stringData,
+		IntData:    intData,
 	}, rq)
 }
 
@@ -357,39 +829,42 @@ func (rp *Repository) MakeReservationPage(wr http.ResponseWriter, rq *http.Reque
 func (rp *Repository) PostMakeReservationPage(wr http.ResponseWriter, rq *http.Request) {
 	err := rq.ParseForm()
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "Error Cannot Parse form data")
+		rp.putMessage(rq, "errors", "Error Cannot Parse form data")
 		http.Redirect(wr, rq, "/", http.StatusSeeOther)
 		return
 	}
 
 	resv, ok := rp.App.Session.Get(rq.Context(), "reservation").(models.Reservation)
 	if !ok {
-		rp.App.Session.Put(rq.Context(), "errors", "Error No data for reservation in session")
+		rp.putMessage(rq, "errors", "Error No data for reservation in session")
 		http.Redirect(wr, rq, "/", http.StatusSeeOther)
 		return
 
 	}
 
 	roomID, err := strconv.Atoi(rq.Form.Get("room_id"))
-	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "Error cannot get valid room id")
+	if err != nil || roomID != resv.RoomID {
+		rp.putMessage(rq, "errors", "Error cannot get valid room id")
 		http.Redirect(wr, rq, "/", http.StatusSeeOther)
 		return
 	}
 
-	resv.FirstName = rq.Form.Get("first-name")
-	resv.LastName = rq.Form.Get("last-name")
-	resv.Email = rq.Form.Get("email")
-	resv.PhoneNumber = rq.Form.Get("phone-number")
-
-	form := forms.NewForm(rq.PostForm)
-
-	//Clients and Server-side Form Validation is process*
-	form.Require("first-name", "last-name", "phone-number", "email")
+	//Clients and Server-side Form Validation is process*, the guest details are checked with the rules of the reservation
+	form := forms.NewLocalizedForm(rq.PostForm, helpers.Locale(rq))
+	err = form.Bind(&resv)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
 
-	form.ValidLenCharacter("first-name", 3, rq)
-	form.ValidLenCharacter("last-name", 3, rq)
-	form.ValidEmail("email")
+	//the use of the promo code is counted once the form is valid, a code used up in the meantime is refused.
+	//The use is given back when the booking or its deposit fails
+	promo := rp.bookingPromoCode(form, resv.Nights())
+	if promo.ID > 0 && form.FormValid() {
+		if err := rp.DB.RedeemPromoCode(promo.ID); err != nil {
+			form.AddError("promo_code", "This promo code was used up")
+		}
+	}
 
 	if !form.FormValid() {
 		data := make(map[string]interface{})
@@ -405,39 +880,83 @@ func (rp *Repository) PostMakeReservationPage(wr http.ResponseWriter, rq *http.R
 		return
 	}
 
-	NewResvervationID, err := rp.DB.InsertReservation(resv)
+	if roomIDs, ok := rp.App.Session.Get(rq.Context(), "reservation_rooms").([]int); ok && len(roomIDs) > 1 {
+		rp.makeGroupReservation(wr, rq, resv, roomIDs, promo)
+		return
+	}
+
+	//the hold on the room becomes the restriction of the reservation
+	holdIDs, _ := rp.App.Session.Get(rq.Context(), "holds").([]int)
+	resv.ID, err = rp.DB.InsertHeldReservation(resv, holdIDs)
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "Error cannot insert reservation in the database")
+		rp.releasePromoCode(promo)
+		rp.putMessage(rq, "errors", "Error cannot insert reservation, %s", err)
 		http.Redirect(wr, rq, "/", http.StatusSeeOther)
 		return
 	}
-	restriction := models.RoomRestriction{
-		ID:            0,
-		RoomID:        roomID,
-		ReservationID: NewResvervationID,
-		RestrictionID: 1,
-		CheckInDate:   resv.CheckInDate,
-		CheckOutDate:  resv.CheckOutDate,
-	}
 
-	err = rp.DB.InsertRoomRestriction(restriction)
+	room, err := rp.DB.GetRooms(resv.RoomID)
+	if err != nil {
+		rp.releasePromoCode(promo)
+		rp.putMessage(rq, "errors", "Error Getting the valid room id")
+		http.Redirect(wr, rq, "/", http.StatusSeeOther)
+		return
+	}
+	resv.Room.RoomName = room.RoomName
+	quote, err := rp.quoteStay(room, resv.CheckInDate, resv.CheckOutDate)
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "Cannot insert room restriction in the data")
+		rp.releasePromoCode(promo)
+		rp.putMessage(rq, "errors", "Error cannot price the stay")
 		http.Redirect(wr, rq, "/", http.StatusSeeOther)
 		return
 	}
+	charges := quote.Breakdown()
+	if promo.ID > 0 {
+		charges = append(charges, pricing.Discount(promo, pricing.Total(charges)))
+	}
+	resv.Charges = rp.keepCharges(resv.ID, charges)
+	payment, err := rp.takeDeposit(rq, []models.Reservation{resv})
+	if err != nil {
+		rp.releasePromoCode(promo)
+		rp.depositDeclined(wr, rq, resv, []int{resv.RoomID}, err)
+		return
+	}
+	rp.App.Session.Remove(rq.Context(), "holds")
+	rp.App.Session.Remove(rq.Context(), "hold_expires_at")
+
+	//the guest came from a waitlist hold link, the offer is used
+	if entryID := rp.App.Session.GetInt(rq.Context(), "waitlist_entry"); entryID > 0 {
+		err = rp.DB.UpdateWaitlistStatus(entryID, models.WaitlistClaimed)
+		if err != nil {
+			rp.App.ErrorLog.Println(err)
+		}
+		rp.App.Session.Remove(rq.Context(), "waitlist_entry")
+	}
+
+	//the reservation is confirmed by the webhook of the provider once the deposit is paid
+	if payment.Status == models.PaymentPending {
+		rp.putMessage(rq, "flash", "Your reservation is waiting for the payment of the deposit, we will email you once it is confirmed")
+		rp.App.Session.Put(rq.Context(), "reservation", resv)
+		rp.App.Session.Remove(rq.Context(), "booking_group")
+		http.Redirect(wr, rq, "/make-reservation-data", http.StatusSeeOther)
+		return
+	}
 
 	//Sending mail notification to customer after make a reservation
 	notifyCustomer := fmt.Sprintf(`<p>warm greetings to you <em> <strong> %v  %v </strong</em></p>`+
-		"congratulations you have successfully reserve a room  %v in our Tavern from %v to %v, Looking forward to give you our utmost service",
-		resv.FirstName, resv.LastName, resv.Room.RoomName, resv.CheckInDate.Format("2006-01-02"), resv.CheckOutDate.Format("2006-01-02"))
+		"congratulations you have successfully reserve a room  %v in our Tavern from %v to %v, Looking forward to give you our utmost service"+
+		"%v<p>%v</p>%v",
+		resv.FirstName, resv.LastName, resv.Room.RoomName, resv.CheckInDate.Format("2006-01-02"), resv.CheckOutDate.Format("2006-01-02"),
+		chargesHTML(resv.Charges, rp.App.Rates.Base()), room.Cancellation.Terms(resv.CheckInDate), rp.stayTimes())
 
+	resv.Room = room
 	mailMsg := models.MailData{
 		MailSubject:  "Reservation At Rest Tavern Inn",
 		Receiver:     resv.Email,
 		Sender:       "ayaaakinleye@gmail.com",
 		MailContent:  notifyCustomer,
 		MailTemplate: "mailTemplate.html",
+		Attachments:  rp.invoiceAttachments(resv),
 	}
 
 	rp.App.MailChannel <- mailMsg
@@ -456,15 +975,380 @@ func (rp *Repository) PostMakeReservationPage(wr http.ResponseWriter, rq *http.R
 
 	rp.App.MailChannel <- mailMsg
 	rp.App.Session.Put(rq.Context(), "reservation", resv)
+	rp.App.Session.Remove(rq.Context(), "booking_group")
 
 	//redirect the data back to avoid submitting the form more than once
 	http.Redirect(wr, rq, "/make-reservation-data", http.StatusSeeOther)
 }
 
-//MakeReservationSummary : Shows all the user information "Fullname, email, Phone Number, check-in-date,
-//check-out-date, Room reserved" and many more
-func (rp *Repository) MakeReservationSummary(wr http.ResponseWriter, rq *http.Request) {
-
+//makeGroupReservation books all the selected rooms under one parent booking and sends a single
+//confirmation listing every room, the promo code is taken off the total of the booking
+func (rp *Repository) makeGroupReservation(wr http.ResponseWriter, rq *http.Request, resv models.Reservation, roomIDs []int,
+	promo models.PromoCode) {
+	//every room is reserved for the share of the guests staying in it
+	rooms, shares, err := rp.selectedRooms(resv, roomIDs)
+	if err != nil {
+		rp.releasePromoCode(promo)
+		rp.putMessage(rq, "errors", "Error cannot reserve the selected rooms, %s", err)
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
+	resvs := make([]models.Reservation, len(rooms))
+	for i, room := range rooms {
+		resvs[i] = resv
+		resvs[i].RoomID = room.ID
+		resvs[i].Adults = shares[i].Adults
+		resvs[i].Children = shares[i].Children
+	}
+
+	holdIDs, _ := rp.App.Session.Get(rq.Context(), "holds").([]int)
+	group, err := rp.DB.InsertBookingGroup(resvs, holdIDs)
+	if err != nil {
+		rp.releasePromoCode(promo)
+		rp.App.ErrorLog.Println(err)
+		rp.putMessage(rq, "errors", "Error cannot reserve all the selected rooms, none was reserved")
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
+
+	var roomNames, cancellation []string
+	resv.Charges = nil
+	for i, r := range group.Reservations {
+		group.Reservations[i].Room = rooms[i]
+		roomNames = append(roomNames, group.Reservations[i].Room.RoomName)
+		quote, err := rp.quoteStay(group.Reservations[i].Room, r.CheckInDate, r.CheckOutDate)
+		if err != nil {
+			rp.App.ErrorLog.Println(err)
+		} else {
+			group.Reservations[i].Charges = rp.keepCharges(r.ID, quote.Breakdown())
+			resv.Charges = append(resv.Charges, group.Reservations[i].Charges...)
+		}
+		cancellation = append(cancellation, fmt.Sprintf("<li>%v: %v</li>", group.Reservations[i].Room.RoomName,
+			group.Reservations[i].Room.Cancellation.Terms(r.CheckInDate)))
+	}
+
+	//the discount is kept with the first room of the booking
+	if promo.ID > 0 {
+		discount := rp.keepCharges(group.Reservations[0].ID, []models.ReservationCharge{
+			pricing.Discount(promo, pricing.Total(resv.Charges)),
+		})
+		group.Reservations[0].Charges = append(group.Reservations[0].Charges, discount...)
+		resv.Charges = append(resv.Charges, discount...)
+	}
+	payment, err := rp.takeDeposit(rq, group.Reservations)
+	if err != nil {
+		rp.releasePromoCode(promo)
+		rp.depositDeclined(wr, rq, resv, roomIDs, err)
+		return
+	}
+	resv.BookingGroupID = group.ID
+	rp.App.Session.Remove(rq.Context(), "holds")
+	rp.App.Session.Remove(rq.Context(), "hold_expires_at")
+
+	if payment.Status == models.PaymentPending {
+		rp.putMessage(rq, "flash", "Your reservation is waiting for the payment of the deposit, we will email you once it is confirmed")
+		rp.App.Session.Put(rq.Context(), "reservation", resv)
+		rp.App.Session.Put(rq.Context(), "booking_group", group)
+		rp.App.Session.Remove(rq.Context(), "reservation_rooms")
+		http.Redirect(wr, rq, "/make-reservation-data", http.StatusSeeOther)
+		return
+	}
+
+	notifyCustomer := fmt.Sprintf(`<p>warm greetings to you <em> <strong> %v  %v </strong</em></p>`+
+		"congratulations you have successfully reserve the rooms %v in our Tavern from %v to %v under the booking number %d, Looking forward to give you our utmost service"+
+		"%v<ul>%v</ul>%v",
+		resv.FirstName, resv.LastName, strings.Join(roomNames, ", "), resv.CheckInDate.Format("2006-01-02"),
+		resv.CheckOutDate.Format("2006-01-02"), group.ID, chargesHTML(resv.Charges, rp.App.Rates.Base()), strings.Join(cancellation, ""),
+		rp.stayTimes())
+
+	rp.App.MailChannel <- models.MailData{
+		MailSubject:  "Reservation At Rest Tavern Inn",
+		Receiver:     resv.Email,
+		Sender:       "ayaaakinleye@gmail.com",
+		MailContent:  notifyCustomer,
+		MailTemplate: "mailTemplate.html",
+		Attachments:  rp.invoiceAttachments(group.Reservations...),
+	}
+
+	notifyOwner := fmt.Sprintf(`<strong>Notification for Reservation at Rest Tavern</strong><br>`+
+		"%v %v have secure a reservation of %v from %v to %v under the booking number %d", resv.FirstName, resv.LastName,
+		strings.Join(roomNames, ", "), resv.CheckInDate.Format("2006-01-02"), resv.CheckOutDate.Format("2006-01-02"), group.ID)
+
+	rp.App.MailChannel <- models.MailData{
+		MailSubject:  "Reservation At Rest Tavern Inn",
+		Receiver:     "ayaaakinleye@gmail.com",
+		Sender:       "ayaaakinleye@gmail.com",
+		MailContent:  notifyOwner,
+		MailTemplate: "mailTemplate.html",
+	}
+
+	rp.App.Session.Put(rq.Context(), "reservation", resv)
+	rp.App.Session.Put(rq.Context(), "booking_group", group)
+	rp.App.Session.Remove(rq.Context(), "reservation_rooms")
+
+	http.Redirect(wr, rq, "/make-reservation-data", http.StatusSeeOther)
+}
+
+//releasePromoCode gives back the use of the promo code counted for a booking that failed
+func (rp *Repository) releasePromoCode(promo models.PromoCode) {
+	if promo.ID == 0 {
+		return
+	}
+	if err := rp.DB.ReleasePromoCode(promo.ID); err != nil {
+		rp.App.ErrorLog.Println(err)
+	}
+}
+
+//releaseDiscounts gives back the uses of the promo codes discounted in the charges of a reservation
+//cancelled because its deposit wasn't paid
+func (rp *Repository) releaseDiscounts(charges []models.ReservationCharge) {
+	for _, charge := range charges {
+		if charge.Kind != models.ChargeDiscount || !strings.HasPrefix(charge.Name, pricing.PromoCodeCharge) {
+			continue
+		}
+		promo, err := rp.DB.GetPromoCodeByCode(strings.TrimPrefix(charge.Name, pricing.PromoCodeCharge))
+		if err != nil {
+			rp.App.ErrorLog.Println(err)
+			continue
+		}
+		rp.releasePromoCode(promo)
+	}
+}
+
+//bookingPromoCode returns the promo code typed in the booking form, the reasons it can't be used for a
+//stay of the nights are set as errors of the field. No code returns a promo code without id
+func (rp *Repository) bookingPromoCode(form *forms.Form, nights int) models.PromoCode {
+	code := strings.TrimSpace(form.Get("promo_code"))
+	if code == "" || !form.ValidPromoCode("promo_code") {
+		return models.PromoCode{}
+	}
+	promo, err := rp.DB.GetPromoCodeByCode(code)
+	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			rp.App.ErrorLog.Println(err)
+		}
+		form.AddError("promo_code", "Unknown promo code")
+		return models.PromoCode{}
+	}
+	if problem, args := promo.Problem(nights, rp.App.Property.Today()); problem != "" {
+		form.AddError("promo_code", problem, args...)
+		return models.PromoCode{}
+	}
+	return promo
+}
+
+//quoteStay prices the stay in the room with the taxes and fees in force during it
+func (rp *Repository) quoteStay(room models.Room, checkInDate, checkOutDate time.Time) (pricing.Quote, error) {
+	rules, err := rp.DB.TaxRules(checkInDate, checkOutDate)
+	if err != nil {
+		return pricing.Quote{}, err
+	}
+	return pricing.Price(room, checkInDate, checkOutDate, rules), nil
+}
+
+//keepCharges stores the charges with the reservation so later changes to the rates and rules don't
+//change its price, charges which can't be stored are logged
+func (rp *Repository) keepCharges(resvID int, charges []models.ReservationCharge) []models.ReservationCharge {
+	if err := rp.DB.InsertReservationCharges(resvID, charges); err != nil {
+		rp.App.ErrorLog.Println(err)
+	}
+	for i := range charges {
+		charges[i].ReservationID = resvID
+	}
+	return charges
+}
+
+//stayTimes tells the guest when the room can be reached and has to be left, on the clock of the property
+func (rp *Repository) stayTimes() string {
+	return fmt.Sprintf("<p>Check-in from %s, check-out until %s, %s time</p>", rp.App.Property.CheckIn(),
+		rp.App.Property.CheckOut(), html.EscapeString(rp.App.Property.Location().String()))
+}
+
+//chargesHTML returns the breakdown of the price in the currency for the confirmation emails
+func chargesHTML(charges []models.ReservationCharge, code string) string {
+	if len(charges) == 0 {
+		return ""
+	}
+	var rows strings.Builder
+	for _, charge := range charges {
+		fmt.Fprintf(&rows, "<tr><td>%v</td><td>%v %v</td></tr>", html.EscapeString(charge.Name),
+			models.FormatAmount(charge.Amount), code)
+	}
+	return fmt.Sprintf("<table>%v<tr><td><strong>Total</strong></td><td><strong>%v %v</strong></td></tr></table>",
+		rows.String(), models.FormatAmount(pricing.Total(charges)), code)
+}
+
+//takeDeposit charges the deposit of the reservations booked together on the card token of the booking form,
+//one charge for their totals. The deposit is split between the reservations in proportion to their totals
+//and each one records its part, so a cancelled room refunds and invoices what was paid for it. When the
+//charge fails the reservations are removed and the rooms freed. It returns the payment of the first
+//reservation, the deposit is paid when there is nothing to charge
+func (rp *Repository) takeDeposit(rq *http.Request, resvs []models.Reservation) (models.Payment, error) {
+	var totals []int
+	total := 0
+	for _, resv := range resvs {
+		totals = append(totals, pricing.Total(resv.Charges))
+		total += totals[len(totals)-1]
+	}
+	payment := models.Payment{Status: models.PaymentSucceeded}
+	if len(resvs) > 0 {
+		payment.ReservationID = resvs[0].ID
+		payment.BookingGroupID = resvs[0].BookingGroupID
+	}
+	amount := payments.Deposit(total)
+	if amount <= 0 {
+		return payment, nil
+	}
+
+	result, err := rp.App.Payments.Charge(payments.ChargeRequest{
+		Amount:         amount,
+		Currency:       rp.App.Rates.Base(),
+		Source:         rq.Form.Get("card_token"),
+		Description:    fmt.Sprintf("Deposit of the reservation %d", payment.ReservationID),
+		IdempotencyKey: fmt.Sprintf("deposit-%d-%d", payment.ReservationID, payment.BookingGroupID),
+	})
+	if err != nil {
+		if err := rp.DB.CancelUnpaidReservations(payment); err != nil {
+			rp.App.ErrorLog.Println(err)
+		}
+		return payment, err
+	}
+
+	payment.Provider = rp.App.Payments.Name()
+	payment.Reference = result.Reference
+	payment.Kind = models.PaymentDeposit
+	payment.Currency = rp.App.Rates.Base()
+	payment.Status = result.Status
+	first := payment
+	for i, part := range payments.Allocate(amount, totals) {
+		if part == 0 {
+			continue
+		}
+		resvPayment := payment
+		resvPayment.ReservationID = resvs[i].ID
+		resvPayment.Amount = part
+		resvPayment.ID, err = rp.DB.InsertPayment(resvPayment)
+		if err != nil {
+			rp.App.ErrorLog.Println(err)
+		}
+		if i == 0 {
+			first = resvPayment
+		}
+	}
+
+	if payment.Status == models.PaymentFailed {
+		if err := rp.DB.CancelUnpaidReservations(payment); err != nil {
+			rp.App.ErrorLog.Println(err)
+		}
+		return first, i18n.NewMessage("the card was declined")
+	}
+	return first, nil
+}
+
+//depositDeclined sends the guest back to the reservation form with the rooms held again so another card
+//can be tried
+func (rp *Repository) depositDeclined(wr http.ResponseWriter, rq *http.Request, resv models.Reservation, roomIDs []int, err error) {
+	rp.App.Session.Remove(rq.Context(), "holds")
+	if err := rp.holdRooms(rq, resv, roomIDs); err != nil {
+		rp.putMessage(rq, "errors", "cannot hold the room, %s", err)
+		http.Redirect(wr, rq, "/check-availability", http.StatusSeeOther)
+		return
+	}
+	rp.putMessage(rq, "errors", "Error cannot take the deposit, %s", err)
+	http.Redirect(wr, rq, "/make-reservation", http.StatusSeeOther)
+}
+
+//PaymentWebhook settles the pending payments with the status changes posted by the payment provider,
+//the guest is emailed when the deposit is paid or when the reservation is cancelled because it wasn't
+func (rp *Repository) PaymentWebhook(wr http.ResponseWriter, rq *http.Request) {
+	event, err := rp.App.Payments.Webhook(rq)
+	if err != nil {
+		rp.App.ErrorLog.Println(err)
+		helpers.ClientSideError(wr, http.StatusBadRequest)
+		return
+	}
+	if event.Status == models.PaymentPending {
+		wr.WriteHeader(http.StatusOK)
+		return
+	}
+
+	settled, err := rp.DB.UpdatePaymentStatus(rp.App.Payments.Name(), event.Reference, event.Status)
+	if errors.Is(err, sql.ErrNoRows) {
+		//an unknown or already settled payment, the provider would keep retrying an error
+		wr.WriteHeader(http.StatusOK)
+		return
+	}
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	//the deposit of a booking group is split between its reservations, the guest is told the whole of it
+	payment := settled[0]
+	for _, part := range settled[1:] {
+		payment.Amount += part.Amount
+	}
+
+	resv, err := rp.DB.ShowUserReservation(payment.ReservationID)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	room, err := rp.DB.GetRooms(resv.RoomID)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	resv.Charges, err = rp.DB.ReservationCharges(resv.ID)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	mailContent := fmt.Sprintf(`<p>warm greetings to you <em> <strong> %v  %v </strong</em></p>`+
+		"the deposit of %v %v was received, your reservation of the room %v in our Tavern from %v to %v is confirmed, Looking forward to give you our utmost service"+
+		"%v<p>%v</p>%v",
+		resv.FirstName, resv.LastName, models.FormatAmount(payment.Amount), payment.Currency, resv.Room.RoomName,
+		resv.CheckInDate.Format("2006-01-02"), resv.CheckOutDate.Format("2006-01-02"), chargesHTML(resv.Charges, rp.App.Rates.Base()),
+		room.Cancellation.Terms(resv.CheckInDate), rp.stayTimes())
+	if payment.Status == models.PaymentFailed {
+		for _, part := range settled {
+			charges, err := rp.DB.ReservationCharges(part.ReservationID)
+			if err != nil {
+				rp.App.ErrorLog.Println(err)
+			}
+			rp.releaseDiscounts(charges)
+		}
+		err = rp.DB.CancelUnpaidReservations(payment)
+		if err != nil {
+			helpers.ServerSideError(wr, err)
+			return
+		}
+		mailContent = fmt.Sprintf(`<p>warm greetings to you <em> <strong> %v  %v </strong</em></p>`+
+			"the deposit of your reservation of the room %v from %v to %v could not be taken, the reservation is cancelled",
+			resv.FirstName, resv.LastName, resv.Room.RoomName, resv.CheckInDate.Format("2006-01-02"),
+			resv.CheckOutDate.Format("2006-01-02"))
+	}
+
+	mailMsg := models.MailData{
+		MailSubject:  "Reservation At Rest Tavern Inn",
+		Receiver:     resv.Email,
+		Sender:       "ayaaakinleye@gmail.com",
+		MailContent:  mailContent,
+		MailTemplate: "mailTemplate.html",
+	}
+	if payment.Status == models.PaymentSucceeded {
+		resv.Room = room
+		mailMsg.Attachments = rp.invoiceAttachments(resv)
+	}
+	rp.App.MailChannel <- mailMsg
+	wr.WriteHeader(http.StatusOK)
+}
+
+//MakeReservationSummary : Shows all the user information "Fullname, email, Phone Number, check-in-date,
+//check-out-date, Room reserved" and many more
+func (rp *Repository) MakeReservationSummary(wr http.ResponseWriter, rq *http.Request) {
+
 	resv, ok := rp.App.Session.Get(rq.Context(), "reservation").(models.Reservation)
 	if !ok {
 		fmt.Println(ok)
@@ -477,12 +1361,21 @@ func (rp *Repository) MakeReservationSummary(wr http.ResponseWriter, rq *http.Re
 
 	data := make(map[string]interface{})
 	data["reservation"] = resv
+	if group, ok := rp.App.Session.Get(rq.Context(), "booking_group").(models.BookingGroup); ok {
+		data["group"] = group
+	}
+	intData := make(map[string]int)
+	if len(resv.Charges) > 0 {
+		intData["total"] = pricing.Total(resv.Charges)
+	}
 
 	//Remove the stored data in the session
 	rp.App.Session.Remove(rq.Context(), "reservation")
+	rp.App.Session.Remove(rq.Context(), "booking_group")
 
 	err := render.Template(wr, "reservation-summary.page.tmpl", &models.TemplateData{
-		Data: data,
+		Data:    data,
+		IntData: intData,
 	}, rq)
 	if err != nil {
 		return
@@ -491,7 +1384,7 @@ func (rp *Repository) MakeReservationSummary(wr http.ResponseWriter, rq *http.Re
 }
 
 func (rp *Repository) LoginPage(wr http.ResponseWriter, rq *http.Request) {
-	render.Template(wr, "login.page.tmpl", &models.TemplateData{Form: forms.NewForm(nil)}, rq)
+	render.Template(wr, "login.page.tmpl", &models.TemplateData{Form: forms.NewLocalizedForm(nil, helpers.Locale(rq))}, rq)
 
 }
 
@@ -503,30 +1396,30 @@ func (rp *Repository) PostLoginPage(wr http.ResponseWriter, rq *http.Request) {
 	_ = rp.App.Session.RenewToken(rq.Context())
 	err := rq.ParseForm()
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "No parsing the login form")
+		rp.putMessage(rq, "errors", "No parsing the login form")
 		return
 	}
 
 	//Get the input value from the form and check for authentication
 	email = rq.Form.Get("email")
 	password = rq.Form.Get("password")
-	form := forms.NewForm(rq.PostForm)
+	form := forms.NewLocalizedForm(rq.PostForm, helpers.Locale(rq))
 	form.Require("email", "password")
 	//form.ValidLenCharacter("password", 15, rq)
 	form.ValidEmail("email")
 	if !form.FormValid() {
-		render.Template(wr, "login.page.tmpl", &models.TemplateData{Form: forms.NewForm(nil)}, rq)
+		render.Template(wr, "login.page.tmpl", &models.TemplateData{Form: form}, rq)
 		return
 	}
 	userID, _, err := rp.DB.AuthenticateUser(password, email)
 	if err != nil {
 		log.Println(err)
-		rp.App.Session.Put(rq.Context(), "errors", "log in with valid details")
+		rp.putMessage(rq, "errors", "log in with valid details")
 		http.Redirect(wr, rq, "/login", http.StatusSeeOther)
 		return
 	}
 	rp.App.Session.Put(rq.Context(), "userID", userID)
-	rp.App.Session.Put(rq.Context(), "flash", "successfully logged in")
+	rp.putMessage(rq, "flash", "successfully logged in")
 	http.Redirect(wr, rq, "/", http.StatusSeeOther)
 
 }
@@ -539,14 +1432,62 @@ func (rp *Repository) LogOutPage(wr http.ResponseWriter, rq *http.Request) {
 	return
 }
 
-//AdminPage this is the Administration management page
+//AdminPage this is the Administration management page which shows today's arrivals and departures,
+//the occupancy of every room and the booking statistics of the last days
 func (rp *Repository) AdminPage(wr http.ResponseWriter, rq *http.Request) {
-	//user := rp.App.Session.Get(rq.Context(),"user")
+	data := make(map[string]interface{})
+	IntData := make(map[string]int)
 
-	err := render.Template(wr, "admin-dashboard.page.tmpl", &models.TemplateData{}, rq)
+	today := rp.App.Property.Today()
+	data["today"] = today
+
+	arrivals, err := rp.DB.ArrivalsByDate(today)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	departures, err := rp.DB.DeparturesByDate(today)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	occupancy, err := rp.DB.RoomOccupancyByDate(today)
 	if err != nil {
+		helpers.ServerSideError(wr, err)
 		return
 	}
+
+	occupied := 0
+	for _, o := range occupancy {
+		if o.Occupied() {
+			occupied++
+		}
+	}
+
+	var stats []models.OccupancyStats
+	for _, days := range dashboardPeriods {
+		//the period covers the last days including today
+		st, err := rp.DB.OccupancyStatistics(today.AddDate(0, 0, 1-days), today.AddDate(0, 0, 1))
+		if err != nil {
+			helpers.ServerSideError(wr, err)
+			return
+		}
+		stats = append(stats, st)
+	}
+
+	data["arrivals"] = arrivals
+	data["departures"] = departures
+	data["occupancy"] = occupancy
+	data["stats"] = stats
+	IntData["arrivals"] = len(arrivals)
+	IntData["departures"] = len(departures)
+	IntData["occupied"] = occupied
+	IntData["rooms"] = len(occupancy)
+
+	render.Template(wr, "admin-dashboard.page.tmpl", &models.TemplateData{
+		Data:    data,
+		IntData: IntData,
+	}, rq)
 }
 
 //AdminAllReservation this show all the registered user in the administration page
@@ -582,7 +1523,6 @@ func (rp *Repository) AdminNewReservation(wr http.ResponseWriter, rq *http.Reque
 //AdminShowReservation this shows all the reservation information about a particular user
 func (rp *Repository) AdminShowReservation(wr http.ResponseWriter, rq *http.Request) {
 	StringData := make(map[string]string)
-	data := make(map[string]interface{})
 	var src string
 	var id int
 
@@ -608,91 +1548,308 @@ func (rp *Repository) AdminShowReservation(wr http.ResponseWriter, rq *http.Requ
 		return
 	}
 
-	data["reservation"] = userResv
 	StringData["src"] = src
 	StringData["month"] = month
 	StringData["year"] = year
+	rp.renderAdminReservation(wr, rq, userResv, forms.NewForm(nil), StringData, http.StatusOK)
+}
+
+//renderAdminReservation shows the reservation with its payments and charges in the admin form, with the status
+//of the response once they are read
+func (rp *Repository) renderAdminReservation(wr http.ResponseWriter, rq *http.Request, userResv models.Reservation, form *forms.Form, StringData map[string]string, status int) {
+	resvPayments, err := rp.DB.PaymentsByReservation(userResv.ID)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	userResv.Charges, err = rp.DB.ReservationCharges(userResv.ID)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	data := make(map[string]interface{})
+	data["reservation"] = userResv
+	data["payments"] = resvPayments
+	wr.WriteHeader(status)
 	render.Template(wr, "admin-show-reservation.page.tmpl", &models.TemplateData{
-		Form:       forms.NewForm(nil),
+		Form:       form,
 		Data:       data,
 		StringData: StringData,
 	}, rq)
 }
 
-//PostAdminShowReservation this show the register user info which can be updated
-func (rp *Repository) PostAdminShowReservation(wr http.ResponseWriter, rq *http.Request) {
-	var src string
-	StringData := make(map[string]string)
+//reservationPayments returns the payments taken for the reservation itself, a deposit of a booking group
+//is linked to its first reservation only
+func (rp *Repository) reservationPayments(resvID int) ([]models.Payment, error) {
+	resvPayments, err := rp.DB.PaymentsByReservation(resvID)
+	if err != nil {
+		return nil, err
+	}
+	var own []models.Payment
+	for _, payment := range resvPayments {
+		if payment.ReservationID == resvID {
+			own = append(own, payment)
+		}
+	}
+	return own, nil
+}
 
-	err := rq.ParseForm()
+//reservationFolio returns the folio of the reservation in the room, the reservation gets its invoice
+//number the first time
+func (rp *Repository) reservationFolio(resv models.Reservation, room models.Room) (invoice.Folio, error) {
+	paidPayments, err := rp.reservationPayments(resv.ID)
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "error parsing forms")
-		return
+		return invoice.Folio{}, err
 	}
-	userInfo := strings.Split(rq.RequestURI, "/")
-	//id, err := strconv.Atoi(userInfo[len(userInfo)-1])
-	id, err := strconv.Atoi(userInfo[4])
+	if len(resv.Charges) == 0 {
+		resv.Charges, err = rp.DB.ReservationCharges(resv.ID)
+		if err != nil {
+			return invoice.Folio{}, err
+		}
+	}
+	inv, err := rp.DB.InvoiceForReservation(resv.ID, time.Now())
+	if err != nil {
+		return invoice.Folio{}, err
+	}
+	//the invoice prints the days the things happened on at the property
+	inv.IssuedAt = rp.App.Property.Local(inv.IssuedAt)
+	resv.CancelledAt = rp.App.Property.Local(resv.CancelledAt)
+	for i := range paidPayments {
+		paidPayments[i].CreatedAt = rp.App.Property.Local(paidPayments[i].CreatedAt)
+	}
+	folio := invoice.NewFolio(inv, resv, room, paidPayments)
+	folio.Currency = rp.App.Rates.Base()
+	return folio, nil
+}
 
+//invoiceAttachments returns the invoices of the reservations to attach to their confirmation, an invoice
+//which can't be made is logged and left out
+func (rp *Repository) invoiceAttachments(resvs ...models.Reservation) []models.MailAttachment {
+	var attachments []models.MailAttachment
+	for _, resv := range resvs {
+		folio, err := rp.reservationFolio(resv, resv.Room)
+		if err != nil {
+			rp.App.ErrorLog.Println(err)
+			continue
+		}
+		attachments = append(attachments, models.MailAttachment{
+			Name:        folio.FileName(),
+			ContentType: "application/pdf",
+			Data:        folio.PDF(),
+		})
+	}
+	return attachments
+}
+
+//AdminReservationInvoice downloads the invoice of the reservation as a PDF
+func (rp *Repository) AdminReservationInvoice(wr http.ResponseWriter, rq *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(rq, "id"))
 	if err != nil {
-		log.Println("invalid id conversion")
+		helpers.ClientSideError(wr, http.StatusBadRequest)
 		return
 	}
-	src = userInfo[3]
-	StringData["src"] = src
-	userResv, err := rp.DB.ShowUserReservation(id)
+	resv, err := rp.DB.ShowUserReservation(id)
 	if err != nil {
 		helpers.ServerSideError(wr, err)
 		return
 	}
-
-	userResv.FirstName = rq.Form.Get("first_name")
-	userResv.LastName = rq.Form.Get("last_name")
-	userResv.PhoneNumber = rq.Form.Get("phone_number")
-	userResv.Email = rq.Form.Get("email")
-
-	err = rp.DB.UpdateUserReservation(userResv)
+	room, err := rp.DB.GetRooms(resv.RoomID)
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "error", "error updating user reservation")
+		helpers.ServerSideError(wr, err)
 		return
 	}
-	month := rq.Form.Get("month")
-	year := rq.Form.Get("year")
-	rp.App.Session.Put(rq.Context(), "flash", "saved")
-
-	if year == "" {
-		http.Redirect(wr, rq, fmt.Sprintf("/admin/admin-%s-reservation", src), http.StatusSeeOther)
-	} else {
-		http.Redirect(wr, rq, fmt.Sprintf("/admin/admin-reservation-calendar?y=%s&m=%s", year, month), http.StatusSeeOther)
+	folio, err := rp.reservationFolio(resv, room)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
 	}
 
+	wr.Header().Set("Content-Type", "application/pdf")
+	wr.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, folio.FileName()))
+	_, _ = wr.Write(folio.PDF())
 }
 
-func (rp Repository) AdminProcessReservation(wr http.ResponseWriter, rq *http.Request) {
-	id, _ := strconv.Atoi(chi.URLParam(rq, "id"))
-	src := chi.URLParam(rq, "src")
-
-	err := rp.DB.ProcessedUpdateReservation(id, 1)
+//PostAdminCancelReservation cancels the reservation under the cancellation policy of its room, the refund
+//is what was paid less the penalty and is recorded on the reservation
+func (rp *Repository) PostAdminCancelReservation(wr http.ResponseWriter, rq *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(rq, "id"))
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "unable to processed reservation update")
+		helpers.ClientSideError(wr, http.StatusBadRequest)
 		return
 	}
-	year := rq.URL.Query().Get("y")
-	month := rq.URL.Query().Get("m")
-
-	rp.App.Session.Put(rq.Context(), "flash", "Reservation marked as processed")
+	showURL := fmt.Sprintf("/admin/admin-show-reservation/%s/%d/show", chi.URLParam(rq, "src"), id)
 
-	if year == "" {
-		http.Redirect(wr, rq, fmt.Sprintf("/admin/admin-%s-reservations", src), http.StatusSeeOther)
-	} else {
-		http.Redirect(wr, rq, fmt.Sprintf("/admin/admin-reservations-calendar?y=%s&m=%s", year, month), http.StatusSeeOther)
+	resv, err := rp.DB.ShowUserReservation(id)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	if resv.IsCancelled() {
+		rp.App.Session.Put(rq.Context(), "errors", "The reservation is already cancelled")
+		http.Redirect(wr, rq, showURL, http.StatusSeeOther)
+		return
+	}
+	room, err := rp.DB.GetRooms(resv.RoomID)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	paidPayments, err := rp.reservationPayments(id)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	//the penalty is taken on the price the stay was booked at, the reservations booked before their price
+	//was kept are priced at the rate of the room
+	resv.Charges, err = rp.DB.ReservationCharges(id)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	if len(resv.Charges) == 0 {
+		resv.Charges = pricing.Quote{Room: room, Nights: resv.Nights(),
+			Subtotal: payments.StayTotal(room.NightlyRate, resv.CheckInDate, resv.CheckOutDate)}.Breakdown()
+	}
+
+	//the deadline of the policy is a day at the property, the cancellation counts on the day it is there
+	now := time.Now()
+	penalty := payments.Penalty(room.Cancellation, resv, rp.App.Property.Date(now))
+	refund := payments.Refund(payments.Paid(paidPayments), penalty)
+	err = rp.DB.CancelReservation(id, refund, now)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", "Error cannot cancel the reservation, "+err.Error())
+		http.Redirect(wr, rq, showURL, http.StatusSeeOther)
+		return
+	}
+
+	rp.App.MailChannel <- models.MailData{
+		MailSubject: "Reservation At Rest Tavern Inn",
+		Receiver:    resv.Email,
+		Sender:      "ayaaakinleye@gmail.com",
+		MailContent: fmt.Sprintf(`<p>warm greetings to you <em> <strong> %v  %v </strong</em></p>`+
+			"your reservation of the room %v from %v to %v is cancelled, %v %v will be refunded",
+			resv.FirstName, resv.LastName, resv.Room.RoomName, resv.CheckInDate.Format("2006-01-02"),
+			resv.CheckOutDate.Format("2006-01-02"), models.FormatAmount(refund), rp.App.Rates.Base()),
+		MailTemplate: "mailTemplate.html",
+	}
+
+	rp.App.Session.Put(rq.Context(), "flash", fmt.Sprintf("Reservation cancelled, %v %v to refund",
+		models.FormatAmount(refund), rp.App.Rates.Base()))
+	http.Redirect(wr, rq, showURL, http.StatusSeeOther)
+}
+
+//adminGuest the guest details of a reservation edited by the admin, the rules of the reservation form apply
+//except a phone number without its country code is kept
+type adminGuest struct {
+	FirstName   string `form:"first-name" rules:"required,min=3,max=255"`
+	LastName    string `form:"last-name" rules:"required,min=3,max=255"`
+	Email       string `form:"email" rules:"required,email,max=255"`
+	PhoneNumber string `form:"phone-number" rules:"required,localphone"`
+}
+
+//PostAdminShowReservation this show the register user info which can be updated
+func (rp *Repository) PostAdminShowReservation(wr http.ResponseWriter, rq *http.Request) {
+	var src string
+	StringData := make(map[string]string)
+
+	err := rq.ParseForm()
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", "error parsing forms")
+		return
+	}
+	userInfo := strings.Split(rq.RequestURI, "/")
+	//id, err := strconv.Atoi(userInfo[len(userInfo)-1])
+	id, err := strconv.Atoi(userInfo[4])
+
+	if err != nil {
+		log.Println("invalid id conversion")
+		return
+	}
+	src = userInfo[3]
+	StringData["src"] = src
+	userResv, err := rp.DB.ShowUserReservation(id)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	//the admin form has the fields of the reservation form, the phone numbers stored before they were checked
+	//may stay local
+	guest := adminGuest{
+		FirstName:   userResv.FirstName,
+		LastName:    userResv.LastName,
+		Email:       userResv.Email,
+		PhoneNumber: userResv.PhoneNumber,
+	}
+	form := forms.NewForm(rq.PostForm)
+	err = form.Bind(&guest)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	userResv.FirstName = guest.FirstName
+	userResv.LastName = guest.LastName
+	userResv.Email = guest.Email
+	userResv.PhoneNumber = guest.PhoneNumber
+	month := rq.Form.Get("month")
+	year := rq.Form.Get("year")
+	if !form.FormValid() {
+		StringData["month"] = month
+		StringData["year"] = year
+		rp.renderAdminReservation(wr, rq, userResv, form, StringData, http.StatusUnprocessableEntity)
+		return
+	}
+
+	err = rp.DB.UpdateUserReservation(userResv)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "error", "error updating user reservation")
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "flash", "saved")
+
+	if year == "" {
+		http.Redirect(wr, rq, fmt.Sprintf("/admin/admin-%s-reservation", src), http.StatusSeeOther)
+	} else {
+		http.Redirect(wr, rq, fmt.Sprintf("/admin/admin-reservation-calendar?y=%s&m=%s", year, month), http.StatusSeeOther)
+	}
+
+}
+
+func (rp *Repository) AdminProcessReservation(wr http.ResponseWriter, rq *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(rq, "id"))
+	if err != nil {
+		helpers.ClientSideError(wr, http.StatusBadRequest)
+		return
+	}
+	src := chi.URLParam(rq, "src")
+
+	err = rp.DB.ProcessedUpdateReservation(id, 1)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", "unable to processed reservation update")
+		return
+	}
+	year := rq.URL.Query().Get("y")
+	month := rq.URL.Query().Get("m")
+
+	rp.App.Session.Put(rq.Context(), "flash", "Reservation marked as processed")
+
+	if year == "" {
+		http.Redirect(wr, rq, fmt.Sprintf("/admin/admin-%s-reservations", src), http.StatusSeeOther)
+	} else {
+		http.Redirect(wr, rq, fmt.Sprintf("/admin/admin-reservations-calendar?y=%s&m=%s", year, month), http.StatusSeeOther)
 	}
 
 }
 
-func (rp Repository) AdminDeleteReservation(wr http.ResponseWriter, rq *http.Request) {
-	id, _ := strconv.Atoi(chi.URLParam(rq, "id"))
+func (rp *Repository) AdminDeleteReservation(wr http.ResponseWriter, rq *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(rq, "id"))
+	if err != nil {
+		helpers.ClientSideError(wr, http.StatusBadRequest)
+		return
+	}
 	src := chi.URLParam(rq, "src")
-	err := rp.DB.DeleteUserReservation(id)
+	err = rp.DB.DeleteUserReservation(id)
 	if err != nil {
 		log.Println(err)
 		return
@@ -710,143 +1867,1555 @@ func (rp Repository) AdminDeleteReservation(wr http.ResponseWriter, rq *http.Req
 
 //AdminReservationCalendar this shows the calendar schedule of all reservations
 func (rp *Repository) AdminReservationCalendar(wr http.ResponseWriter, rq *http.Request) {
-	data := make(map[string]interface{})
+	rp.renderCalendar(wr, rq, calendarMonth(rq.URL.Query(), rp.App.Property.Today()), make(map[string]string))
+}
+
+//roomCalendar the reservations, blocks and holds of a room over a month, keyed by night
+type roomCalendar struct {
+	reservations map[string]int
+	blocks       map[string]int
+	holds        map[string]int
+	restrictions []models.RoomRestriction
+}
+
+//loadCalendar reads the reservations and blocks of every room for the month starting on the first day
+func (rp *Repository) loadCalendar(rooms []models.Room, firstDay time.Time) (map[int]roomCalendar, error) {
+	lastDay := firstDay.AddDate(0, 1, -1)
+	calendars := make(map[int]roomCalendar)
+	for _, room := range rooms {
+		cal := roomCalendar{
+			reservations: make(map[string]int),
+			blocks:       make(map[string]int),
+			holds:        make(map[string]int),
+		}
+		for d := firstDay; !d.After(lastDay); d = d.AddDate(0, 0, 1) {
+			cal.reservations[d.Format("2006-01-02")] = 0
+			cal.blocks[d.Format("2006-01-02")] = 0
+			cal.holds[d.Format("2006-01-02")] = 0
+		}
 
-	present := time.Now()
-	if rq.URL.Query().Get("y") != "" {
-		year, _ := strconv.Atoi(rq.URL.Query().Get("y"))
-		month, _ := strconv.Atoi(rq.URL.Query().Get("m"))
-		//func Date(year int, month Month, day, hour, min, sec, nsec int, loc *Location)
-		present = time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+		// get all the restrictions for the current room
+		restrictions, err := rp.DB.GetRestrictionsForRoomByDate(room.ID, firstDay, lastDay)
+		if err != nil {
+			return calendars, err
+		}
+		cal.restrictions = restrictions
+
+		for _, y := range restrictions {
+			if y.IsHold() {
+				// a guest is filling the reservation form for the room
+				for d := y.CheckInDate; d.Before(y.CheckOutDate); d = d.AddDate(0, 0, 1) {
+					cal.holds[d.Format("2006-01-02")] = y.ID
+				}
+			} else if y.ReservationID > 0 {
+				// it's a reservation with respect to the date
+				for d := y.CheckInDate; !d.After(y.CheckOutDate); d = d.AddDate(0, 0, 1) {
+					cal.reservations[d.Format("2006-01-02")] = y.ReservationID
+				}
+			} else {
+				// it's a block, every night of it points to the same block
+				for d := y.CheckInDate; d.Before(y.CheckOutDate); d = d.AddDate(0, 0, 1) {
+					cal.blocks[d.Format("2006-01-02")] = y.ID
+				}
+			}
+		}
+		calendars[room.ID] = cal
 	}
+	return calendars, nil
+}
+
+//renderCalendar shows the calendar of the month, the conflicts are keyed by the name of the calendar
+//cell which could not be saved
+func (rp *Repository) renderCalendar(wr http.ResponseWriter, rq *http.Request, present time.Time, conflicts map[string]string) {
+	data := make(map[string]interface{})
 	data["present"] = present
+	data["conflicts"] = conflicts
 	//this increase or add up to the current day // Decrease the date
 	nextDate := present.AddDate(0, 1, 0)
 	lastDate := present.AddDate(0, -1, 0)
 
-	//Dateformatting
-	nextMonthDate := nextDate.Format("01")
-	nextMonthYearDate := nextDate.Format("2006")
-
-	lastMonthDate := lastDate.Format("01")
-	lastMonthYearDate := lastDate.Format("2006")
-
 	//Storing Formatted date in the database
 	StringData := make(map[string]string)
-	StringData["next_month_date"] = nextMonthDate
-	StringData["next_month_year_date"] = nextMonthYearDate
-	StringData["last_month_date"] = lastMonthDate
-	StringData["last_month_year_date"] = lastMonthYearDate
+	StringData["next_month_date"] = nextDate.Format("01")
+	StringData["next_month_year_date"] = nextDate.Format("2006")
+	StringData["last_month_date"] = lastDate.Format("01")
+	StringData["last_month_year_date"] = lastDate.Format("2006")
 
 	StringData["current_month"] = present.Format("01")
 	StringData["current_month_year"] = present.Format("2006")
 
-	//Knowing th current date of the month
-	presentYear, presentMonth, _ := present.Date()
-	presentLocation := present.Location()
-	firstDay := time.Date(presentYear, presentMonth, 1, 0, 0, 0, 0, presentLocation)
-	lastDay := firstDay.AddDate(0, 1, -1)
-
 	IntData := make(map[string]int)
-	IntData["days_in_month"] = lastDay.Day()
+	IntData["days_in_month"] = present.AddDate(0, 1, -1).Day()
 
 	allRooms, err := rp.DB.AllRoom()
 	if err != nil {
-		rp.App.Session.Put(rq.Context(), "errors", "error cannot get room schedule from calendar")
+		helpers.ServerSideError(wr, err)
 		return
 	}
 	data["rooms"] = allRooms
-	for _, room := range allRooms {
-		reservationMap := make(map[string]int)
-		blockMap := make(map[string]int)
-
-		for d := firstDay; !d.After(lastDay); d = d.AddDate(0, 0, 1) {
-			reservationMap[d.Format("2006-01-02")] = 0
-			blockMap[d.Format("2006-01-02")] = 0
-		}
-
-		// get all the restrictions for the current room
-		restrictions, err := rp.DB.GetRestrictionsForRoomByDate(room.ID, firstDay, lastDay)
-		if err != nil {
-			helpers.ServerSideError(wr, err)
-			return
-		}
 
-		for _, y := range restrictions {
-			if y.ReservationID > 0 {
-				// it's a reservation with respect to the date
-				for d := y.CheckInDate; !d.After(y.CheckOutDate); d = d.AddDate(0, 0, 1) {
-					reservationMap[d.Format("2006-01-02")] = y.ReservationID
-				}
-			} else {
-				// it's a block
-				blockMap[y.CheckInDate.Format("2006-01-02")] = y.ID
+	calendars, err := rp.loadCalendar(allRooms, present)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	blocks := make(map[int]models.RoomRestriction)
+	holds := make(map[int]models.RoomRestriction)
+	for roomID, cal := range calendars {
+		data[fmt.Sprintf("reservation_map_%d", roomID)] = cal.reservations
+		data[fmt.Sprintf("block_map_%d", roomID)] = cal.blocks
+		data[fmt.Sprintf("hold_map_%d", roomID)] = cal.holds
+		for _, y := range cal.restrictions {
+			if y.IsHold() {
+				holds[y.ID] = y
+			} else if y.ReservationID == 0 {
+				blocks[y.ID] = y
 			}
 		}
-		data[fmt.Sprintf("reservation_map_%d", room.ID)] = reservationMap
-		data[fmt.Sprintf("block_map_%d", room.ID)] = blockMap
-		fmt.Println(
-			StringData["next_month_date"],
-			StringData["next_month_year_date"],
-			StringData["last_month_date"],
-			StringData["last_month_year_date"],
-			"getting the current year and month/n", StringData["current_month"], StringData["current_month_year"])
-
-		rp.App.Session.Put(rq.Context(), fmt.Sprintf("block_map_%d", room.ID), blockMap)
 	}
+	data["blocks"] = blocks
+	data["holds"] = holds
 
+	if len(conflicts) > 0 {
+		wr.WriteHeader(http.StatusConflict)
+	}
 	render.Template(wr, "admin-reservation-calendar.page.tmpl", &models.TemplateData{
 		StringData: StringData,
 		IntData:    IntData,
 		Data:       data}, rq)
-
 }
 
-func (rp Repository) PostAdminReservationCalendar(wr http.ResponseWriter, rq *http.Request) {
+//PostAdminReservationCalendar applies the blocks ticked to be added or removed on the calendar. The
+//calendar is read again from the database so every change is checked against the current reservations
+//and blocks, a block removed or changed by someone else since the page was loaded is reported as a
+//conflict. When a cell conflicts nothing is saved and the calendar is shown again with the conflicts
+func (rp *Repository) PostAdminReservationCalendar(wr http.ResponseWriter, rq *http.Request) {
 
 	err := rq.ParseForm()
 	if err != nil {
 		helpers.ServerSideError(wr, err)
 		return
 	}
-	year, _ := strconv.Atoi(rq.Form.Get("y"))
-	month, _ := strconv.Atoi(rq.Form.Get("m"))
+	present := calendarMonth(rq.PostForm, rp.App.Property.Today())
 
 	allRooms, err := rp.DB.AllRoom()
 	if err != nil {
 		helpers.ServerSideError(wr, err)
 		return
 	}
-	form := forms.NewForm(rq.PostForm)
-	//this is to implement the blocked reservations
-	for _, r := range allRooms {
-		currentMap := rp.App.Session.Get(rq.Context(), fmt.Sprintf("block_map_%d", r.ID)).(map[string]int)
-		for key, value := range currentMap {
-			if val, ok := currentMap[key]; ok {
-				if val > 0 {
-					if !form.HasForm(fmt.Sprintf("remove_block_%d_%s", r.ID, key)) {
-						err := rp.DB.DeleteBlockByID(value)
-						if err != nil {
-							log.Println(err)
-						}
-					}
+	calendars, err := rp.loadCalendar(allRooms, present)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	conflicts := make(map[string]string)
+	newNights := make(map[int][]time.Time)
+	var removes []models.RoomRestriction
+
+	for name := range rq.PostForm {
+		exploded := strings.Split(name, "_")
+		switch {
+		case strings.HasPrefix(name, "add_block_") && len(exploded) == 4:
+			// add_block_<room id>_<night>
+			cell := forms.NewForm(url.Values{"room_id": {exploded[2]}, "night": {exploded[3]}})
+			roomID, night := cell.Int("room_id"), cell.Date("night")
+			if !cell.FormValid() {
+				helpers.ClientSideError(wr, http.StatusBadRequest)
+				return
+			}
+			cal, ok := calendars[roomID]
+			if !ok {
+				conflicts[name] = "unknown room or night"
+				continue
+			}
+			key := night.Format("2006-01-02")
+			if cal.reservations[key] > 0 {
+				conflicts[name] = fmt.Sprintf("the room was reserved on %s in the meantime", key)
+				continue
+			}
+			if cal.blocks[key] > 0 {
+				conflicts[name] = fmt.Sprintf("the room was blocked on %s in the meantime", key)
+				continue
+			}
+			if cal.holds[key] > 0 {
+				conflicts[name] = fmt.Sprintf("a guest is booking the room on %s", key)
+				continue
+			}
+			newNights[roomID] = append(newNights[roomID], night)
+
+		case strings.HasPrefix(name, "remove_block_") && len(exploded) == 5:
+			// remove_block_<room id>_<block id>_<version>, the version is the last update of the block
+			cell := forms.NewForm(url.Values{"room_id": {exploded[2]}, "block_id": {exploded[3]}, "version": {exploded[4]}})
+			roomID, blockID, version := cell.Int("room_id"), cell.Int("block_id"), cell.Int64("version")
+			if !cell.FormValid() {
+				helpers.ClientSideError(wr, http.StatusBadRequest)
+				return
+			}
+
+			var current *models.RoomRestriction
+			for i, y := range calendars[roomID].restrictions {
+				if y.ID == blockID && y.ReservationID == 0 && !y.IsHold() {
+					current = &calendars[roomID].restrictions[i]
 				}
 			}
-		}
-	} // now handle new blocks
-	for name, _ := range rq.PostForm {
-		if strings.HasPrefix(name, "add_block") {
-			exploded := strings.Split(name, "_")
-			roomID, _ := strconv.Atoi(exploded[2])
-			t, _ := time.Parse("2006-01-02", exploded[3])
-			// insert a new block
-			err := rp.DB.InsertBlockForRoom(roomID, t)
-			if err != nil {
-				log.Println(err)
+			if current == nil {
+				conflicts[name] = "the block was already removed"
+				continue
+			}
+			if current.UpdatedAt.UnixNano() != version {
+				conflicts[name] = "the block was changed by someone else, check it before removing it"
+				continue
 			}
+			removes = append(removes, *current)
+		}
+	}
+
+	if len(conflicts) > 0 {
+		rp.App.Session.Put(rq.Context(), "errors", "Nothing was saved, the calendar changed since it was loaded")
+		rp.renderCalendar(wr, rq, present, conflicts)
+		return
+	}
+
+	adds := nightsToBlocks(newNights)
+	if len(adds) > 0 || len(removes) > 0 {
+		err = rp.DB.UpdateCalendarBlocks(adds, removes)
+		if err != nil {
+			rp.App.Session.Put(rq.Context(), "errors", err.Error())
+			http.Redirect(wr, rq, calendarURL(present), http.StatusSeeOther)
+			return
 		}
 	}
 
 	rp.App.Session.Put(rq.Context(), "flash", "Changes saved")
-	http.Redirect(wr, rq, fmt.Sprintf("/admin/admin-reservation-calendar?y=%d&m=%d", year, month), http.StatusSeeOther)
+	http.Redirect(wr, rq, calendarURL(present), http.StatusSeeOther)
+}
+
+//nightsToBlocks joins the consecutive nights ticked for a room into a single block
+func nightsToBlocks(nights map[int][]time.Time) []models.RoomRestriction {
+	var blocks []models.RoomRestriction
+	var roomIDs []int
+	for roomID := range nights {
+		roomIDs = append(roomIDs, roomID)
+	}
+	sort.Ints(roomIDs)
+
+	for _, roomID := range roomIDs {
+		days := nights[roomID]
+		sort.Slice(days, func(i, j int) bool { return days[i].Before(days[j]) })
+		for _, night := range days {
+			last := len(blocks) - 1
+			if last >= 0 && blocks[last].RoomID == roomID && blocks[last].CheckOutDate.Equal(night) {
+				blocks[last].CheckOutDate = night.AddDate(0, 0, 1)
+				continue
+			}
+			blocks = append(blocks, models.RoomRestriction{
+				RoomID:       roomID,
+				CheckInDate:  night,
+				CheckOutDate: night.AddDate(0, 0, 1),
+				Reason:       "other",
+			})
+		}
+	}
+	return blocks
+}
+
+//calendarMonth returns the first day of the month asked in the y and m parameters, the month of today
+//when they are missing or invalid
+func calendarMonth(values url.Values, today time.Time) time.Time {
+	year, err := strconv.Atoi(values.Get("y"))
+	if err != nil {
+		return time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	month, err := strconv.Atoi(values.Get("m"))
+	if err != nil || month < 1 || month > 12 {
+		return time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, time.UTC)
+	}
+	return time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC)
+}
+
+//CalendarJSON the rooms and their reservations and blocks of a month for the interactive calendar
+type CalendarJSON struct {
+	Start   string              `json:"start"`
+	Days    int                 `json:"days"`
+	Rooms   []CalendarRoomJSON  `json:"rooms"`
+	Entries []CalendarEntryJSON `json:"entries"`
+}
+
+//CalendarRoomJSON a row of the interactive calendar
+type CalendarRoomJSON struct {
+	ID          int    `json:"id"`
+	Name        string `json:"name"`
+	MaxAdults   int    `json:"max_adults"`
+	MaxChildren int    `json:"max_children"`
+}
+
+//CalendarEntryJSON a reservation or a block shown on the interactive calendar, the check-out date
+//is the first free night
+type CalendarEntryJSON struct {
+	ID            int    `json:"id"`
+	Kind          string `json:"kind"`
+	RoomID        int    `json:"room_id"`
+	ReservationID int    `json:"reservation_id,omitempty"`
+	CheckInDate   string `json:"check_in_date"`
+	CheckOutDate  string `json:"check_out_date"`
+	Label         string `json:"label"`
+}
+
+//AdminReservationPlanner shows the interactive calendar, its rooms and reservations are loaded from
+//AdminCalendarData
+func (rp *Repository) AdminReservationPlanner(wr http.ResponseWriter, rq *http.Request) {
+	present := calendarMonth(rq.URL.Query(), rp.App.Property.Today())
+	StringData := make(map[string]string)
+	StringData["year"] = present.Format("2006")
+	StringData["month"] = present.Format("01")
+	StringData["last_month_year_date"] = present.AddDate(0, -1, 0).Format("2006")
+	StringData["last_month_date"] = present.AddDate(0, -1, 0).Format("01")
+	StringData["next_month_year_date"] = present.AddDate(0, 1, 0).Format("2006")
+	StringData["next_month_date"] = present.AddDate(0, 1, 0).Format("01")
+
+	data := make(map[string]interface{})
+	data["present"] = present
+	render.Template(wr, "admin-reservation-planner.page.tmpl", &models.TemplateData{
+		StringData: StringData,
+		Data:       data,
+	}, rq)
+}
+
+//AdminCalendarData returns the rooms with their reservations and blocks of the month as json
+func (rp *Repository) AdminCalendarData(wr http.ResponseWriter, rq *http.Request) {
+	firstDay := calendarMonth(rq.URL.Query(), rp.App.Property.Today())
+	nextMonth := firstDay.AddDate(0, 1, 0)
+
+	rooms, err := rp.DB.AllRoom()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	entries, err := rp.DB.CalendarEntries(firstDay, nextMonth)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	calendar := CalendarJSON{
+		Start:   firstDay.Format("2006-01-02"),
+		Days:    int(nextMonth.Sub(firstDay).Hours() / 24),
+		Rooms:   []CalendarRoomJSON{},
+		Entries: []CalendarEntryJSON{},
+	}
+	for _, room := range rooms {
+		calendar.Rooms = append(calendar.Rooms, CalendarRoomJSON{
+			ID:          room.ID,
+			Name:        room.RoomName,
+			MaxAdults:   room.MaxAdults,
+			MaxChildren: room.MaxChildren,
+		})
+	}
+	for _, entry := range entries {
+		item := CalendarEntryJSON{
+			ID:           entry.ID,
+			Kind:         "block",
+			RoomID:       entry.RoomID,
+			CheckInDate:  entry.CheckInDate.Format("2006-01-02"),
+			CheckOutDate: entry.CheckOutDate.Format("2006-01-02"),
+			Label:        entry.Reason,
+		}
+		if entry.IsHold() {
+			item.Kind = "hold"
+			item.Label = "held until " + entry.ExpiresAt.Format("15:04")
+		} else if entry.ReservationID > 0 {
+			item.Kind = "reservation"
+			item.ReservationID = entry.ReservationID
+			item.Label = strings.TrimSpace(entry.Reservation.FirstName + " " + entry.Reservation.LastName)
+		}
+		calendar.Entries = append(calendar.Entries, item)
+	}
+
+	output, err := json.MarshalIndent(calendar, "", "   ")
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	wr.Header().Set("Content-type", "application/json")
+	wr.Write(output)
+}
+
+//PostAdminCalendarMove moves a reservation dropped on another room or date, or stretched to a new
+//check-out date, the move is checked against the guests the room holds and the other reservations
+//and blocks of the room before it is saved
+func (rp *Repository) PostAdminCalendarMove(wr http.ResponseWriter, rq *http.Request) {
+	myResp := ResponseJSON{}
+	writeResponse := func() {
+		output, _ := json.MarshalIndent(myResp, "", "   ")
+		wr.Header().Set("Content-type", "application/json")
+		wr.Write(output)
+	}
+
+	err := rq.ParseForm()
+	if err != nil {
+		myResp.Message = "server error"
+		writeResponse()
+		return
+	}
+	myResp.RoomID = rq.Form.Get("room_id")
+	myResp.CheckInDate = rq.Form.Get("check-in")
+	myResp.CheckOutDate = rq.Form.Get("check-out")
+
+	resvID, err := strconv.Atoi(rq.Form.Get("reservation_id"))
+	if err != nil {
+		myResp.Message = "invalid reservation id"
+		writeResponse()
+		return
+	}
+	roomID, err := strconv.Atoi(myResp.RoomID)
+	if err != nil {
+		myResp.Message = "invalid room id"
+		writeResponse()
+		return
+	}
+	checkInDate, err := dates.Parse(myResp.CheckInDate)
+	if err != nil {
+		myResp.Message = "invalid check-in date"
+		writeResponse()
+		return
+	}
+	checkOutDate, err := dates.Parse(myResp.CheckOutDate)
+	if err != nil || !checkOutDate.After(checkInDate) {
+		myResp.Message = "the check-out date must be after the check-in date"
+		writeResponse()
+		return
+	}
+
+	resv, err := rp.DB.ShowUserReservation(resvID)
+	if err != nil {
+		myResp.Message = fmt.Sprintf("reservation %d doesn't exist", resvID)
+		writeResponse()
+		return
+	}
+	myResp.Adults, myResp.Children = resv.Adults, resv.Children
+	room, err := rp.DB.GetRooms(roomID)
+	if err != nil {
+		myResp.Message = "invalid room id"
+		writeResponse()
+		return
+	}
+	if !room.Fits(resv.Adults, resv.Children) {
+		myResp.Message = fmt.Sprintf("the room holds at most %d adults and %d children", room.MaxAdults, room.MaxChildren)
+		writeResponse()
+		return
+	}
+
+	err = rp.DB.MoveReservation(resvID, roomID, checkInDate, checkOutDate)
+	if err != nil {
+		myResp.Message = err.Error()
+		writeResponse()
+		return
+	}
+	myResp.Ok = true
+	myResp.Message = fmt.Sprintf("reservation %d moved", resvID)
+	writeResponse()
+}
+
+//calendarURL returns the page of the reservation calendar showing the month of the date
+func calendarURL(date time.Time) string {
+	return fmt.Sprintf("/admin/admin-reservation-calendar?y=%d&m=%d", date.Year(), date.Month())
+}
+
+//AdminBlock shows the owner block with its whole date range, a new block is started when the id is "new",
+//its room and first night can be passed in the room_id and start query parameters
+func (rp *Repository) AdminBlock(wr http.ResponseWriter, rq *http.Request) {
+	block := models.RoomRestriction{Reason: models.BlockReasons[0]}
+	if id := chi.URLParam(rq, "id"); id != "new" {
+		blockID, err := strconv.Atoi(id)
+		if err != nil {
+			helpers.ClientSideError(wr, http.StatusNotFound)
+			return
+		}
+		block, err = rp.DB.GetBlockByID(blockID)
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.ClientSideError(wr, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			helpers.ServerSideError(wr, err)
+			return
+		}
+	} else {
+		query := forms.NewForm(rq.URL.Query())
+		if query.Get("room_id") != "" {
+			block.RoomID = query.Int("room_id")
+		}
+		start := rp.App.Property.Today()
+		if query.Get("start") != "" {
+			start = query.Date("start")
+		}
+		if !query.FormValid() {
+			helpers.ClientSideError(wr, http.StatusBadRequest)
+			return
+		}
+		block.CheckInDate = start
+		block.CheckOutDate = start.AddDate(0, 0, 1)
+	}
+	rp.renderBlock(wr, rq, block, forms.NewForm(nil))
+}
+
+//renderBlock shows the block page with the rooms and the reasons to choose from
+func (rp *Repository) renderBlock(wr http.ResponseWriter, rq *http.Request, block models.RoomRestriction, form *forms.Form) {
+	rooms, err := rp.DB.AllRoom()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	data := make(map[string]interface{})
+	data["block"] = block
+	data["rooms"] = rooms
+	data["reasons"] = models.BlockReasons
+
+	StringData := make(map[string]string)
+	StringData["calendar"] = calendarURL(block.CheckInDate)
+	if !form.FormValid() {
+		wr.WriteHeader(http.StatusUnprocessableEntity)
+	}
+	_ = render.Template(wr, "admin-block.page.tmpl", &models.TemplateData{
+		Data:       data,
+		StringData: StringData,
+		Form:       form,
+	}, rq)
+}
+
+//PostAdminBlock validates and saves the owner block, the repository refuses a block overlapping a
+//reservation or another block of the room
+func (rp *Repository) PostAdminBlock(wr http.ResponseWriter, rq *http.Request) {
+	err := rq.ParseForm()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	var block models.RoomRestriction
+	if id := chi.URLParam(rq, "id"); id != "new" {
+		block.ID, err = strconv.Atoi(id)
+		if err != nil {
+			helpers.ClientSideError(wr, http.StatusNotFound)
+			return
+		}
+	}
+
+	form := forms.NewForm(rq.PostForm)
+	form.Check("room_id", forms.Required(), forms.Matches(roomIDPattern, "Choose a room"))
+	form.Check("start_date", forms.Required(), forms.Date())
+	form.Check("end_date", forms.Required(), forms.Date())
+	form.Check("reason", forms.Required(), forms.OneOf(models.BlockReasons...))
+
+	block.RoomID = form.Int("room_id")
+	block.CheckInDate = form.Date("start_date")
+	//the form takes the last blocked night, the block ends the morning after
+	lastNight := form.Date("end_date")
+	if !lastNight.IsZero() {
+		block.CheckOutDate = lastNight.AddDate(0, 0, 1)
+	}
+	if !block.CheckInDate.IsZero() && !lastNight.IsZero() && lastNight.Before(block.CheckInDate) {
+		form.AddError("end_date", "The last night can't be before the first one")
+	}
+	block.Reason = form.Get("reason")
+	block.Notes = strings.TrimSpace(form.Get("notes"))
+
+	if form.FormValid() {
+		if block.ID == 0 {
+			block.ID, err = rp.DB.InsertBlock(block)
+		} else {
+			err = rp.DB.UpdateBlock(block)
+		}
+		if err != nil {
+			form.Error.Set("start_date", err.Error())
+		}
+	}
+	if !form.FormValid() {
+		rp.renderBlock(wr, rq, block, form)
+		return
+	}
+
+	rp.App.Session.Put(rq.Context(), "flash", fmt.Sprintf("Room blocked from %s to %s",
+		block.CheckInDate.Format("2006-01-02"), lastNight.Format("2006-01-02")))
+	http.Redirect(wr, rq, calendarURL(block.CheckInDate), http.StatusSeeOther)
+}
+
+//PostAdminDeleteBlock removes the whole owner block in one go
+func (rp *Repository) PostAdminDeleteBlock(wr http.ResponseWriter, rq *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(rq, "id"))
+	if err != nil {
+		helpers.ClientSideError(wr, http.StatusNotFound)
+		return
+	}
+	err = rq.ParseForm()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	calendar := "/admin/admin-reservation-calendar"
+	if start, err := dates.Parse(rq.PostForm.Get("start_date")); err == nil {
+		calendar = calendarURL(start)
+	}
+
+	err = rp.DB.DeleteBlockByID(id)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", "error cannot delete the block")
+		http.Redirect(wr, rq, calendar, http.StatusSeeOther)
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "flash", "Block removed")
+	http.Redirect(wr, rq, calendar, http.StatusSeeOther)
+}
+
+//AdminImportReservation this shows the upload form for importing offline reservations from a csv file
+func (rp *Repository) AdminImportReservation(wr http.ResponseWriter, rq *http.Request) {
+	StringData := make(map[string]string)
+	StringData["columns"] = strings.Join(importer.Columns, ",")
+
+	render.Template(wr, "admin-import-reservation.page.tmpl", &models.TemplateData{
+		StringData: StringData,
+		Data:       make(map[string]interface{}),
+	}, rq)
+}
+
+//PostAdminImportReservation this parses the uploaded csv file and shows a dry-run preview of the
+//reservations with the errors of every row, nothing is stored in the database yet
+func (rp *Repository) PostAdminImportReservation(wr http.ResponseWriter, rq *http.Request) {
+	err := rq.ParseMultipartForm(maxImportFileSize)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", "error cannot parse the uploaded file")
+		http.Redirect(wr, rq, "/admin/admin-import-reservation", http.StatusSeeOther)
+		return
+	}
+
+	file, _, err := rq.FormFile("reservation-file")
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", "select a csv file to import")
+		http.Redirect(wr, rq, "/admin/admin-import-reservation", http.StatusSeeOther)
+		return
+	}
+	defer file.Close()
+
+	rows, err := importer.ParseReservationCSV(file)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", err.Error())
+		http.Redirect(wr, rq, "/admin/admin-import-reservation", http.StatusSeeOther)
+		return
+	}
+
+	var validResv []models.Reservation
+	for i := range rows {
+		row := &rows[i]
+		if !row.Form.FormValid() {
+			continue
+		}
+
+		room, err := rp.DB.GetRooms(row.Reservation.RoomID)
+		if err != nil {
+			row.AddError(fmt.Sprintf("room %d does not exist", row.Reservation.RoomID))
+			continue
+		}
+		row.Reservation.Room = room
+		if !room.Fits(row.Reservation.Adults, row.Reservation.Children) {
+			row.AddError(fmt.Sprintf("the room holds at most %d adults and %d children", room.MaxAdults, room.MaxChildren))
+			continue
+		}
+
+		available, err := rp.DB.SearchRoomAvailabileByRoomID(row.Reservation.RoomID, row.Reservation.CheckInDate, row.Reservation.CheckOutDate)
+		if err != nil {
+			helpers.ServerSideError(wr, err)
+			return
+		}
+		if !available {
+			row.AddError("the room is not available for the requested dates")
+			continue
+		}
+
+		for _, other := range validResv {
+			if importer.Overlaps(row.Reservation, other) {
+				row.AddError("the dates overlap another row of the file for the same room")
+				break
+			}
+		}
+		if row.Valid() {
+			validResv = append(validResv, row.Reservation)
+		}
+	}
+
+	rp.App.Session.Put(rq.Context(), "import_reservation", validResv)
+
+	data := make(map[string]interface{})
+	data["rows"] = rows
+	IntData := make(map[string]int)
+	IntData["valid"] = len(validResv)
+	IntData["invalid"] = len(rows) - len(validResv)
+	StringData := make(map[string]string)
+	StringData["columns"] = strings.Join(importer.Columns, ",")
+
+	render.Template(wr, "admin-import-reservation.page.tmpl", &models.TemplateData{
+		Data:       data,
+		IntData:    IntData,
+		StringData: StringData,
+	}, rq)
+}
+
+//PostAdminConfirmImportReservation this stores the previewed reservations and their room restrictions
+//in a single transaction
+func (rp *Repository) PostAdminConfirmImportReservation(wr http.ResponseWriter, rq *http.Request) {
+	resvs, ok := rp.App.Session.Get(rq.Context(), "import_reservation").([]models.Reservation)
+	if !ok || len(resvs) == 0 {
+		rp.App.Session.Put(rq.Context(), "errors", "no previewed reservation to import")
+		http.Redirect(wr, rq, "/admin/admin-import-reservation", http.StatusSeeOther)
+		return
+	}
+
+	err := rp.DB.InsertReservationsWithRestrictions(resvs)
+	if err != nil {
+		rp.App.ErrorLog.Println(err)
+		rp.App.Session.Put(rq.Context(), "errors", "import failed, no reservation was saved: "+err.Error())
+		http.Redirect(wr, rq, "/admin/admin-import-reservation", http.StatusSeeOther)
+		return
+	}
+
+	rp.App.Session.Remove(rq.Context(), "import_reservation")
+	rp.App.Session.Put(rq.Context(), "flash", fmt.Sprintf("%d reservations imported", len(resvs)))
+	http.Redirect(wr, rq, "/admin/admin-all-reservation", http.StatusSeeOther)
+}
+
+//AdminRooms lists the rooms of the catalogue for the admin
+func (rp *Repository) AdminRooms(wr http.ResponseWriter, rq *http.Request) {
+	rooms, err := rp.DB.AllRoom()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	data := make(map[string]interface{})
+	data["rooms"] = rooms
+	_ = render.Template(wr, "admin-rooms.page.tmpl", &models.TemplateData{
+		Data: data,
+	}, rq)
+}
+
+//AdminShowRoom shows the form to edit a room of the catalogue, or to add one when the id is "new"
+func (rp *Repository) AdminShowRoom(wr http.ResponseWriter, rq *http.Request) {
+	room := models.Room{MaxAdults: 2}
+	if id := chi.URLParam(rq, "id"); id != "new" {
+		roomID, err := strconv.Atoi(id)
+		if err != nil {
+			helpers.ClientSideError(wr, http.StatusNotFound)
+			return
+		}
+		room, err = rp.DB.GetRooms(roomID)
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.ClientSideError(wr, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			helpers.ServerSideError(wr, err)
+			return
+		}
+	}
+	data := make(map[string]interface{})
+	data["room"] = room
+	if room.ID != 0 {
+		rules, err := rp.DB.BookingRulesByRoom(room.ID)
+		if err != nil {
+			helpers.ServerSideError(wr, err)
+			return
+		}
+		data["rules"] = rules
+	}
+	_ = render.Template(wr, "admin-room.page.tmpl", &models.TemplateData{
+		Data: data,
+		Form: forms.NewForm(nil),
+	}, rq)
+}
+
+//PostAdminShowRoom validates and saves the room, a new one is added when the id is "new"
+func (rp *Repository) PostAdminShowRoom(wr http.ResponseWriter, rq *http.Request) {
+	err := rq.ParseForm()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	var room models.Room
+	if id := chi.URLParam(rq, "id"); id != "new" {
+		roomID, err := strconv.Atoi(id)
+		if err != nil {
+			helpers.ClientSideError(wr, http.StatusNotFound)
+			return
+		}
+		room, err = rp.DB.GetRooms(roomID)
+		if errors.Is(err, sql.ErrNoRows) {
+			helpers.ClientSideError(wr, http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			helpers.ServerSideError(wr, err)
+			return
+		}
+	}
+
+	form := forms.NewForm(rq.PostForm)
+	form.Set("slug", strings.ToLower(strings.TrimSpace(form.Get("slug"))))
+	form.Check("room_name", forms.Required())
+	form.Check("slug", forms.Required(), forms.Matches(slugPattern, "Use lower case letters, digits and dashes only"))
+	form.Check("max_adults", forms.Required(), forms.IntRange(1, 99))
+	form.Check("max_children", forms.Required(), forms.IntRange(0, 99))
+
+	room.RoomName = strings.TrimSpace(form.Get("room_name"))
+	room.Slug = form.Get("slug")
+	room.Description = strings.TrimSpace(form.Get("description"))
+	room.Amenities = strings.TrimSpace(strings.ReplaceAll(form.Get("amenities"), "\r\n", "\n"))
+	room.MaxAdults = form.Int("max_adults")
+	room.MaxChildren = form.Int("max_children")
+	//a room without a rate is free and no deposit is taken
+	room.NightlyRate = 0
+	if rate := form.Get("nightly_rate"); rate != "" {
+		room.NightlyRate, err = models.ParseAmount(rate)
+		if err != nil {
+			form.AddError("nightly_rate", "Invalid nightly rate")
+		}
+	}
+	room.Cancellation = cancellationFromForm(form)
+
+	if slugPattern.MatchString(room.Slug) {
+		existing, err := rp.DB.GetRoomBySlug(room.Slug)
+		if err != nil && !errors.Is(err, sql.ErrNoRows) {
+			helpers.ServerSideError(wr, err)
+			return
+		}
+		if err == nil && existing.ID != room.ID {
+			form.AddError("slug", "The slug is already used by %s", existing.RoomName)
+		}
+	}
+
+	if !form.FormValid() {
+		data := make(map[string]interface{})
+		data["room"] = room
+		if room.ID != 0 {
+			data["rules"], _ = rp.DB.BookingRulesByRoom(room.ID)
+		}
+		wr.WriteHeader(http.StatusUnprocessableEntity)
+		_ = render.Template(wr, "admin-room.page.tmpl", &models.TemplateData{
+			Data: data,
+			Form: form,
+		}, rq)
+		return
+	}
+
+	if room.ID == 0 {
+		_, err = rp.DB.InsertRoom(room)
+	} else {
+		err = rp.DB.UpdateRoom(room)
+	}
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "flash", fmt.Sprintf("%s saved", room.RoomName))
+	http.Redirect(wr, rq, "/admin/admin-rooms", http.StatusSeeOther)
+}
+
+//cancellationFromForm reads the cancellation policy of the room form, a room without a penalty is
+//cancelled for free up to the check-in. The problems found are the error feedbacks of the form
+func cancellationFromForm(form *forms.Form) models.CancellationPolicy {
+	policy := models.CancellationPolicy{Penalty: form.Get("cancel_penalty")}
+	if !form.Check("cancel_penalty", forms.OneOf(models.PenaltyNone, models.PenaltyPercent, models.PenaltyFirstNight)) {
+		return policy
+	}
+	if policy.Penalty == "" || policy.Penalty == models.PenaltyNone {
+		return models.CancellationPolicy{Penalty: models.PenaltyNone}
+	}
+
+	form.Check("cancel_free_days", forms.Required(), forms.IntRange(0, 365))
+	policy.FreeDays = form.Int("cancel_free_days")
+	if policy.Penalty == models.PenaltyPercent {
+		form.Check("cancel_penalty_percent", forms.Required(), forms.IntRange(1, 100))
+		policy.PenaltyPercent = form.Int("cancel_penalty_percent")
+	}
+	return policy
+}
+
+//PostAdminDeleteRoom removes a room which was never reserved from the catalogue
+func (rp *Repository) PostAdminDeleteRoom(wr http.ResponseWriter, rq *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(rq, "id"))
+	if err != nil {
+		helpers.ClientSideError(wr, http.StatusNotFound)
+		return
+	}
+	err = rp.DB.DeleteRoom(id)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", err.Error())
+		http.Redirect(wr, rq, "/admin/admin-rooms", http.StatusSeeOther)
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "flash", "Room deleted")
+	http.Redirect(wr, rq, "/admin/admin-rooms", http.StatusSeeOther)
+}
+
+//PostAdminRoomPhoto validates the uploaded photo, stores it with its thumbnail and adds it at the end
+//of the gallery of the room
+func (rp *Repository) PostAdminRoomPhoto(wr http.ResponseWriter, rq *http.Request) {
+	roomID, err := strconv.Atoi(chi.URLParam(rq, "id"))
+	if err != nil {
+		helpers.ClientSideError(wr, http.StatusNotFound)
+		return
+	}
+	_, err = rp.DB.GetRooms(roomID)
+	if errors.Is(err, sql.ErrNoRows) {
+		helpers.ClientSideError(wr, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	roomPage := fmt.Sprintf("/admin/admin-rooms/%d", roomID)
+
+	//the photo is checked against photos.MaxSize once read, the margin leaves room for the other fields
+	rq.Body = http.MaxBytesReader(wr, rq.Body, photos.MaxSize+1<<20)
+	err = rq.ParseMultipartForm(photos.MaxSize + 1<<20)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", fmt.Sprintf("the photo is larger than %d MB or can't be read", photos.MaxSize>>20))
+		http.Redirect(wr, rq, roomPage, http.StatusSeeOther)
+		return
+	}
+
+	file, _, err := rq.FormFile("photo")
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", "select a photo to upload")
+		http.Redirect(wr, rq, roomPage, http.StatusSeeOther)
+		return
+	}
+	defer file.Close()
+
+	upload, err := photos.Process(file)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", err.Error())
+		http.Redirect(wr, rq, roomPage, http.StatusSeeOther)
+		return
+	}
+
+	name, err := storage.UniqueName(fmt.Sprintf("room-%d", roomID), "")
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	photo := models.RoomPhoto{
+		RoomID:  roomID,
+		Caption: strings.TrimSpace(rq.Form.Get("caption")),
+	}
+	photo.Path, err = rp.App.Storage.Save(name+upload.Ext, upload.Data)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	photo.ThumbnailPath, err = rp.App.Storage.Save(name+"-thumb.jpg", upload.Thumbnail)
+	if err != nil {
+		_ = rp.App.Storage.Delete(photo.Path)
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	_, err = rp.DB.InsertRoomPhoto(photo)
+	if err != nil {
+		_ = rp.App.Storage.Delete(photo.Path)
+		_ = rp.App.Storage.Delete(photo.ThumbnailPath)
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "flash", "Photo added")
+	http.Redirect(wr, rq, roomPage, http.StatusSeeOther)
+}
+
+//PostAdminRoomPhotos saves the captions and the order of the photos of the room, the photos are
+//numbered again from 1 in the order of the positions typed by the admin
+func (rp *Repository) PostAdminRoomPhotos(wr http.ResponseWriter, rq *http.Request) {
+	roomID, err := strconv.Atoi(chi.URLParam(rq, "id"))
+	if err != nil {
+		helpers.ClientSideError(wr, http.StatusNotFound)
+		return
+	}
+	roomPage := fmt.Sprintf("/admin/admin-rooms/%d", roomID)
+
+	err = rq.ParseForm()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	var gallery []models.RoomPhoto
+	for _, value := range rq.PostForm["photo_id"] {
+		photoID, err := strconv.Atoi(value)
+		if err != nil {
+			rp.App.Session.Put(rq.Context(), "errors", "invalid photo")
+			http.Redirect(wr, rq, roomPage, http.StatusSeeOther)
+			return
+		}
+		position, err := strconv.Atoi(rq.PostForm.Get(fmt.Sprintf("position-%d", photoID)))
+		if err != nil {
+			rp.App.Session.Put(rq.Context(), "errors", "the position of every photo must be a number")
+			http.Redirect(wr, rq, roomPage, http.StatusSeeOther)
+			return
+		}
+		gallery = append(gallery, models.RoomPhoto{
+			ID:       photoID,
+			RoomID:   roomID,
+			Caption:  strings.TrimSpace(rq.PostForm.Get(fmt.Sprintf("caption-%d", photoID))),
+			Position: position,
+		})
+	}
+	sort.SliceStable(gallery, func(i, j int) bool {
+		return gallery[i].Position < gallery[j].Position
+	})
+	for i := range gallery {
+		gallery[i].Position = i + 1
+	}
+
+	err = rp.DB.UpdateRoomPhotos(roomID, gallery)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", err.Error())
+		http.Redirect(wr, rq, roomPage, http.StatusSeeOther)
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "flash", "Photos saved")
+	http.Redirect(wr, rq, roomPage, http.StatusSeeOther)
+}
+
+//PostAdminDeleteRoomPhoto removes the photo from the gallery and its files from the storage
+func (rp *Repository) PostAdminDeleteRoomPhoto(wr http.ResponseWriter, rq *http.Request) {
+	roomID, err := strconv.Atoi(chi.URLParam(rq, "id"))
+	if err != nil {
+		helpers.ClientSideError(wr, http.StatusNotFound)
+		return
+	}
+	photoID, err := strconv.Atoi(chi.URLParam(rq, "photoID"))
+	if err != nil {
+		helpers.ClientSideError(wr, http.StatusNotFound)
+		return
+	}
+	roomPage := fmt.Sprintf("/admin/admin-rooms/%d", roomID)
+
+	photo, err := rp.DB.DeleteRoomPhoto(roomID, photoID)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", "error cannot delete the photo")
+		http.Redirect(wr, rq, roomPage, http.StatusSeeOther)
+		return
+	}
+	for _, path := range []string{photo.Path, photo.ThumbnailPath} {
+		if err = rp.App.Storage.Delete(path); err != nil {
+			rp.App.ErrorLog.Println(err)
+		}
+	}
+	rp.App.Session.Put(rq.Context(), "flash", "Photo deleted")
+	http.Redirect(wr, rq, roomPage, http.StatusSeeOther)
+}
+
+//PostAdminBookingRule validates and adds a booking rule to the room
+func (rp *Repository) PostAdminBookingRule(wr http.ResponseWriter, rq *http.Request) {
+	roomID, err := strconv.Atoi(chi.URLParam(rq, "id"))
+	if err != nil {
+		helpers.ClientSideError(wr, http.StatusNotFound)
+		return
+	}
+	_, err = rp.DB.GetRooms(roomID)
+	if errors.Is(err, sql.ErrNoRows) {
+		helpers.ClientSideError(wr, http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	roomPage := fmt.Sprintf("/admin/admin-rooms/%d", roomID)
+
+	err = rq.ParseForm()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	form := forms.NewForm(rq.PostForm)
+	rule := bookingRuleFromForm(form)
+	if !form.FormValid() {
+		rp.App.Session.Put(rq.Context(), "errors", formProblems(form, "start_date", "end_date", "min_nights",
+			"max_nights", "lead_days", "max_advance_days"))
+		http.Redirect(wr, rq, roomPage, http.StatusSeeOther)
+		return
+	}
+	rule.RoomID = roomID
+
+	_, err = rp.DB.InsertBookingRule(rule)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "flash", "Booking rule added")
+	http.Redirect(wr, rq, roomPage, http.StatusSeeOther)
+}
+
+//bookingRuleFromForm reads a booking rule from the admin form, the problems found are the error feedbacks
+//of the form
+func bookingRuleFromForm(form *forms.Form) models.BookingRule {
+	var rule models.BookingRule
+	form.Check("start_date", forms.Required(), forms.Date())
+	form.Check("end_date", forms.Required(), forms.Date())
+	form.Check("min_nights", forms.IntRange(0, 365))
+	form.Check("max_nights", forms.IntRange(0, 365))
+	form.Check("lead_days", forms.IntRange(0, 3650))
+	form.Check("max_advance_days", forms.IntRange(0, 3650))
+
+	rule.StartDate = form.Date("start_date")
+	rule.EndDate = form.Date("end_date")
+	if !rule.StartDate.IsZero() && !rule.EndDate.IsZero() && rule.EndDate.Before(rule.StartDate) {
+		form.AddError("end_date", "the end date can't be before the start date")
+	}
+	rule.MinNights = optionalInt(form, "min_nights")
+	rule.MaxNights = optionalInt(form, "max_nights")
+	rule.LeadDays = optionalInt(form, "lead_days")
+	rule.MaxAdvanceDays = optionalInt(form, "max_advance_days")
+	if rule.MaxNights > 0 && rule.MaxNights < rule.MinNights {
+		form.AddError("max_nights", "the maximum nights can't be less than the minimum nights")
+	}
+
+	rule.ClosedToArrival = form.Get("closed_to_arrival") != ""
+	rule.ClosedToDeparture = form.Get("closed_to_departure") != ""
+	if form.FormValid() && rule.MinNights == 0 && rule.MaxNights == 0 && rule.LeadDays == 0 &&
+		rule.MaxAdvanceDays == 0 && !rule.ClosedToArrival && !rule.ClosedToDeparture {
+		form.AddError("min_nights", "the booking rule doesn't restrict anything")
+	}
+	return rule
+}
+
+//optionalInt returns the whole number typed in the optional form field, 0 when it is left blank
+func optionalInt(form *forms.Form, formField string) int {
+	if strings.TrimSpace(form.Get(formField)) == "" {
+		return 0
+	}
+	return form.Int(formField)
+}
+
+//formProblems joins the error feedbacks of the form fields in their order for the message of the pages
+//which redirect, each one after the name of its field
+func formProblems(form *forms.Form, formFields ...string) string {
+	var problems []string
+	for _, field := range formFields {
+		if message := form.Error.Get(field); message != "" {
+			problems = append(problems, fmt.Sprintf("%s: %s", field, message))
+		}
+	}
+	return strings.Join(problems, ", ")
+}
+
+//PostAdminDeleteBookingRule removes a booking rule of the room
+func (rp *Repository) PostAdminDeleteBookingRule(wr http.ResponseWriter, rq *http.Request) {
+	roomID, err := strconv.Atoi(chi.URLParam(rq, "id"))
+	if err != nil {
+		helpers.ClientSideError(wr, http.StatusNotFound)
+		return
+	}
+	ruleID, err := strconv.Atoi(chi.URLParam(rq, "ruleID"))
+	if err != nil {
+		helpers.ClientSideError(wr, http.StatusNotFound)
+		return
+	}
+	roomPage := fmt.Sprintf("/admin/admin-rooms/%d", roomID)
+
+	err = rp.DB.DeleteBookingRule(roomID, ruleID)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", "error cannot delete the booking rule")
+		http.Redirect(wr, rq, roomPage, http.StatusSeeOther)
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "flash", "Booking rule deleted")
+	http.Redirect(wr, rq, roomPage, http.StatusSeeOther)
+}
+
+//AdminTaxes lists the taxes and fees added to the price of the stays with the form to add one
+func (rp *Repository) AdminTaxes(wr http.ResponseWriter, rq *http.Request) {
+	rules, err := rp.DB.AllTaxRules()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	rooms, err := rp.DB.AllRoom()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	roomNames := make(map[int]string)
+	for _, room := range rooms {
+		roomNames[room.ID] = room.RoomName
+	}
+
+	data := make(map[string]interface{})
+	data["rules"] = rules
+	data["rooms"] = rooms
+	data["room_names"] = roomNames
+	_ = render.Template(wr, "admin-taxes.page.tmpl", &models.TemplateData{
+		Data: data,
+	}, rq)
+}
+
+//PostAdminTaxRule validates and adds a tax or a fee, the reservations already booked keep their price
+func (rp *Repository) PostAdminTaxRule(wr http.ResponseWriter, rq *http.Request) {
+	err := rq.ParseForm()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	form := forms.NewForm(rq.PostForm)
+	rule := taxRuleFromForm(form)
+	if !form.FormValid() {
+		rp.App.Session.Put(rq.Context(), "errors", formProblems(form, "name", "room_id", "kind", "per", "basis",
+			"value", "start_date", "end_date"))
+		http.Redirect(wr, rq, "/admin/admin-taxes", http.StatusSeeOther)
+		return
+	}
+
+	_, err = rp.DB.InsertTaxRule(rule)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "flash", "Tax rule added")
+	http.Redirect(wr, rq, "/admin/admin-taxes", http.StatusSeeOther)
+}
+
+//taxRuleFromForm reads a tax or a fee from the admin form, the problems found are the error feedbacks
+//of the form
+func taxRuleFromForm(form *forms.Form) models.TaxRule {
+	var rule models.TaxRule
+	var err error
+	form.Check("name", forms.Required())
+	form.Check("room_id", forms.Matches(roomIDPattern, "invalid room"))
+	form.Check("kind", forms.Required(), forms.OneOf(models.ChargeTax, models.ChargeFee))
+	form.Check("per", forms.Required(), forms.OneOf(models.PerNight, models.PerStay))
+	form.Check("basis", forms.Required(), forms.OneOf(models.BasisPercent, models.BasisFixed))
+	form.Check("value", forms.Required())
+	form.Check("start_date", forms.Required(), forms.Date())
+	form.Check("end_date", forms.Date())
+
+	rule.Name = strings.TrimSpace(form.Get("name"))
+	rule.RoomID = optionalInt(form, "room_id")
+	rule.Kind = form.Get("kind")
+	rule.Per = form.Get("per")
+	rule.Basis = form.Get("basis")
+	if form.Error.Get("value") == "" {
+		switch rule.Basis {
+		case models.BasisPercent:
+			//the percentage has two decimals like an amount, so it is read in hundredths of a percent
+			rule.Rate, err = models.ParseAmount(form.Get("value"))
+			if err != nil || rule.Rate == 0 || rule.Rate > 10000 {
+				form.AddError("value", "the percentage must be more than 0 and at most 100")
+			}
+		case models.BasisFixed:
+			rule.Amount, err = models.ParseAmount(form.Get("value"))
+			if err != nil || rule.Amount == 0 {
+				form.AddError("value", "the amount must be more than 0")
+			}
+		}
+	}
+
+	rule.StartDate = form.Date("start_date")
+	if strings.TrimSpace(form.Get("end_date")) != "" {
+		rule.EndDate = form.Date("end_date")
+		if !rule.StartDate.IsZero() && !rule.EndDate.IsZero() && rule.EndDate.Before(rule.StartDate) {
+			form.AddError("end_date", "the end date can't be before the start date")
+		}
+	}
+	return rule
+}
+
+//PostAdminDeleteTaxRule removes a tax or a fee
+func (rp *Repository) PostAdminDeleteTaxRule(wr http.ResponseWriter, rq *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(rq, "id"))
+	if err != nil {
+		helpers.ClientSideError(wr, http.StatusNotFound)
+		return
+	}
+
+	err = rp.DB.DeleteTaxRule(id)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", "error cannot delete the tax rule")
+		http.Redirect(wr, rq, "/admin/admin-taxes", http.StatusSeeOther)
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "flash", "Tax rule deleted")
+	http.Redirect(wr, rq, "/admin/admin-taxes", http.StatusSeeOther)
+}
+
+//AdminPromoCodes lists the promo codes with the number of times they were used and the form to add one
+func (rp *Repository) AdminPromoCodes(wr http.ResponseWriter, rq *http.Request) {
+	promos, err := rp.DB.AllPromoCodes()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	data := make(map[string]interface{})
+	data["promos"] = promos
+	_ = render.Template(wr, "admin-promo-codes.page.tmpl", &models.TemplateData{
+		Data: data,
+	}, rq)
+}
+
+//PostAdminPromoCode validates and adds a promo code
+func (rp *Repository) PostAdminPromoCode(wr http.ResponseWriter, rq *http.Request) {
+	err := rq.ParseForm()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	form := forms.NewForm(rq.PostForm)
+	promo := promoCodeFromForm(form)
+	if !form.FormValid() {
+		rp.App.Session.Put(rq.Context(), "errors", formProblems(form, "code", "basis", "value", "min_nights",
+			"max_redemptions", "start_date", "end_date"))
+		http.Redirect(wr, rq, "/admin/admin-promo-codes", http.StatusSeeOther)
+		return
+	}
+
+	_, err = rp.DB.InsertPromoCode(promo)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "flash", "Promo code added")
+	http.Redirect(wr, rq, "/admin/admin-promo-codes", http.StatusSeeOther)
+}
+
+//promoCodeFromForm reads a promo code from the admin form, the problems found are the error feedbacks
+//of the form
+func promoCodeFromForm(form *forms.Form) models.PromoCode {
+	var promo models.PromoCode
+	var err error
+	if form.Check("code", forms.Required()) {
+		form.ValidPromoCode("code")
+	}
+	form.Check("basis", forms.Required(), forms.OneOf(models.BasisPercent, models.BasisFixed))
+	form.Check("value", forms.Required())
+	form.Check("min_nights", forms.IntRange(0, 365))
+	form.Check("max_redemptions", forms.IntRange(0, 1000000))
+	form.Check("start_date", forms.Required(), forms.Date())
+	form.Check("end_date", forms.Date())
+
+	promo.Code = strings.ToUpper(strings.TrimSpace(form.Get("code")))
+	promo.Description = strings.TrimSpace(form.Get("description"))
+	promo.Basis = form.Get("basis")
+	if form.Error.Get("value") == "" {
+		switch promo.Basis {
+		case models.BasisPercent:
+			promo.Rate, err = models.ParseAmount(form.Get("value"))
+			if err != nil || promo.Rate == 0 || promo.Rate > 10000 {
+				form.AddError("value", "the percentage must be more than 0 and at most 100")
+			}
+		case models.BasisFixed:
+			promo.Amount, err = models.ParseAmount(form.Get("value"))
+			if err != nil || promo.Amount == 0 {
+				form.AddError("value", "the amount must be more than 0")
+			}
+		}
+	}
+	promo.MinNights = optionalInt(form, "min_nights")
+	promo.MaxRedemptions = optionalInt(form, "max_redemptions")
+
+	promo.StartDate = form.Date("start_date")
+	if strings.TrimSpace(form.Get("end_date")) != "" {
+		promo.EndDate = form.Date("end_date")
+		if !promo.StartDate.IsZero() && !promo.EndDate.IsZero() && promo.EndDate.Before(promo.StartDate) {
+			form.AddError("end_date", "the end date can't be before the start date")
+		}
+	}
+	return promo
+}
+
+//PostAdminDeletePromoCode removes a promo code
+func (rp *Repository) PostAdminDeletePromoCode(wr http.ResponseWriter, rq *http.Request) {
+	id, err := strconv.Atoi(chi.URLParam(rq, "id"))
+	if err != nil {
+		helpers.ClientSideError(wr, http.StatusNotFound)
+		return
+	}
+
+	err = rp.DB.DeletePromoCode(id)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", "error cannot delete the promo code")
+		http.Redirect(wr, rq, "/admin/admin-promo-codes", http.StatusSeeOther)
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "flash", "Promo code deleted")
+	http.Redirect(wr, rq, "/admin/admin-promo-codes", http.StatusSeeOther)
+}
+
+//PostDisplayCurrency keeps the currency the guest displays the prices in for the rest of the visit and
+//goes back to the page the guest came from
+func (rp *Repository) PostDisplayCurrency(wr http.ResponseWriter, rq *http.Request) {
+	err := rq.ParseForm()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	code := strings.ToUpper(strings.TrimSpace(rq.Form.Get("currency")))
+	if rp.App.Rates.Known(code) {
+		rp.App.Session.Put(rq.Context(), "currency", code)
+	} else {
+		rp.putMessage(rq, "errors", "Error the prices can't be shown in this currency")
+	}
+
+	http.Redirect(wr, rq, refererPath(rq), http.StatusSeeOther)
+}
+
+//PostLocale keeps the language the guest reads the pages in for the rest of the visit and goes back to
+//the page the guest came from
+func (rp *Repository) PostLocale(wr http.ResponseWriter, rq *http.Request) {
+	err := rq.ParseForm()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	locale := strings.ToLower(strings.TrimSpace(rq.Form.Get("locale")))
+	if i18n.Supported(locale) {
+		rp.App.Session.Put(rq.Context(), "locale", locale)
+	} else {
+		rp.putMessage(rq, "errors", "Error the pages can't be shown in this language")
+	}
+	http.Redirect(wr, rq, refererPath(rq), http.StatusSeeOther)
+}
+
+//refererPath returns the page the request came from, only its path is followed so the guest stays on the site
+func refererPath(rq *http.Request) string {
+	back := "/"
+	if referer, err := url.Parse(rq.Referer()); err == nil && strings.HasPrefix(referer.Path, "/") {
+		back = referer.Path
+		if referer.RawQuery != "" {
+			back += "?" + referer.RawQuery
+		}
+	}
+	return back
+}
+
+//reloadRates replaces the exchange rates the prices are displayed with by the stored ones
+func (rp *Repository) reloadRates() error {
+	rates, err := rp.DB.AllExchangeRates()
+	if err != nil {
+		return err
+	}
+	rp.App.Rates.Set(rates)
+	return nil
+}
+
+//AdminCurrencies lists the exchange rates from the currency of the property with the forms to change
+//them one by one or from a rates file
+func (rp *Repository) AdminCurrencies(wr http.ResponseWriter, rq *http.Request) {
+	rates, err := rp.DB.AllExchangeRates()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	data := make(map[string]interface{})
+	data["rates"] = rates
+	_ = render.Template(wr, "admin-currencies.page.tmpl", &models.TemplateData{
+		Data: data,
+	}, rq)
+}
+
+//PostAdminExchangeRate validates and stores the exchange rate of a currency, the rate it had is replaced
+func (rp *Repository) PostAdminExchangeRate(wr http.ResponseWriter, rq *http.Request) {
+	err := rq.ParseForm()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+
+	rate := models.ExchangeRate{Currency: strings.ToUpper(strings.TrimSpace(rq.PostForm.Get("currency")))}
+	var problems []string
+	if !currency.ValidCode(rate.Currency) {
+		problems = append(problems, "the currency must be a code of 3 letters")
+	} else if rate.Currency == rp.App.Rates.Base() {
+		problems = append(problems, "the prices are already in "+rate.Currency)
+	}
+	rate.Rate, err = currency.ParseRate(rq.PostForm.Get("rate"))
+	if err != nil {
+		problems = append(problems, "the rate must be a positive number")
+	}
+	if len(problems) > 0 {
+		rp.App.Session.Put(rq.Context(), "errors", strings.Join(problems, ", "))
+		http.Redirect(wr, rq, "/admin/admin-currencies", http.StatusSeeOther)
+		return
+	}
+
+	rp.storeRates(wr, rq, []models.ExchangeRate{rate}, "Exchange rate saved")
+}
+
+//PostAdminImportExchangeRates stores every rate of the uploaded rates file, the file is refused as a
+//whole when a line is invalid
+func (rp *Repository) PostAdminImportExchangeRates(wr http.ResponseWriter, rq *http.Request) {
+	err := rq.ParseMultipartForm(maxImportFileSize)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", "error cannot parse the uploaded file")
+		http.Redirect(wr, rq, "/admin/admin-currencies", http.StatusSeeOther)
+		return
+	}
+
+	file, _, err := rq.FormFile("rates-file")
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", "select a rates file to import")
+		http.Redirect(wr, rq, "/admin/admin-currencies", http.StatusSeeOther)
+		return
+	}
+	defer file.Close()
+
+	rates, err := currency.ParseRates(file)
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", err.Error())
+		http.Redirect(wr, rq, "/admin/admin-currencies", http.StatusSeeOther)
+		return
+	}
+	for _, rate := range rates {
+		if rate.Currency == rp.App.Rates.Base() {
+			rp.App.Session.Put(rq.Context(), "errors", "the rates file can't change the rate of "+rate.Currency)
+			http.Redirect(wr, rq, "/admin/admin-currencies", http.StatusSeeOther)
+			return
+		}
+	}
+
+	rp.storeRates(wr, rq, rates, fmt.Sprintf("%d exchange rates imported", len(rates)))
+}
+
+//storeRates saves the rates and displays the prices with them from now on
+func (rp *Repository) storeRates(wr http.ResponseWriter, rq *http.Request, rates []models.ExchangeRate, flash string) {
+	err := rp.DB.UpsertExchangeRates(rates)
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	err = rp.reloadRates()
+	if err != nil {
+		helpers.ServerSideError(wr, err)
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "flash", flash)
+	http.Redirect(wr, rq, "/admin/admin-currencies", http.StatusSeeOther)
+}
+
+//PostAdminDeleteExchangeRate removes the exchange rate of a currency
+func (rp *Repository) PostAdminDeleteExchangeRate(wr http.ResponseWriter, rq *http.Request) {
+	err := rp.DB.DeleteExchangeRate(chi.URLParam(rq, "currency"))
+	if err == nil {
+		err = rp.reloadRates()
+	}
+	if err != nil {
+		rp.App.Session.Put(rq.Context(), "errors", "error cannot delete the exchange rate")
+		http.Redirect(wr, rq, "/admin/admin-currencies", http.StatusSeeOther)
+		return
+	}
+	rp.App.Session.Put(rq.Context(), "flash", "Exchange rate deleted")
+	http.Redirect(wr, rq, "/admin/admin-currencies", http.StatusSeeOther)
 }
@@ -1,20 +1,29 @@
 package handlers
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"image"
+	"image/png"
 	"log"
+	"mime/multipart"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
+	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	_ "github.com/alexedwards/scs/v2"
 	"github.com/dev-ayaa/resvbooking/pkg/driver"
 	"github.com/dev-ayaa/resvbooking/pkg/models"
+	"github.com/dev-ayaa/resvbooking/pkg/payments"
+	"github.com/dev-ayaa/resvbooking/repository/dbRepository"
+	"github.com/go-chi/chi"
 )
 
 //Notice
@@ -57,6 +66,10 @@ var hTests = []struct {
 	{pageName: "ContactPage", pagesUrl: "/contact", pageMethod: "GET", pageStatusCode: http.StatusOK},
 	{pageName: "JuniorSuitePage", pagesUrl: "/junior-suite", pageMethod: "GET", pageStatusCode: http.StatusOK},
 	{pageName: "DeluxeSuitePage", pagesUrl: "/deluxe-suite", pageMethod: "GET", pageStatusCode: http.StatusOK},
+	{pageName: "RoomsPage", pagesUrl: "/rooms", pageMethod: "GET", pageStatusCode: http.StatusOK},
+	{pageName: "RoomPage", pagesUrl: "/rooms/deluxe-suite", pageMethod: "GET", pageStatusCode: http.StatusOK},
+	{pageName: "MissingRoomPage", pagesUrl: "/rooms/no-such-room", pageMethod: "GET", pageStatusCode: http.StatusNotFound},
+	{pageName: "BrokenRoomPage", pagesUrl: "/rooms/broken-suite", pageMethod: "GET", pageStatusCode: http.StatusInternalServerError},
 	{pageName: "MakeReservationPage", pagesUrl: "/make-reservation", pageMethod: "GET", pageStatusCode: http.StatusOK},
 	{pageName: "MakeReservationSummary", pagesUrl: "/make-reservation-data", pageMethod: "GET", pageStatusCode: http.StatusOK},
 	{pageName: "CheckAvailabilityPage", pagesUrl: "/check-availability", pageMethod: "GET", pageStatusCode: http.StatusOK},
@@ -70,6 +83,25 @@ var hTests = []struct {
 	{"ResvCalendarValue", "/admin/admin-reservation-calendar?y=2022&m=05", "GET", http.StatusOK},
 
 	{"ShowResv", "/admin/admin-show-reservation/new/1/show", "GET", http.StatusOK},
+	{"ImportResv", "/admin/admin-import-reservation", "GET", http.StatusOK},
+	{"AdminRooms", "/admin/admin-rooms", "GET", http.StatusOK},
+	{"AdminNewRoom", "/admin/admin-rooms/new", "GET", http.StatusOK},
+	{"AdminShowRoom", "/admin/admin-rooms/1", "GET", http.StatusOK},
+	{"AdminShowMissingRoom", "/admin/admin-rooms/9", "GET", http.StatusNotFound},
+	{"AdminShowRoomError", "/admin/admin-rooms/99", "GET", http.StatusInternalServerError},
+	{"AdminTaxes", "/admin/admin-taxes", "GET", http.StatusOK},
+	{"AdminPromoCodes", "/admin/admin-promo-codes", "GET", http.StatusOK},
+	{"AdminCurrencies", "/admin/admin-currencies", "GET", http.StatusOK},
+	{"AdminNewBlock", "/admin/admin-blocks/new?room_id=1&start=2022-05-10", "GET", http.StatusOK},
+	{"AdminNewBlockToday", "/admin/admin-blocks/new", "GET", http.StatusOK},
+	{"AdminNewBlockBadRoom", "/admin/admin-blocks/new?room_id=slim", "GET", http.StatusBadRequest},
+	{"AdminNewBlockBadStart", "/admin/admin-blocks/new?room_id=1&start=2022-13-45", "GET", http.StatusBadRequest},
+	{"AdminShowBlock", "/admin/admin-blocks/1", "GET", http.StatusOK},
+	{"AdminShowMissingBlock", "/admin/admin-blocks/9", "GET", http.StatusNotFound},
+	{"AdminShowUnreadableBlock", "/admin/admin-blocks/99", "GET", http.StatusInternalServerError},
+	{"ResvPlanner", "/admin/admin-reservation-planner?y=2022&m=05", "GET", http.StatusOK},
+	{"CalendarData", "/admin/admin-calendar-data?y=2022&m=05", "GET", http.StatusOK},
+	{"CalendarDataError", "/admin/admin-calendar-data?y=2046&m=01", "GET", http.StatusInternalServerError},
 	//{"DeleteResv", "/admin/admin-delete-reservation/new/1/done", "GET", http.StatusSeeOther},
 	//{"ProcessResv", "/admin/admin-process-reservation/new/1/done", "GET", http.StatusSeeOther},
 
@@ -149,7 +181,7 @@ var PostMakeResv = []struct {
 			"first-name":   {"Graham"},
 			"last-name":    {"Graham"},
 			"email":        {"Grahams@gmail.com"},
-			"phone-number": {"20229028844"},
+			"phone-number": {"+20229028844"},
 			"room_id":      {"1"},
 		},
 		correctStatusCode: http.StatusSeeOther,
@@ -163,7 +195,7 @@ var PostMakeResv = []struct {
 			"first-name":   {"Graham"},
 			"last-name":    {"Graham"},
 			"email":        {"Grahams@gmail.com"},
-			"phone-number": {"20229028844"},
+			"phone-number": {"+20229028844"},
 			"room_id":      {"1"},
 		},
 		correctStatusCode: http.StatusSeeOther,
@@ -176,7 +208,7 @@ var PostMakeResv = []struct {
 			"first-name":   {"Graham"},
 			"last-name":    {"Graham"},
 			"email":        {"Grahams@gmail.com"},
-			"phone-number": {"20229028844"},
+			"phone-number": {"+20229028844"},
 			"room_id":      {"1"},
 		},
 		correctStatusCode: http.StatusSeeOther,
@@ -189,7 +221,7 @@ var PostMakeResv = []struct {
 			"first-name":   {"Graham"},
 			"last-name":    {"Graham"},
 			"email":        {"Grahams@gmail.com"},
-			"phone-number": {"20229028844"},
+			"phone-number": {"+20229028844"},
 			"room_id":      {"invalid"},
 		},
 		correctStatusCode: http.StatusSeeOther,
@@ -202,7 +234,7 @@ var PostMakeResv = []struct {
 			"first-name":   {"G"},
 			"last-name":    {"G"},
 			"email":        {"Grahams@gmail.com"},
-			"phone-number": {"20229028844"},
+			"phone-number": {"+20229028844"},
 			"room_id":      {"1"},
 		},
 		correctStatusCode: http.StatusSeeOther,
@@ -215,7 +247,7 @@ var PostMakeResv = []struct {
 			"first-name":   {"Graham"},
 			"last-name":    {"Graham"},
 			"email":        {"Grahams@gmail.com"},
-			"phone-number": {"20229028844"},
+			"phone-number": {"+20229028844"},
 			"room_id":      {"14"},
 		},
 		correctStatusCode: http.StatusSeeOther,
@@ -228,7 +260,7 @@ var PostMakeResv = []struct {
 			"first-name":   {"Graham"},
 			"last-name":    {"Graham"},
 			"email":        {"Grahams@gmail.com"},
-			"phone-number": {"20229028844"},
+			"phone-number": {"+20229028844"},
 			"room_id":      {"11"},
 		},
 		correctStatusCode: http.StatusSeeOther,
@@ -262,6 +294,7 @@ var CheckAvailTest = []struct {
 	testName          string
 	postRqData        url.Values
 	correctStatusCode int
+	correctHTML       string
 }{
 
 	{
@@ -278,7 +311,8 @@ var CheckAvailTest = []struct {
 			"check-in":  {"invalid"},
 			"check-out": {"2022-09-10"},
 		},
-		correctStatusCode: http.StatusSeeOther,
+		correctStatusCode: http.StatusUnprocessableEntity,
+		correctHTML:       "Invalid date, use the format YYYY-MM-DD",
 	},
 	{
 		testName: "valid check-out date",
@@ -294,14 +328,112 @@ var CheckAvailTest = []struct {
 			"check-in":  {"2000-09-09"},
 			"check-out": {"2000-09-10"},
 		},
-		correctStatusCode: http.StatusOK,
+		correctStatusCode: http.StatusUnprocessableEntity,
+		correctHTML:       "be in the past",
+	},
+	{
+		testName: "check-out before check-in",
+		postRqData: url.Values{
+			"check-in":  {"2022-09-10"},
+			"check-out": {"2022-09-09"},
+		},
+		correctStatusCode: http.StatusUnprocessableEntity,
+		correctHTML:       "Check-out date must be after the check-in date",
+	},
+	{
+		testName: "too long stay",
+		postRqData: url.Values{
+			"check-in":  {"2022-09-09"},
+			"check-out": {"2022-12-09"},
+		},
+		correctStatusCode: http.StatusUnprocessableEntity,
+		correctHTML:       "A stay can be at most 30 nights",
 	},
 	{
-		testName: "invalid future reservation",
+		testName: "no rooms for future reservation",
 		postRqData: url.Values{
 			"check-in":  {"2029-09-09"},
 			"check-out": {"2029-09-10"},
 		},
+		correctStatusCode: http.StatusOK,
+		correctHTML:       "2029-09-12 to 2029-09-13",
+	},
+	{
+		testName: "invalid database query",
+		postRqData: url.Values{
+			"check-in":  {"2045-09-09"},
+			"check-out": {"2045-09-10"},
+		},
+		correctStatusCode: http.StatusSeeOther,
+	},
+	{
+		testName: "guests fit the room",
+		postRqData: url.Values{
+			"check-in":  {"2022-09-09"},
+			"check-out": {"2022-09-10"},
+			"adults":    {"2"},
+			"children":  {"1"},
+		},
+		correctStatusCode: http.StatusOK,
+		correctHTML:       "/select-available-room/1",
+	},
+	{
+		//no room holds the guests on its own, they book the first and the second room together
+		testName: "guests need several rooms",
+		postRqData: url.Values{
+			"check-in":  {"2022-09-09"},
+			"check-out": {"2022-09-10"},
+			"adults":    {"3"},
+			"children":  {"1"},
+		},
+		correctStatusCode: http.StatusOK,
+		correctHTML:       "select the rooms to book together",
+	},
+	{
+		testName: "too many guests",
+		postRqData: url.Values{
+			"check-in":  {"2022-09-09"},
+			"check-out": {"2022-09-10"},
+			"adults":    {"5"},
+		},
+		correctStatusCode: http.StatusOK,
+		correctHTML:       "No available rooms for 5 adults and 0 children",
+	},
+	{
+		//the first room needs 3 nights for arrivals in January 2022
+		testName: "minimum stay",
+		postRqData: url.Values{
+			"check-in":  {"2022-01-10"},
+			"check-out": {"2022-01-11"},
+		},
+		correctStatusCode: http.StatusOK,
+		correctHTML:       "stays arriving on 2022-01-10 must be at least 3 nights",
+	},
+	{
+		testName: "closed to arrival",
+		postRqData: url.Values{
+			"check-in":  {"2022-02-01"},
+			"check-out": {"2022-02-05"},
+		},
+		correctStatusCode: http.StatusOK,
+		correctHTML:       "arrivals are closed on 2022-02-01",
+	},
+	{
+		testName: "invalid number of adults",
+		postRqData: url.Values{
+			"check-in":  {"2022-09-09"},
+			"check-out": {"2022-09-10"},
+			"adults":    {"0"},
+		},
+		correctStatusCode: http.StatusSeeOther,
+	},
+	{
+		testName: "invalid number of children",
+		postRqData: url.Values{
+			"check-in":  {"2022-09-09"},
+			"check-out": {"2022-09-10"},
+			"children":  {"slim"},
+		},
 		correctStatusCode: http.StatusSeeOther,
 	},
 }
@@ -319,6 +451,13 @@ func TestRepository_PostCheckAvailabilityPage(t *testing.T) {
 		if responseRecorder.Code != m.correctStatusCode {
 			t.Errorf("Error Testing for %s in Post availability when no rooms available gave wrong status code: got %d, wanted %d", m.testName, responseRecorder.Code, m.correctStatusCode)
 		}
+		if m.correctHTML != "" && !strings.Contains(responseRecorder.Body.String(), m.correctHTML) {
+			t.Errorf("Error Testing for %s in Post availability expected html %v", m.testName, m.correctHTML)
+		}
+		//only the rooms found can be selected afterwards
+		if available, _ := session.Get(ctx, "available_rooms").([]int); m.testName == "valid reservation date" && !reflect.DeepEqual(available, []int{1, 2}) {
+			t.Errorf("Error Testing for %s in Post availability got available rooms %v in session", m.testName, available)
+		}
 	}
 }
 
@@ -348,6 +487,38 @@ var AvailTest = []struct {
 		},
 		correctResult: false,
 	},
+	{
+		//the room is free but too small for the guests
+		testName: "too many guests",
+		message:  "the room holds at most 2 adults and 1 children",
+		postRqData: url.Values{
+			"check-in":  {"2022-09-09"},
+			"check-out": {"2022-09-10"},
+			"room_id":   {"1"},
+			"adults":    {"2"},
+			"children":  {"2"},
+		},
+		correctResult: false,
+	},
+	{
+		testName: "minimum stay",
+		message:  "stays arriving on 2022-01-10 must be at least 3 nights",
+		postRqData: url.Values{
+			"check-in":  {"2022-01-10"},
+			"check-out": {"2022-01-11"},
+			"room_id":   {"1"},
+		},
+		correctResult: false,
+	},
+	{
+		testName: "long enough stay",
+		postRqData: url.Values{
+			"check-in":  {"2022-01-10"},
+			"check-out": {"2022-01-13"},
+			"room_id":   {"1"},
+		},
+		correctResult: true,
+	},
 	{
 		//No posted data
 		testName:      "no data posted",
@@ -402,6 +573,108 @@ func TestRepository_AvailabilityJSON(t *testing.T) {
 	}
 }
 
+func TestRepository_AvailabilityJSON_BookingRules(t *testing.T) {
+	postRqData := url.Values{
+		"check-in":  {"2022-02-01"},
+		"check-out": {"2022-02-03"},
+		"room_id":   {"1"},
+	}
+	rq, _ := http.NewRequest("POST", "/json-availability", strings.NewReader(postRqData.Encode()))
+	ctx := getContext(rq)
+	rq = rq.WithContext(ctx)
+	rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	responseRecorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(Repo.JsonAvailabilityPage)
+	handler.ServeHTTP(responseRecorder, rq)
+
+	var js ResponseJSON
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &js)
+	if err != nil {
+		t.Fatal("failed to parse json!")
+	}
+	if js.Ok || js.Message != "arrivals are closed on 2022-02-01" {
+		t.Errorf("Error Testing the booking rules in the json availability got %v %q", js.Ok, js.Message)
+	}
+}
+
+var AvailDatesTest = []struct {
+	testName   string
+	postRqData url.Values
+	errors     map[string]string
+}{
+	{"missing-dates", url.Values{"room_id": {"1"}},
+		map[string]string{"check-in": "Invalid date, use the format YYYY-MM-DD", "check-out": "Invalid date, use the format YYYY-MM-DD"}},
+	{"past-dates", url.Values{"check-in": {"2021-12-30"}, "check-out": {"2021-12-31"}, "room_id": {"1"}},
+		map[string]string{"check-in": "The check-in date can't be in the past"}},
+	{"reversed-dates", url.Values{"check-in": {"2022-09-10"}, "check-out": {"2022-09-09"}, "room_id": {"1"}},
+		map[string]string{"check-out": "Check-out date must be after the check-in date"}},
+	{"too-long-stay", url.Values{"check-in": {"2022-09-09"}, "check-out": {"2022-10-10"}, "room_id": {"1"}},
+		map[string]string{"check-out": "A stay can be at most 30 nights"}},
+	{"invalid-room", url.Values{"check-in": {"2022-09-09"}, "check-out": {"2022-09-10"}, "room_id": {"deluxe"}},
+		map[string]string{"room_id": "Enter a whole number"}},
+}
+
+func TestRepository_AvailabilityJSON_InvalidDates(t *testing.T) {
+	for _, m := range AvailDatesTest {
+		rq, _ := http.NewRequest("POST", "/json-availability", strings.NewReader(m.postRqData.Encode()))
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.JsonAvailabilityPage)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != http.StatusUnprocessableEntity {
+			t.Errorf("Error Testing %s in the json availability expected %d got %d", m.testName, http.StatusUnprocessableEntity, responseRecorder.Code)
+		}
+		var js ResponseJSON
+		err := json.Unmarshal(responseRecorder.Body.Bytes(), &js)
+		if err != nil {
+			t.Fatalf("Error Testing %s in the json availability, cannot parse the json: %v", m.testName, err)
+		}
+		if js.Ok || !reflect.DeepEqual(js.Errors, m.errors) || js.Message == "" {
+			t.Errorf("Error Testing %s in the json availability got %v %q %v", m.testName, js.Ok, js.Message, js.Errors)
+		}
+	}
+}
+
+func TestRepository_AvailabilityJSON_Body(t *testing.T) {
+	for _, m := range []struct {
+		testName    string
+		body        string
+		correctCode int
+		correctOk   bool
+		errors      map[string]string
+	}{
+		{"available-room", `{"check-in": "2022-09-09", "check-out": "2022-09-10", "room_id": 1, "adults": 2}`, http.StatusOK, true, nil},
+		{"too-many-adults", `{"check-in": "2022-09-09", "check-out": "2022-09-10", "room_id": 1, "adults": 120}`, http.StatusUnprocessableEntity, false,
+			map[string]string{"adults": "Enter a whole number from 1 to 99"}},
+		{"missing-room", `{"check-in": "2022-09-09", "check-out": "2022-09-10"}`, http.StatusUnprocessableEntity, false,
+			map[string]string{"room_id": "This field can't be blank"}},
+		{"not-a-json-object", `["2022-09-09", "2022-09-10"]`, http.StatusOK, false, nil},
+	} {
+		rq, _ := http.NewRequest("POST", "/json-availability", strings.NewReader(m.body))
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+		rq.Header.Set("Content-Type", "application/json")
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.JsonAvailabilityPage)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		var js ResponseJSON
+		err := json.Unmarshal(responseRecorder.Body.Bytes(), &js)
+		if err != nil {
+			t.Fatalf("Error Testing %s in the json availability, cannot parse the json: %v", m.testName, err)
+		}
+		if responseRecorder.Code != m.correctCode || js.Ok != m.correctOk || !reflect.DeepEqual(js.Errors, m.errors) {
+			t.Errorf("Error Testing %s in the json availability got %d %v %v", m.testName, responseRecorder.Code, js.Ok, js.Errors)
+		}
+	}
+}
+
 var ResvSummTest = []struct {
 	testName          string
 	correctStatusCode int
@@ -445,6 +718,257 @@ func TestRepository_ReservationSummary(t *testing.T) {
 
 }
 
+func TestRepository_MakeReservationPage_Charges(t *testing.T) {
+	checkIn := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	rq, _ := http.NewRequest("GET", "/make-reservation", nil)
+	ctx := getContext(rq)
+	rq = rq.WithContext(ctx)
+	session.Put(ctx, "reservation", models.Reservation{RoomID: 1, CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 2)})
+
+	responseRecorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(Repo.MakeReservationPage)
+	handler.ServeHTTP(responseRecorder, rq)
+
+	html := responseRecorder.Body.String()
+	//two nights at 120.00 with the city tax, the VAT and the cleaning fee of the first room
+	for _, correctHTML := range []string{", 2 nights", "240.00 USD", "City tax", "5.00 USD", "VAT", "24.00 USD",
+		"Cleaning", "30.00 USD", "299.00 USD"} {
+		if !strings.Contains(html, correctHTML) {
+			t.Errorf("Error Testing the breakdown of the stay expected html %v", correctHTML)
+		}
+	}
+}
+
+func TestRepository_ReservationSummary_Charges(t *testing.T) {
+	rq, _ := http.NewRequest("GET", "/make-reservation-data", nil)
+	ctx := getContext(rq)
+	rq = rq.WithContext(ctx)
+	session.Put(ctx, "reservation", models.Reservation{RoomID: 1, Charges: []models.ReservationCharge{
+		{Name: "Deluxe suite, 2 nights", Kind: models.ChargeRoom, Quantity: 2, Amount: 24000},
+		{Name: "Cleaning", Kind: models.ChargeFee, Quantity: 1, Amount: 3000},
+	}})
+
+	responseRecorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(Repo.MakeReservationSummary)
+	handler.ServeHTTP(responseRecorder, rq)
+
+	html := responseRecorder.Body.String()
+	for _, correctHTML := range []string{"Deluxe suite, 2 nights", "Cleaning", "30.00 USD", "270.00 USD"} {
+		if !strings.Contains(html, correctHTML) {
+			t.Errorf("Error Testing the breakdown of the summary expected html %v", correctHTML)
+		}
+	}
+}
+
+func TestRepository_ReservationSummary_StayTimes(t *testing.T) {
+	rq, _ := http.NewRequest("GET", "/make-reservation-data", nil)
+	ctx := getContext(rq)
+	rq = rq.WithContext(ctx)
+	session.Put(ctx, "reservation", models.Reservation{RoomID: 1})
+
+	responseRecorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(Repo.MakeReservationSummary)
+	handler.ServeHTTP(responseRecorder, rq)
+
+	if want := "Check-in from 15:00, check-out until 11:00, Europe/Paris time"; !strings.Contains(responseRecorder.Body.String(), want) {
+		t.Errorf("Error Testing the times of the stay, %q missing from the summary", want)
+	}
+}
+
+func TestRepository_ReservationSummary_DisplayCurrency(t *testing.T) {
+	rq, _ := http.NewRequest("GET", "/make-reservation-data", nil)
+	ctx := getContext(rq)
+	rq = rq.WithContext(ctx)
+	session.Put(ctx, "currency", "EUR")
+	session.Put(ctx, "reservation", models.Reservation{RoomID: 1, Charges: []models.ReservationCharge{
+		{Name: "Deluxe suite, 2 nights", Kind: models.ChargeRoom, Quantity: 2, Amount: 24000},
+		{Name: "Cleaning", Kind: models.ChargeFee, Quantity: 1, Amount: 3000},
+	}})
+
+	responseRecorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(Repo.MakeReservationSummary)
+	handler.ServeHTTP(responseRecorder, rq)
+
+	html := responseRecorder.Body.String()
+	for _, correctHTML := range []string{"220.80 EUR", "27.60 EUR", "248.40 EUR", `<option value="EUR" selected>`} {
+		if !strings.Contains(html, correctHTML) {
+			t.Errorf("Error Testing the summary in euros expected html %v", correctHTML)
+		}
+	}
+}
+
+var DisplayCurrencyTest = []struct {
+	testName           string
+	currency           string
+	referer            string
+	correctUrlLocation string
+	sessionCurrency    string
+}{
+	{"euros", "eur", "http://localhost:8080/rooms/deluxe-suite?from=home", "/rooms/deluxe-suite?from=home", "EUR"},
+	{"base-currency", "USD", "http://localhost:8080/rooms", "/rooms", "USD"},
+	{"unknown-currency", "JPY", "http://localhost:8080/rooms", "/rooms", ""},
+	{"other-site", "GBP", "https://example.com/offers", "/offers", "GBP"},
+	{"no-referer", "GBP", "", "/", "GBP"},
+}
+
+func TestRepository_PostDisplayCurrency(t *testing.T) {
+	for _, m := range DisplayCurrencyTest {
+		rq, _ := http.NewRequest("POST", "/display-currency", strings.NewReader(url.Values{"currency": {m.currency}}.Encode()))
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rq.Header.Set("Referer", m.referer)
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostDisplayCurrency)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		urlLocation, _ := responseRecorder.Result().Location()
+		if urlLocation == nil || urlLocation.String() != m.correctUrlLocation {
+			t.Errorf("Error Testing %s for the display currency expected location %v got %v", m.testName, m.correctUrlLocation, urlLocation)
+		}
+		if got := session.GetString(ctx, "currency"); got != m.sessionCurrency {
+			t.Errorf("Error Testing %s for the display currency expected %q in the session got %q", m.testName, m.sessionCurrency, got)
+		}
+		if m.sessionCurrency == "" && session.GetString(ctx, "errors") == "" {
+			t.Errorf("Error Testing %s for the display currency, no errors message in the session", m.testName)
+		}
+	}
+}
+
+var LocaleTest = []struct {
+	testName           string
+	locale             string
+	referer            string
+	correctUrlLocation string
+	sessionLocale      string
+}{
+	{"french", "FR", "http://localhost:8080/rooms/deluxe-suite?from=home", "/rooms/deluxe-suite?from=home", "fr"},
+	{"english", "en", "http://localhost:8080/rooms", "/rooms", "en"},
+	{"unknown-locale", "de", "http://localhost:8080/rooms", "/rooms", ""},
+	{"no-referer", "es", "", "/", "es"},
+}
+
+func TestRepository_PostLocale(t *testing.T) {
+	for _, m := range LocaleTest {
+		rq, _ := http.NewRequest("POST", "/locale", strings.NewReader(url.Values{"locale": {m.locale}}.Encode()))
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rq.Header.Set("Referer", m.referer)
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostLocale)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		urlLocation, _ := responseRecorder.Result().Location()
+		if urlLocation == nil || urlLocation.String() != m.correctUrlLocation {
+			t.Errorf("Error Testing %s for the locale expected location %v got %v", m.testName, m.correctUrlLocation, urlLocation)
+		}
+		if got := session.GetString(ctx, "locale"); got != m.sessionLocale {
+			t.Errorf("Error Testing %s for the locale expected %q in the session got %q", m.testName, m.sessionLocale, got)
+		}
+		if m.sessionLocale == "" && session.GetString(ctx, "errors") == "" {
+			t.Errorf("Error Testing %s for the locale, no errors message in the session", m.testName)
+		}
+	}
+}
+
+func TestRepository_RoomPage_Locale(t *testing.T) {
+	rq, _ := http.NewRequest("GET", "/rooms/deluxe-suite", nil)
+	rq.Header.Set("Accept-Language", "fr-FR, fr;q=0.9, en;q=0.8")
+	ctx := withURLParam(getContext(rq), "slug", "deluxe-suite")
+	rq = rq.WithContext(ctx)
+
+	responseRecorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(Repo.RoomPage)
+	handler.ServeHTTP(responseRecorder, rq)
+
+	html := responseRecorder.Body.String()
+	for _, want := range []string{`<html lang="fr">`, "Pour 2 adultes et 1 enfants", "Accueil", `<option value="fr" selected>`} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Error Testing the french room page, %q missing from the html", want)
+		}
+	}
+}
+
+func TestRepository_PostMakeReservationPage_Locale(t *testing.T) {
+	checkIn := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	postRqData := url.Values{
+		"first-name":   {"Al"},
+		"last-name":    {"Graham"},
+		"email":        {"graham"},
+		"phone-number": {"+20229028844"},
+		"room_id":      {"1"},
+		"promo_code":   {"SUMMER"},
+	}
+	rq, _ := http.NewRequest("POST", "/make-reservation", strings.NewReader(postRqData.Encode()))
+	ctx := getContext(rq)
+	rq = rq.WithContext(ctx)
+	rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	session.Put(ctx, "locale", "fr")
+	session.Put(ctx, "reservation", models.Reservation{RoomID: 1, CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 1)})
+
+	responseRecorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(Repo.PostMakeReservationPage)
+	handler.ServeHTTP(responseRecorder, rq)
+
+	html := responseRecorder.Body.String()
+	for _, want := range []string{"Ce champ doit comporter au moins 3 caractères", "Adresse e-mail invalide", "au moins 2 nuits", "Prénom"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Error Testing the french reservation form, %q missing from the html", want)
+		}
+	}
+}
+
+func TestRepository_PostCheckAvailabilityPage_Locale(t *testing.T) {
+	for _, m := range []struct {
+		testName    string
+		postRqData  url.Values
+		correctHTML []string
+	}{
+		{"waitlist", url.Values{"check-in": {"2022-09-09"}, "check-out": {"2022-09-10"}, "adults": {"5"}},
+			[]string{"Aucune chambre disponible pour 5 adultes et 0 enfants", "Inscrivez-vous sur la liste d&#39;attente", "n&#39;importe quelle chambre"}},
+		{"minimum stay", url.Values{"check-in": {"2022-01-10"}, "check-out": {"2022-01-11"}},
+			[]string{"Chambres disponibles", "les séjours arrivant le 2022-01-10 doivent durer au moins 3 nuits"}},
+	} {
+		rq, _ := http.NewRequest("POST", "/check-availability", strings.NewReader(m.postRqData.Encode()))
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		session.Put(ctx, "locale", "fr")
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostCheckAvailabilityPage)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		html := responseRecorder.Body.String()
+		for _, want := range m.correctHTML {
+			if !strings.Contains(html, want) {
+				t.Errorf("Error Testing %s for the french available rooms, %q missing from the html", m.testName, want)
+			}
+		}
+	}
+}
+
+func TestRepository_PostSelectAvailableRoom_Locale(t *testing.T) {
+	rq, _ := http.NewRequest("POST", "/select-available-room", strings.NewReader(url.Values{"room_id": {"1"}}.Encode()))
+	ctx := getContext(rq)
+	rq = rq.WithContext(ctx)
+	rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	session.Put(ctx, "locale", "fr")
+	session.Put(ctx, "reservation", models.Reservation{Adults: 1})
+
+	responseRecorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(Repo.PostSelectAvailableRoom)
+	handler.ServeHTTP(responseRecorder, rq)
+
+	want := "impossible de choisir les chambres, la chambre 1 n'est pas disponible pour le séjour recherché"
+	if got := session.GetString(ctx, "errors"); got != want {
+		t.Errorf("Error Testing the french error of the room selection got %q wanted %q", got, want)
+	}
+}
+
 var SelectRoomTest = []struct {
 	testName          string
 	resv              models.Reservation
@@ -461,12 +985,12 @@ var SelectRoomTest = []struct {
 			},
 		},
 		id:                "1",
-		correctStatusCode: http.StatusOK,
+		correctStatusCode: http.StatusSeeOther,
 	},
 	{
 		testName:          "no reservation",
 		id:                "5",
-		correctStatusCode: http.StatusOK,
+		correctStatusCode: http.StatusSeeOther,
 	},
 	{
 		testName: "invalid details",
@@ -478,7 +1002,7 @@ var SelectRoomTest = []struct {
 			},
 		},
 		id:                "slim",
-		correctStatusCode: http.StatusOK,
+		correctStatusCode: http.StatusSeeOther,
 	},
 }
 
@@ -504,66 +1028,208 @@ func TestRepository_SelectAvailableRoom(t *testing.T) {
 	}
 }
 
-var BookRoomTest = []struct {
-	testName          string
-	resv              models.Reservation
-	correctStatusCode int
-	query             string
-	postRqData        url.Values
+var HoldRoomTest = []struct {
+	testName           string
+	id                 string
+	correctUrlLocation string
+	held               bool
 }{
-	{
-		testName: "valid bookings",
-		resv: models.Reservation{
-			RoomID: 1,
-			Room: models.Room{
-				ID:       1,
-				RoomName: "Deluxe suite",
-			},
-		},
-		correctStatusCode: http.StatusOK,
-		query:             "?s=2022-09-09&e=2022-09-10&id=1",
-		postRqData: url.Values{
-			"check_in_date":  {"2022-09-09"},
-			"check_out_date": {"2022-09-10"},
-			"room_id":        {"1"},
-		},
-	},
-	{
-		testName: "invalid bookings",
-		resv: models.Reservation{
-			RoomID: 1,
-			Room: models.Room{
-				ID:       1,
-				RoomName: "Deluxe suite",
-			},
-		},
-		correctStatusCode: http.StatusOK,
-		query:             "?s=2044-09-09&e=2044-09-10&id=4",
-		postRqData:        nil,
-	},
+	{"free-room", "1", "/make-reservation", true},
+	{"taken-room", "5", "/check-availability", false},
+	{"room-not-searched", "3", "/check-availability", false},
 }
 
-func TestRepository_BookRoom(t *testing.T) {
-	var rq *http.Request
-	for _, m := range BookRoomTest {
-		if m.postRqData != nil {
-			rq, _ = http.NewRequest("GET", fmt.Sprintf("/book-room-now%s", m.query), strings.NewReader(m.postRqData.Encode()))
-		} else {
-			rq, _ = http.NewRequest("GET", fmt.Sprintf("/book-room-now%s", m.query), nil)
-		}
-		ctx := getContext(rq)
+func TestRepository_SelectAvailableRoom_Hold(t *testing.T) {
+	for _, m := range HoldRoomTest {
+		rq, _ := http.NewRequest("GET", "/select-available-room/"+m.id, nil)
+		ctx := withURLParam(getContext(rq), "id", m.id)
 		rq = rq.WithContext(ctx)
+		session.Put(ctx, "reservation", models.Reservation{Adults: 1})
+		session.Put(ctx, "available_rooms", []int{1, 2, 5})
+		//the holds of the previous selection are released
+		session.Put(ctx, "holds", []int{2})
 
 		responseRecorder := httptest.NewRecorder()
-		session.Put(ctx, "reservation", m.resv)
+		handler := http.HandlerFunc(Repo.SelectAvailableRoom)
+		handler.ServeHTTP(responseRecorder, rq)
 
-		handler := http.HandlerFunc(Repo.BookRoomNow)
+		if responseRecorder.Header().Get("Location") != m.correctUrlLocation {
+			t.Errorf("Error Testing %s for holding the room expected %s got %s", m.testName, m.correctUrlLocation, responseRecorder.Header().Get("Location"))
+		}
+		holdIDs, _ := session.Get(ctx, "holds").([]int)
+		_, expires := session.Get(ctx, "hold_expires_at").(int64)
+		if m.held && (!reflect.DeepEqual(holdIDs, []int{1}) || !expires) {
+			t.Errorf("Error Testing %s for holding the room got holds %v in session", m.testName, holdIDs)
+		}
+		if !m.held && (holdIDs != nil || expires || session.GetString(ctx, "errors") == "") {
+			t.Errorf("Error Testing %s for holding the room, the room is held or no error shown", m.testName)
+		}
+	}
+}
+
+func TestRepository_PostMakeReservationPage_Hold(t *testing.T) {
+	postRqData := url.Values{
+		"first-name":   {"Graham"},
+		"last-name":    {"Graham"},
+		"email":        {"Grahams@gmail.com"},
+		"phone-number": {"+20229028844"},
+		"room_id":      {"1"},
+		"card_token":   {"tok_visa"},
+	}
+	rq, _ := http.NewRequest("POST", "/make-reservation", strings.NewReader(postRqData.Encode()))
+	ctx := getContext(rq)
+	rq = rq.WithContext(ctx)
+	rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	checkIn := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	session.Put(ctx, "reservation", models.Reservation{RoomID: 1, CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 2)})
+	session.Put(ctx, "holds", []int{1})
+	session.Put(ctx, "hold_expires_at", time.Now().Add(time.Minute).Unix())
+
+	responseRecorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(Repo.PostMakeReservationPage)
+	handler.ServeHTTP(responseRecorder, rq)
+
+	if responseRecorder.Header().Get("Location") != "/make-reservation-data" {
+		t.Errorf("Error Testing the held room booking expected /make-reservation-data got %s", responseRecorder.Header().Get("Location"))
+	}
+	if session.Get(ctx, "holds") != nil || session.Get(ctx, "hold_expires_at") != nil {
+		t.Errorf("Error Testing the held room booking, the hold is still in the session")
+	}
+}
+
+var ResvPhoneTest = []struct {
+	testName    string
+	phone       string
+	correctCode int
+	correctHTML string
+	correctResv string
+}{
+	{"spaced-number", "(+20) 229-028 844", http.StatusSeeOther, "", "+20229028844"},
+	{"double-zero-prefix", "0020229028844", http.StatusSeeOther, "", "+20229028844"},
+	{"national-number", "0229028844", http.StatusSeeOther, "Invalid phone number, use the international format", ""},
+	{"letters", "+20 CALL ME", http.StatusSeeOther, "Invalid phone number, use the international format", ""},
+}
+
+func TestRepository_PostMakeReservationPage_Phone(t *testing.T) {
+	for _, m := range ResvPhoneTest {
+		postRqData := url.Values{
+			"first-name":   {"Graham"},
+			"last-name":    {"Graham"},
+			"email":        {"Grahams@gmail.com"},
+			"phone-number": {m.phone},
+			"room_id":      {"1"},
+			"card_token":   {"tok_visa"},
+		}
+		rq, _ := http.NewRequest("POST", "/make-reservation", strings.NewReader(postRqData.Encode()))
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		checkIn := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+		session.Put(ctx, "reservation", models.Reservation{RoomID: 1, CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 2)})
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostMakeReservationPage)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.correctCode || !strings.Contains(responseRecorder.Body.String(), m.correctHTML) {
+			t.Errorf("Error Testing %s for the phone number got %d %q", m.testName, responseRecorder.Code, responseRecorder.Body.String())
+		}
+		resv, _ := session.Get(ctx, "reservation").(models.Reservation)
+		if m.correctResv != "" && resv.PhoneNumber != m.correctResv {
+			t.Errorf("Error Testing %s for the phone number got %q in the reservation wanted %q", m.testName, resv.PhoneNumber, m.correctResv)
+		}
+	}
+}
+
+var BookRoomTest = []struct {
+	testName           string
+	resv               models.Reservation
+	correctStatusCode  int
+	correctUrlLocation string
+	query              string
+	postRqData         url.Values
+}{
+	{
+		testName: "valid bookings",
+		resv: models.Reservation{
+			RoomID: 1,
+			Room: models.Room{
+				ID:       1,
+				RoomName: "Deluxe suite",
+			},
+		},
+		correctStatusCode:  http.StatusSeeOther,
+		correctUrlLocation: "/make-reservation",
+		query:              "?s=2022-09-09&e=2022-09-10&id=1",
+		postRqData: url.Values{
+			"check_in_date":  {"2022-09-09"},
+			"check_out_date": {"2022-09-10"},
+			"room_id":        {"1"},
+		},
+	},
+	{
+		testName: "far ahead bookings",
+		resv: models.Reservation{
+			RoomID: 1,
+			Room: models.Room{
+				ID:       1,
+				RoomName: "Deluxe suite",
+			},
+		},
+		correctStatusCode:  http.StatusSeeOther,
+		correctUrlLocation: "/make-reservation",
+		query:              "?s=2044-09-09&e=2044-09-10&id=4",
+		postRqData:         nil,
+	},
+	{
+		testName:           "past bookings",
+		correctStatusCode:  http.StatusSeeOther,
+		correctUrlLocation: "/check-availability",
+		query:              "?s=2021-09-09&e=2021-09-10&id=1",
+	},
+	{
+		testName:           "reversed dates",
+		correctStatusCode:  http.StatusSeeOther,
+		correctUrlLocation: "/check-availability",
+		query:              "?s=2022-09-10&e=2022-09-09&id=1",
+	},
+	{
+		testName:           "invalid room id",
+		correctStatusCode:  http.StatusSeeOther,
+		correctUrlLocation: "/check-availability",
+		query:              "?s=2022-09-09&e=2022-09-10&id=slim",
+	},
+	{
+		testName:          "unknown room",
+		correctStatusCode: http.StatusInternalServerError,
+		query:             "?s=2022-09-09&e=2022-09-10&id=5",
+	},
+}
+
+func TestRepository_BookRoom(t *testing.T) {
+	var rq *http.Request
+	for _, m := range BookRoomTest {
+		if m.postRqData != nil {
+			rq, _ = http.NewRequest("GET", fmt.Sprintf("/book-room-now%s", m.query), strings.NewReader(m.postRqData.Encode()))
+		} else {
+			rq, _ = http.NewRequest("GET", fmt.Sprintf("/book-room-now%s", m.query), nil)
+		}
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		session.Put(ctx, "reservation", m.resv)
+
+		handler := http.HandlerFunc(Repo.BookRoomNow)
 
 		handler.ServeHTTP(responseRecorder, rq)
 
 		if responseRecorder.Code != m.correctStatusCode {
 			t.Errorf("BookRoom handler : %s returned wrong response code: got %d, wanted %d", m.testName, responseRecorder.Code, m.correctStatusCode)
 		}
+		if m.correctUrlLocation != "" && responseRecorder.Header().Get("Location") != m.correctUrlLocation {
+			t.Errorf("BookRoom handler : %s redirected to %s, wanted %s", m.testName, responseRecorder.Header().Get("Location"), m.correctUrlLocation)
+		}
 	}
 }
 
@@ -651,9 +1317,9 @@ var ShowResvTest = []struct {
 		http.StatusSeeOther,
 		"",
 		url.Values{
-			"first_name":   {"Yusuf"},
-			"last_name":    {"Akinleye"},
-			"phone_number": {"09088765312"},
+			"first-name":   {"Yusuf"},
+			"last-name":    {"Akinleye"},
+			"phone-number": {"+2349088765312"},
 			"email":        {"dev-ayaa007@admin.com"},
 		},
 	},
@@ -664,9 +1330,9 @@ var ShowResvTest = []struct {
 	//	http.StatusSeeOther,
 	//	"",
 	//	url.Values{
-	//		"first_name":   {"Yusuf"},
-	//		"last_name":    {"Akinleye"},
-	//		"phone_number": {"09088765312"},
+	//		"first-name":   {"Yusuf"},
+	//		"last-name":    {"Akinleye"},
+	//		"phone-number": {"+2349088765312"},
 	//		"email":        {"dev-ayaa007@admin.com"},
 	//		"month":        {"05"},
 	//		"year":         {"2022"},
@@ -679,9 +1345,48 @@ var ShowResvTest = []struct {
 		http.StatusSeeOther,
 		"",
 		url.Values{
-			"first_name":   {"Yusuf"},
-			"last_name":    {"Akinleye"},
-			"phone_number": {"09088765312"},
+			"first-name":   {"Yusuf"},
+			"last-name":    {"Akinleye"},
+			"phone-number": {"+2349088765312"},
+			"email":        {"dev-ayaa007@admin.com"},
+		},
+	},
+	{
+		"national-phone-number",
+		"/admin/admin-new-reservation/new/1/show",
+		"/admin/admin-new-reservation",
+		http.StatusSeeOther,
+		"",
+		url.Values{
+			"first-name":   {"Yusuf"},
+			"last-name":    {"Akinleye"},
+			"phone-number": {"09088765312"},
+			"email":        {"dev-ayaa007@admin.com"},
+		},
+	},
+	{
+		"invalid-phone-number",
+		"/admin/admin-new-reservation/new/1/show",
+		"",
+		http.StatusUnprocessableEntity,
+		"Invalid phone number, use the digits of the number or the international format",
+		url.Values{
+			"first-name":   {"Yusuf"},
+			"last-name":    {"Akinleye"},
+			"phone-number": {"090 CALL ME"},
+			"email":        {"dev-ayaa007@admin.com"},
+		},
+	},
+	{
+		"blank-name",
+		"/admin/admin-new-reservation/new/1/show",
+		"",
+		http.StatusUnprocessableEntity,
+		"This field can&#39;t be blank",
+		url.Values{
+			"first-name":   {""},
+			"last-name":    {"Akinleye"},
+			"phone-number": {"+2349088765312"},
 			"email":        {"dev-ayaa007@admin.com"},
 		},
 	},
@@ -729,6 +1434,7 @@ func TestRepository_PostAdminShowReservation(t *testing.T) {
 
 var ProcessResv = []struct {
 	testName           string
+	id                 string
 	query              string
 	correctHTML        string
 	correctUrlLocation string
@@ -736,6 +1442,7 @@ var ProcessResv = []struct {
 }{
 	{
 		testName:           "valid-testing",
+		id:                 "1",
 		query:              "?y=2022&m=05",
 		correctHTML:        "",
 		correctUrlLocation: "",
@@ -743,18 +1450,25 @@ var ProcessResv = []struct {
 	},
 	{
 		testName:           "invalid-testing",
+		id:                 "1",
 		query:              "",
 		correctHTML:        "",
 		correctUrlLocation: "",
 		correctStatusCode:  http.StatusSeeOther,
 	},
+	{
+		testName:          "invalid-id",
+		id:                "slim",
+		query:             "",
+		correctStatusCode: http.StatusBadRequest,
+	},
 }
 
 func TestRepository_AdminProcessReservation(t *testing.T) {
 	for _, s := range ProcessResv {
-		rq, _ := http.NewRequest("GET", fmt.Sprintf("/admin/admin-process-reservation/calendar/1/done/%s", s.query), nil)
+		rq, _ := http.NewRequest("GET", fmt.Sprintf("/admin/admin-process-reservation/calendar/%s/done/%s", s.id, s.query), nil)
 
-		ctx := getContext(rq)
+		ctx := withURLParam(getContext(rq), "id", s.id)
 		rq = rq.WithContext(ctx)
 		// rq.RequestURI = s.correctURL
 		responseRecorder := httptest.NewRecorder()
@@ -783,10 +1497,1722 @@ func TestRepository_AdminProcessReservation(t *testing.T) {
 
 }
 
-func getContext(rq *http.Request) context.Context {
-	ctx, err := session.Load(rq.Context(), rq.Header.Get("X-Session"))
-	if err != nil {
-		log.Println(err)
+var ImportResvTest = []struct {
+	testName           string
+	content            string
+	correctStatusCode  int
+	correctUrlLocation string
+	correctHTML        string
+}{
+	{
+		testName: "valid-file",
+		content: "first-name,last-name,email,phone-number,check-in,check-out,room_id\n" +
+			"Graham,Graham,Grahams@gmail.com,20229028844,2022-09-09,2022-09-10,1\n" +
+			"Graham,Graham,Grahams@gmail.com,20229028844,2022-09-09,2022-09-10,9\n" +
+			"Graham,Graham,Grahams@gmail.com,20229028844,2030-09-09,2030-09-10,1\n" +
+			"Graham,Graham,Grahams@gmail.com,20229028844,2022-09-09,2022-09-11,1\n",
+		correctStatusCode: http.StatusOK,
+		correctHTML:       "Preview: 1 valid, 3 with errors",
+	},
+	{
+		testName:           "missing-column",
+		content:            "first-name,last-name,email\nGraham,Graham,Grahams@gmail.com\n",
+		correctStatusCode:  http.StatusSeeOther,
+		correctUrlLocation: "/admin/admin-import-reservation",
+	},
+	{
+		testName:           "no-file",
+		correctStatusCode:  http.StatusSeeOther,
+		correctUrlLocation: "/admin/admin-import-reservation",
+	},
+}
+
+func TestRepository_PostAdminImportReservation(t *testing.T) {
+	for _, m := range ImportResvTest {
+		body := &strings.Builder{}
+		writer := multipart.NewWriter(body)
+		if m.content != "" {
+			part, _ := writer.CreateFormFile("reservation-file", "reservations.csv")
+			part.Write([]byte(m.content))
+		}
+		writer.Close()
+
+		rq, _ := http.NewRequest("POST", "/admin/admin-import-reservation", strings.NewReader(body.String()))
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+		rq.Header.Set("Content-Type", writer.FormDataContentType())
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminImportReservation)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.correctStatusCode {
+			t.Errorf("Error Testing %s for import reservation expected %v but get %v", m.testName, m.correctStatusCode, responseRecorder.Code)
+		}
+		if m.correctUrlLocation != "" {
+			urlLocation, _ := responseRecorder.Result().Location()
+			if urlLocation.String() != m.correctUrlLocation {
+				t.Errorf("Error Testing %s for import reservation expected location %v", m.testName, m.correctUrlLocation)
+			}
+		}
+		if m.correctHTML != "" && !strings.Contains(responseRecorder.Body.String(), m.correctHTML) {
+			t.Errorf("Error Testing %s for import reservation expected html %v", m.testName, m.correctHTML)
+		}
+	}
+}
+
+var ConfirmImportTest = []struct {
+	testName           string
+	resvs              []models.Reservation
+	correctUrlLocation string
+}{
+	{"valid-import", []models.Reservation{{RoomID: 1}, {RoomID: 2}}, "/admin/admin-all-reservation"},
+	{"failed-import", []models.Reservation{{RoomID: 1}, {RoomID: 14}}, "/admin/admin-import-reservation"},
+	{"nothing-to-import", nil, "/admin/admin-import-reservation"},
+}
+
+func TestRepository_PostAdminConfirmImportReservation(t *testing.T) {
+	for _, m := range ConfirmImportTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-import-reservation/confirm", nil)
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+		if m.resvs != nil {
+			session.Put(ctx, "import_reservation", m.resvs)
+		}
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminConfirmImportReservation)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != http.StatusSeeOther {
+			t.Errorf("Error Testing %s for confirm import expected %v but get %v", m.testName, http.StatusSeeOther, responseRecorder.Code)
+		}
+		urlLocation, _ := responseRecorder.Result().Location()
+		if urlLocation.String() != m.correctUrlLocation {
+			t.Errorf("Error Testing %s for confirm import expected location %v got %v", m.testName, m.correctUrlLocation, urlLocation)
+		}
+	}
+}
+
+func TestRepository_AdminPage(t *testing.T) {
+	rq, _ := http.NewRequest("GET", "/admin/dashboard", nil)
+	ctx := getContext(rq)
+	rq = rq.WithContext(ctx)
+	responseRecorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(Repo.AdminPage)
+	handler.ServeHTTP(responseRecorder, rq)
+
+	if responseRecorder.Code != http.StatusOK {
+		t.Errorf("Error Testing the admin dashboard expected %v but get %v", http.StatusOK, responseRecorder.Code)
+	}
+	html := responseRecorder.Body.String()
+	for _, correctHTML := range []string{"1 / 2", "last 7 days", "last 90 days", "50.0%", "2.5 nights", "No departures today"} {
+		if !strings.Contains(html, correctHTML) {
+			t.Errorf("Error Testing the admin dashboard expected html %v", correctHTML)
+		}
+	}
+	//the cancelled reservation checking in today isn't an arrival
+	if strings.Contains(html, "Cancelled") {
+		t.Error("Error Testing the admin dashboard, the cancelled reservation is among the arrivals")
+	}
+}
+
+var SelectRoomsTest = []struct {
+	testName           string
+	resv               *models.Reservation
+	postRqData         url.Values
+	correctUrlLocation string
+}{
+	{"several-rooms", &models.Reservation{Adults: 3, Children: 1}, url.Values{"room_id": {"1", "2", "2"}}, "/make-reservation"},
+	{"no-room-selected", &models.Reservation{Adults: 2}, url.Values{}, "/check-availability"},
+	{"invalid-room", &models.Reservation{Adults: 2}, url.Values{"room_id": {"slim"}}, "/check-availability"},
+	{"no-reservation", nil, url.Values{"room_id": {"1", "2"}}, "/check-availability"},
+	{"taken-room", &models.Reservation{Adults: 2}, url.Values{"room_id": {"1", "5"}}, "/check-availability"},
+	{"too-small-rooms", &models.Reservation{Adults: 5}, url.Values{"room_id": {"1", "2"}}, "/check-availability"},
+	{"more-rooms-than-adults", &models.Reservation{Adults: 1}, url.Values{"room_id": {"1", "2"}}, "/check-availability"},
+	{"room-not-searched", &models.Reservation{Adults: 3, Children: 1}, url.Values{"room_id": {"1", "3"}}, "/check-availability"},
+}
+
+func TestRepository_PostSelectAvailableRoom(t *testing.T) {
+	for _, m := range SelectRoomsTest {
+		rq, _ := http.NewRequest("POST", "/select-available-room", strings.NewReader(m.postRqData.Encode()))
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		if m.resv != nil {
+			session.Put(ctx, "reservation", *m.resv)
+		}
+		session.Put(ctx, "available_rooms", []int{1, 2, 5})
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostSelectAvailableRoom)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		urlLocation, _ := responseRecorder.Result().Location()
+		if urlLocation == nil || urlLocation.String() != m.correctUrlLocation {
+			t.Errorf("Error Testing %s for select rooms expected location %v got %v", m.testName, m.correctUrlLocation, urlLocation)
+		}
+		if m.testName == "several-rooms" {
+			roomIDs, _ := session.Get(ctx, "reservation_rooms").([]int)
+			if !reflect.DeepEqual(roomIDs, []int{1, 2}) {
+				t.Errorf("Error Testing %s for select rooms got rooms %v in session", m.testName, roomIDs)
+			}
+			holdIDs, _ := session.Get(ctx, "holds").([]int)
+			if !reflect.DeepEqual(holdIDs, []int{1, 2}) {
+				t.Errorf("Error Testing %s for select rooms got holds %v in session", m.testName, holdIDs)
+			}
+		}
+		if (m.testName == "taken-room" || m.testName == "room-not-searched") && session.Get(ctx, "holds") != nil {
+			t.Errorf("Error Testing %s for select rooms, the rooms are held", m.testName)
+		}
+	}
+}
+
+var GroupResvTest = []struct {
+	testName           string
+	roomIDs            []int
+	correctUrlLocation string
+}{
+	{"valid-group", []int{1, 2}, "/make-reservation-data"},
+	{"failed-group", []int{1, 14}, "/check-availability"},
+}
+
+func TestRepository_PostMakeReservationPage_Group(t *testing.T) {
+	postRqData := url.Values{
+		"first-name":   {"Graham"},
+		"last-name":    {"Graham"},
+		"email":        {"Grahams@gmail.com"},
+		"phone-number": {"+20229028844"},
+		"room_id":      {"1"},
+		"card_token":   {"tok_visa"},
+	}
+	checkIn := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	for _, m := range GroupResvTest {
+		rq, _ := http.NewRequest("POST", "/make-reservation", strings.NewReader(postRqData.Encode()))
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		session.Put(ctx, "reservation", models.Reservation{RoomID: m.roomIDs[0], CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 2), Adults: 2})
+		session.Put(ctx, "reservation_rooms", m.roomIDs)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostMakeReservationPage)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		urlLocation, _ := responseRecorder.Result().Location()
+		if urlLocation == nil || urlLocation.String() != m.correctUrlLocation {
+			t.Errorf("Error Testing %s for group reservation expected location %v got %v", m.testName, m.correctUrlLocation, urlLocation)
+		}
+		if m.testName == "valid-group" {
+			group, ok := session.Get(ctx, "booking_group").(models.BookingGroup)
+			if !ok || len(group.Reservations) != len(m.roomIDs) {
+				t.Errorf("Error Testing %s for group reservation got booking group %+v in session", m.testName, group)
+			}
+			//the two adults are shared between the rooms instead of being counted in each of them
+			for _, r := range group.Reservations {
+				if r.Adults != 1 || r.Children != 0 {
+					t.Errorf("Error Testing %s for group reservation, room %d holds %d adults and %d children", m.testName, r.RoomID, r.Adults, r.Children)
+				}
+			}
+		}
+	}
+}
+
+var DepositTest = []struct {
+	testName           string
+	roomIDs            []int
+	cardToken          string
+	promoCode          string
+	correctUrlLocation string
+	flash              bool
+}{
+	{"paid-deposit", []int{1}, "tok_visa", "", "/make-reservation-data", false},
+	{"pending-deposit", []int{1}, "tok_pending", "", "/make-reservation-data", true},
+	{"declined-card", []int{1}, "tok_declined", "", "/make-reservation", false},
+	{"declined-card-with-code", []int{1}, "tok_declined", "WELCOME", "/make-reservation", false},
+	{"missing-card", []int{1}, "", "", "/make-reservation", false},
+	{"free-room", []int{4}, "", "", "/make-reservation-data", false},
+	{"declined-group", []int{1, 2}, "tok_declined", "", "/make-reservation", false},
+	{"declined-group-with-code", []int{1, 2}, "tok_declined", "WELCOME", "/make-reservation", false},
+	{"pending-group", []int{1, 2}, "tok_pending", "", "/make-reservation-data", true},
+}
+
+func TestRepository_PostMakeReservationPage_Deposit(t *testing.T) {
+	checkIn := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	for _, m := range DepositTest {
+		postRqData := url.Values{
+			"first-name":   {"Graham"},
+			"last-name":    {"Graham"},
+			"email":        {"Grahams@gmail.com"},
+			"phone-number": {"+20229028844"},
+			"room_id":      {strconv.Itoa(m.roomIDs[0])},
+			"card_token":   {m.cardToken},
+			"promo_code":   {m.promoCode},
+		}
+		rq, _ := http.NewRequest("POST", "/make-reservation", strings.NewReader(postRqData.Encode()))
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		session.Put(ctx, "reservation", models.Reservation{RoomID: m.roomIDs[0], CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 2), Adults: 2})
+		session.Put(ctx, "reservation_rooms", m.roomIDs)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostMakeReservationPage)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		urlLocation, _ := responseRecorder.Result().Location()
+		if urlLocation == nil || urlLocation.String() != m.correctUrlLocation {
+			t.Errorf("Error Testing %s for the deposit expected location %v got %v", m.testName, m.correctUrlLocation, urlLocation)
+		}
+		if (session.GetString(ctx, "flash") != "") != m.flash {
+			t.Errorf("Error Testing %s for the deposit got flash %q", m.testName, session.GetString(ctx, "flash"))
+		}
+		if m.correctUrlLocation == "/make-reservation" {
+			if !strings.Contains(session.GetString(ctx, "errors"), "deposit") {
+				t.Errorf("Error Testing %s for the deposit got error %q", m.testName, session.GetString(ctx, "errors"))
+			}
+			if holdIDs, _ := session.Get(ctx, "holds").([]int); len(holdIDs) != len(m.roomIDs) {
+				t.Errorf("Error Testing %s for the deposit, the rooms are not held again %v", m.testName, holdIDs)
+			}
+		}
+	}
+}
+
+var PromoBookingTest = []struct {
+	testName    string
+	promoCode   string
+	nights      int
+	discount    int
+	correctHTML string
+}{
+	{"percent-code", "summer", 2, -4485, ""},
+	{"fixed-code", "WELCOME", 2, -2000, ""},
+	{"no-code", "", 2, 0, ""},
+	{"too-short-stay", "SUMMER", 1, 0, "at least 2 nights"},
+	{"unknown-code", "WINTER", 2, 0, "Unknown promo code"},
+	{"invalid-code", "<b>", 2, 0, "Invalid promo code"},
+	{"expired-code", "EXPIRED", 2, 0, "has expired"},
+	{"used-up-code", "USEDUP", 2, 0, "was used up"},
+}
+
+func TestRepository_PostMakeReservationPage_PromoCode(t *testing.T) {
+	checkIn := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	for _, m := range PromoBookingTest {
+		postRqData := url.Values{
+			"first-name":   {"Graham"},
+			"last-name":    {"Graham"},
+			"email":        {"Grahams@gmail.com"},
+			"phone-number": {"+20229028844"},
+			"room_id":      {"1"},
+			"card_token":   {"tok_visa"},
+			"promo_code":   {m.promoCode},
+		}
+		rq, _ := http.NewRequest("POST", "/make-reservation", strings.NewReader(postRqData.Encode()))
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		session.Put(ctx, "reservation", models.Reservation{RoomID: 1, CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, m.nights)})
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostMakeReservationPage)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if m.correctHTML != "" {
+			if !strings.Contains(responseRecorder.Body.String(), m.correctHTML) {
+				t.Errorf("Error Testing %s for the promo code expected html %v", m.testName, m.correctHTML)
+			}
+			continue
+		}
+		resv, _ := session.Get(ctx, "reservation").(models.Reservation)
+		discount := 0
+		for _, charge := range resv.Charges {
+			if charge.Kind == models.ChargeDiscount {
+				discount += charge.Amount
+			}
+		}
+		if discount != m.discount {
+			t.Errorf("Error Testing %s for the promo code expected a discount of %d got %d", m.testName, m.discount, discount)
+		}
+	}
+}
+
+var WebhookTest = []struct {
+	testName          string
+	body              string
+	secret            string
+	correctStatusCode int
+}{
+	{"paid-deposit", `{"reference":"fake_pending","state":"paid"}`, "test-secret", http.StatusOK},
+	{"declined-deposit", `{"reference":"fake_pending","state":"declined"}`, "test-secret", http.StatusOK},
+	{"paid-group-deposit", `{"reference":"fake_pending_group","state":"paid"}`, "test-secret", http.StatusOK},
+	{"still-pending", `{"reference":"fake_pending","state":"pending"}`, "test-secret", http.StatusOK},
+	{"unknown-payment", `{"reference":"fake_unknown","state":"paid"}`, "test-secret", http.StatusOK},
+	{"bad-signature", `{"reference":"fake_pending","state":"paid"}`, "another-secret", http.StatusBadRequest},
+	{"bad-body", `not json`, "test-secret", http.StatusBadRequest},
+}
+
+func TestRepository_PaymentWebhook(t *testing.T) {
+	for _, m := range WebhookTest {
+		rq, _ := http.NewRequest("POST", "/payments/webhook", strings.NewReader(m.body))
+		rq.Header.Set(payments.SignatureHeader, payments.Sign(m.secret, []byte(m.body)))
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PaymentWebhook)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.correctStatusCode {
+			t.Errorf("Error Testing %s for the payment webhook expected %v got %v", m.testName, m.correctStatusCode, responseRecorder.Code)
+		}
+	}
+}
+
+func TestRepository_RoomPage(t *testing.T) {
+	rq, _ := http.NewRequest("GET", "/rooms/deluxe-suite", nil)
+	ctx := withURLParam(getContext(rq), "slug", "deluxe-suite")
+	rq = rq.WithContext(ctx)
+
+	responseRecorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(Repo.RoomPage)
+	handler.ServeHTTP(responseRecorder, rq)
+
+	if responseRecorder.Code != http.StatusOK {
+		t.Errorf("Error Testing the room page expected %v got %v", http.StatusOK, responseRecorder.Code)
+	}
+	html := responseRecorder.Body.String()
+	for _, want := range []string{"Deluxe suite", "Private balcony", "Mini bar", "pexels-max-vakhtbovych-6970069.jpg", "Sleeps 2 adults and 1 children",
+		"/uploads/room-1-thumb.jpg", "Balcony at sunset"} {
+		if !strings.Contains(html, want) {
+			t.Errorf("Error Testing the room page, %q missing from the html", want)
+		}
+	}
+}
+
+var PostRoomTest = []struct {
+	testName           string
+	id                 string
+	postRqData         url.Values
+	correctStatusCode  int
+	correctUrlLocation string
+	correctHTML        string
+}{
+	{
+		testName:           "new-room",
+		id:                 "new",
+		postRqData:         url.Values{"room_name": {"Garden Suite"}, "slug": {"garden-suite"}, "max_adults": {"2"}, "max_children": {"2"}},
+		correctStatusCode:  http.StatusSeeOther,
+		correctUrlLocation: "/admin/admin-rooms",
+	},
+	{
+		testName:           "same-slug-same-room",
+		id:                 "1",
+		postRqData:         url.Values{"room_name": {"Deluxe suite"}, "slug": {"deluxe-suite"}, "max_adults": {"2"}, "max_children": {"1"}},
+		correctStatusCode:  http.StatusSeeOther,
+		correctUrlLocation: "/admin/admin-rooms",
+	},
+	{
+		testName:          "slug-used",
+		id:                "new",
+		postRqData:        url.Values{"room_name": {"Deluxe suite"}, "slug": {"deluxe-suite"}, "max_adults": {"2"}, "max_children": {"1"}},
+		correctStatusCode: http.StatusUnprocessableEntity,
+		correctHTML:       "The slug is already used by Deluxe suite",
+	},
+	{
+		testName:          "invalid-slug",
+		id:                "new",
+		postRqData:        url.Values{"room_name": {"Garden Suite"}, "slug": {"Garden Suite"}, "max_adults": {"2"}, "max_children": {"0"}},
+		correctStatusCode: http.StatusUnprocessableEntity,
+		correctHTML:       "Use lower case letters, digits and dashes only",
+	},
+	{
+		testName:          "no-adults",
+		id:                "new",
+		postRqData:        url.Values{"room_name": {"Garden Suite"}, "slug": {"garden-suite"}, "max_adults": {"0"}, "max_children": {"0"}},
+		correctStatusCode: http.StatusUnprocessableEntity,
+		correctHTML:       "Enter a whole number from 1 to 99",
+	},
+	{
+		testName:           "room-rate",
+		id:                 "1",
+		postRqData:         url.Values{"room_name": {"Deluxe suite"}, "slug": {"deluxe-suite"}, "max_adults": {"2"}, "max_children": {"1"}, "nightly_rate": {"185.50"}},
+		correctStatusCode:  http.StatusSeeOther,
+		correctUrlLocation: "/admin/admin-rooms",
+	},
+	{
+		testName:          "invalid-rate",
+		id:                "new",
+		postRqData:        url.Values{"room_name": {"Garden Suite"}, "slug": {"garden-suite"}, "max_adults": {"2"}, "max_children": {"0"}, "nightly_rate": {"12.345"}},
+		correctStatusCode: http.StatusUnprocessableEntity,
+		correctHTML:       "Invalid nightly rate",
+	},
+	{
+		testName:          "invalid-penalty",
+		id:                "new",
+		postRqData:        url.Values{"room_name": {"Garden Suite"}, "slug": {"garden-suite"}, "max_adults": {"2"}, "max_children": {"0"}, "cancel_penalty": {"percent"}, "cancel_free_days": {"7"}, "cancel_penalty_percent": {"150"}},
+		correctStatusCode: http.StatusUnprocessableEntity,
+		correctHTML:       "Enter a whole number from 1 to 100",
+	},
+	{
+		testName:          "unknown-penalty",
+		id:                "new",
+		postRqData:        url.Values{"room_name": {"Garden Suite"}, "slug": {"garden-suite"}, "max_adults": {"2"}, "max_children": {"0"}, "cancel_penalty": {"everything"}},
+		correctStatusCode: http.StatusUnprocessableEntity,
+		correctHTML:       "Choose one of none, percent, first_night",
+	},
+	{
+		testName:          "invalid-free-days",
+		id:                "new",
+		postRqData:        url.Values{"room_name": {"Garden Suite"}, "slug": {"garden-suite"}, "max_adults": {"2"}, "max_children": {"0"}, "cancel_penalty": {"first_night"}, "cancel_free_days": {"a week"}},
+		correctStatusCode: http.StatusUnprocessableEntity,
+		correctHTML:       "Enter a whole number from 0 to 365",
+	},
+	{
+		testName:           "cancellation-policy",
+		id:                 "1",
+		postRqData:         url.Values{"room_name": {"Deluxe suite"}, "slug": {"deluxe-suite"}, "max_adults": {"2"}, "max_children": {"1"}, "cancel_penalty": {"first_night"}, "cancel_free_days": {"2"}},
+		correctStatusCode:  http.StatusSeeOther,
+		correctUrlLocation: "/admin/admin-rooms",
+	},
+	{
+		testName:          "insert-error",
+		id:                "new",
+		postRqData:        url.Values{"room_name": {"Broken Suite"}, "slug": {"broken-suite"}, "max_adults": {"2"}, "max_children": {"0"}},
+		correctStatusCode: http.StatusInternalServerError,
+	},
+	{
+		testName:          "missing-room",
+		id:                "9",
+		postRqData:        url.Values{"room_name": {"Garden Suite"}, "slug": {"garden-suite"}, "max_adults": {"2"}, "max_children": {"0"}},
+		correctStatusCode: http.StatusNotFound,
+	},
+	{
+		testName:          "room-error",
+		id:                "99",
+		postRqData:        url.Values{"room_name": {"Garden Suite"}, "slug": {"garden-suite"}, "max_adults": {"2"}, "max_children": {"0"}},
+		correctStatusCode: http.StatusInternalServerError,
+	},
+}
+
+func TestRepository_PostAdminShowRoom(t *testing.T) {
+	for _, m := range PostRoomTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-rooms/"+m.id, strings.NewReader(m.postRqData.Encode()))
+		ctx := withURLParam(getContext(rq), "id", m.id)
+		rq = rq.WithContext(ctx)
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminShowRoom)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.correctStatusCode {
+			t.Errorf("Error Testing %s for saving a room expected %v got %v", m.testName, m.correctStatusCode, responseRecorder.Code)
+		}
+		if m.correctUrlLocation != "" {
+			urlLocation, _ := responseRecorder.Result().Location()
+			if urlLocation == nil || urlLocation.String() != m.correctUrlLocation {
+				t.Errorf("Error Testing %s for saving a room expected location %v got %v", m.testName, m.correctUrlLocation, urlLocation)
+			}
+		}
+		if m.correctHTML != "" && !strings.Contains(responseRecorder.Body.String(), m.correctHTML) {
+			t.Errorf("Error Testing %s for saving a room, %q missing from the html", m.testName, m.correctHTML)
+		}
+	}
+}
+
+var DeleteRoomTest = []struct {
+	testName   string
+	id         string
+	sessionKey string
+}{
+	{"unused-room", "1", "flash"},
+	{"reserved-room", "5", "errors"},
+}
+
+func TestRepository_PostAdminDeleteRoom(t *testing.T) {
+	for _, m := range DeleteRoomTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-rooms/"+m.id+"/delete", nil)
+		ctx := withURLParam(getContext(rq), "id", m.id)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminDeleteRoom)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != http.StatusSeeOther {
+			t.Errorf("Error Testing %s for deleting a room expected %v got %v", m.testName, http.StatusSeeOther, responseRecorder.Code)
+		}
+		if session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for deleting a room, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+var UploadPhotoTest = []struct {
+	testName    string
+	id          string
+	content     []byte
+	sessionKey  string
+	correctCode int
+}{
+	{testName: "valid-photo", id: "1", content: testPNG(), sessionKey: "flash", correctCode: http.StatusSeeOther},
+	{testName: "text-file", id: "1", content: []byte("first-name,last-name\n"), sessionKey: "errors", correctCode: http.StatusSeeOther},
+	{testName: "no-file", id: "1", sessionKey: "errors", correctCode: http.StatusSeeOther},
+	{testName: "insert-error", id: "4", content: testPNG(), correctCode: http.StatusInternalServerError},
+	{testName: "missing-room", id: "5", content: testPNG(), correctCode: http.StatusNotFound},
+	{testName: "unreadable-room", id: "99", content: testPNG(), correctCode: http.StatusInternalServerError},
+}
+
+//testPNG returns a small png photo
+func testPNG() []byte {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 20, 10)))
+	return buf.Bytes()
+}
+
+func TestRepository_PostAdminRoomPhoto(t *testing.T) {
+	for _, m := range UploadPhotoTest {
+		body := &bytes.Buffer{}
+		writer := multipart.NewWriter(body)
+		_ = writer.WriteField("caption", "Balcony at sunset")
+		if m.content != nil {
+			part, _ := writer.CreateFormFile("photo", "photo.png")
+			_, _ = part.Write(m.content)
+		}
+		_ = writer.Close()
+
+		rq, _ := http.NewRequest("POST", "/admin/admin-rooms/"+m.id+"/photos", body)
+		ctx := withURLParam(getContext(rq), "id", m.id)
+		rq = rq.WithContext(ctx)
+		rq.Header.Set("Content-Type", writer.FormDataContentType())
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminRoomPhoto)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.correctCode {
+			t.Errorf("Error Testing %s for uploading a photo expected %v got %v", m.testName, m.correctCode, responseRecorder.Code)
+		}
+		if m.sessionKey != "" && session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for uploading a photo, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+var UpdatePhotosTest = []struct {
+	testName   string
+	id         string
+	postRqData url.Values
+	sessionKey string
+}{
+	{"reorder", "1", url.Values{"photo_id": {"1", "2"}, "position-1": {"2"}, "position-2": {"1"}, "caption-2": {"Sunset"}}, "flash"},
+	{"invalid-position", "1", url.Values{"photo_id": {"1"}, "position-1": {"first"}}, "errors"},
+	{"update-error", "5", url.Values{"photo_id": {"1"}, "position-1": {"1"}}, "errors"},
+}
+
+func TestRepository_PostAdminRoomPhotos(t *testing.T) {
+	for _, m := range UpdatePhotosTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-rooms/"+m.id+"/photos/update", strings.NewReader(m.postRqData.Encode()))
+		ctx := withURLParam(getContext(rq), "id", m.id)
+		rq = rq.WithContext(ctx)
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminRoomPhotos)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != http.StatusSeeOther {
+			t.Errorf("Error Testing %s for saving the photos expected %v got %v", m.testName, http.StatusSeeOther, responseRecorder.Code)
+		}
+		if session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for saving the photos, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+func TestRepository_PostAdminDeleteRoomPhoto(t *testing.T) {
+	for _, m := range DeleteRoomTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-rooms/"+m.id+"/photos/2/delete", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", m.id)
+		rctx.URLParams.Add("photoID", "2")
+		ctx := context.WithValue(getContext(rq), chi.RouteCtxKey, rctx)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminDeleteRoomPhoto)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != http.StatusSeeOther {
+			t.Errorf("Error Testing %s for deleting a photo expected %v got %v", m.testName, http.StatusSeeOther, responseRecorder.Code)
+		}
+		if session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for deleting a photo, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+var BookingRuleTest = []struct {
+	testName    string
+	id          string
+	postedData  url.Values
+	sessionKey  string
+	correctCode int
+}{
+	{
+		testName: "valid-rule",
+		id:       "1",
+		postedData: url.Values{
+			"start_date": {"2022-03-01"},
+			"end_date":   {"2022-03-31"},
+			"min_nights": {"2"},
+		},
+		sessionKey:  "flash",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "closed-to-arrival",
+		id:       "1",
+		postedData: url.Values{
+			"start_date":        {"2022-03-05"},
+			"end_date":          {"2022-03-05"},
+			"closed_to_arrival": {"1"},
+		},
+		sessionKey:  "flash",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "end-before-start",
+		id:       "1",
+		postedData: url.Values{
+			"start_date": {"2022-03-31"},
+			"end_date":   {"2022-03-01"},
+			"min_nights": {"2"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "invalid-nights",
+		id:       "1",
+		postedData: url.Values{
+			"start_date": {"2022-03-01"},
+			"end_date":   {"2022-03-31"},
+			"min_nights": {"-2"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "nights-not-a-number",
+		id:       "1",
+		postedData: url.Values{
+			"start_date": {"2022-03-01"},
+			"end_date":   {"2022-03-31"},
+			"max_nights": {"seven"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "invalid-date",
+		id:       "1",
+		postedData: url.Values{
+			"start_date": {"2022-02-30"},
+			"end_date":   {"2022-03-31"},
+			"min_nights": {"2"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "no-restriction",
+		id:       "1",
+		postedData: url.Values{
+			"start_date": {"2022-03-01"},
+			"end_date":   {"2022-03-31"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "insert-error",
+		id:       "4",
+		postedData: url.Values{
+			"start_date": {"2022-03-01"},
+			"end_date":   {"2022-03-31"},
+			"max_nights": {"7"},
+		},
+		correctCode: http.StatusInternalServerError,
+	},
+	{
+		testName: "missing-room",
+		id:       "5",
+		postedData: url.Values{
+			"start_date": {"2022-03-01"},
+			"end_date":   {"2022-03-31"},
+			"max_nights": {"7"},
+		},
+		correctCode: http.StatusNotFound,
+	},
+	{
+		testName: "unreadable-room",
+		id:       "99",
+		postedData: url.Values{
+			"start_date": {"2022-03-01"},
+			"end_date":   {"2022-03-31"},
+			"max_nights": {"7"},
+		},
+		correctCode: http.StatusInternalServerError,
+	},
+}
+
+func TestRepository_PostAdminBookingRule(t *testing.T) {
+	for _, m := range BookingRuleTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-rooms/"+m.id+"/rules", strings.NewReader(m.postedData.Encode()))
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := withURLParam(getContext(rq), "id", m.id)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminBookingRule)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.correctCode {
+			t.Errorf("Error Testing %s for adding a booking rule expected %v got %v", m.testName, m.correctCode, responseRecorder.Code)
+		}
+		if m.sessionKey != "" && session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for adding a booking rule, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+func TestRepository_PostAdminDeleteBookingRule(t *testing.T) {
+	for _, m := range DeleteRoomTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-rooms/"+m.id+"/rules/1/delete", nil)
+		rctx := chi.NewRouteContext()
+		rctx.URLParams.Add("id", m.id)
+		rctx.URLParams.Add("ruleID", "1")
+		ctx := context.WithValue(getContext(rq), chi.RouteCtxKey, rctx)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminDeleteBookingRule)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != http.StatusSeeOther {
+			t.Errorf("Error Testing %s for deleting a booking rule expected %v got %v", m.testName, http.StatusSeeOther, responseRecorder.Code)
+		}
+		if session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for deleting a booking rule, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+var TaxRuleTest = []struct {
+	testName    string
+	postedData  url.Values
+	sessionKey  string
+	correctCode int
+}{
+	{
+		testName: "percent-per-night",
+		postedData: url.Values{
+			"name": {"VAT"}, "kind": {"tax"}, "basis": {"percent"}, "value": {"10"}, "per": {"night"},
+			"start_date": {"2022-03-01"},
+		},
+		sessionKey:  "flash",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "fixed-per-stay-of-a-room",
+		postedData: url.Values{
+			"name": {"Cleaning"}, "kind": {"fee"}, "basis": {"fixed"}, "value": {"30.00"}, "per": {"stay"},
+			"room_id": {"1"}, "start_date": {"2022-03-01"}, "end_date": {"2022-12-31"},
+		},
+		sessionKey:  "flash",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "missing-name",
+		postedData: url.Values{
+			"kind": {"tax"}, "basis": {"fixed"}, "value": {"2.50"}, "per": {"night"}, "start_date": {"2022-03-01"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "percent-over-100",
+		postedData: url.Values{
+			"name": {"VAT"}, "kind": {"tax"}, "basis": {"percent"}, "value": {"120"}, "per": {"night"},
+			"start_date": {"2022-03-01"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "invalid-basis",
+		postedData: url.Values{
+			"name": {"VAT"}, "kind": {"tax"}, "basis": {"per-guest"}, "value": {"10"}, "per": {"night"},
+			"start_date": {"2022-03-01"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "invalid-room",
+		postedData: url.Values{
+			"name": {"VAT"}, "kind": {"tax"}, "basis": {"percent"}, "value": {"10"}, "per": {"night"},
+			"room_id": {"first"}, "start_date": {"2022-03-01"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "end-before-start",
+		postedData: url.Values{
+			"name": {"City tax"}, "kind": {"tax"}, "basis": {"fixed"}, "value": {"2.50"}, "per": {"night"},
+			"start_date": {"2022-03-31"}, "end_date": {"2022-03-01"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "insert-error",
+		postedData: url.Values{
+			"name": {"City tax"}, "kind": {"tax"}, "basis": {"fixed"}, "value": {"2.50"}, "per": {"night"},
+			"room_id": {"5"}, "start_date": {"2022-03-01"},
+		},
+		correctCode: http.StatusInternalServerError,
+	},
+}
+
+func TestRepository_PostAdminTaxRule(t *testing.T) {
+	for _, m := range TaxRuleTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-taxes", strings.NewReader(m.postedData.Encode()))
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminTaxRule)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.correctCode {
+			t.Errorf("Error Testing %s for adding a tax rule expected %v got %v", m.testName, m.correctCode, responseRecorder.Code)
+		}
+		if m.sessionKey != "" && session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for adding a tax rule, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+func TestRepository_PostAdminDeleteTaxRule(t *testing.T) {
+	for _, m := range DeleteRoomTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-taxes/"+m.id+"/delete", nil)
+		ctx := withURLParam(getContext(rq), "id", m.id)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminDeleteTaxRule)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != http.StatusSeeOther {
+			t.Errorf("Error Testing %s for deleting a tax rule expected %v got %v", m.testName, http.StatusSeeOther, responseRecorder.Code)
+		}
+		if session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for deleting a tax rule, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+var PromoCodeTest = []struct {
+	testName    string
+	postedData  url.Values
+	sessionKey  string
+	correctCode int
+}{
+	{
+		testName: "percent-code",
+		postedData: url.Values{
+			"code": {"summer-25"}, "basis": {"percent"}, "value": {"15"}, "min_nights": {"2"}, "max_redemptions": {"100"},
+			"start_date": {"2022-06-01"}, "end_date": {"2022-08-31"},
+		},
+		sessionKey:  "flash",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "fixed-code",
+		postedData: url.Values{
+			"code": {"WELCOME"}, "basis": {"fixed"}, "value": {"20.00"}, "start_date": {"2022-06-01"},
+		},
+		sessionKey:  "flash",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "invalid-code",
+		postedData: url.Values{
+			"code": {"summer 25"}, "basis": {"percent"}, "value": {"15"}, "start_date": {"2022-06-01"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "percent-over-100",
+		postedData: url.Values{
+			"code": {"SUMMER"}, "basis": {"percent"}, "value": {"150"}, "start_date": {"2022-06-01"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "negative-limit",
+		postedData: url.Values{
+			"code": {"SUMMER"}, "basis": {"percent"}, "value": {"15"}, "max_redemptions": {"-1"}, "start_date": {"2022-06-01"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "invalid-date",
+		postedData: url.Values{
+			"code": {"SUMMER"}, "basis": {"percent"}, "value": {"15"}, "start_date": {"June 1st"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "end-before-start",
+		postedData: url.Values{
+			"code": {"SUMMER"}, "basis": {"percent"}, "value": {"15"}, "start_date": {"2022-06-01"}, "end_date": {"2022-05-01"},
+		},
+		sessionKey:  "errors",
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName: "insert-error",
+		postedData: url.Values{
+			"code": {"ERROR"}, "basis": {"fixed"}, "value": {"20"}, "start_date": {"2022-06-01"},
+		},
+		correctCode: http.StatusInternalServerError,
+	},
+}
+
+func TestRepository_PostAdminPromoCode(t *testing.T) {
+	for _, m := range PromoCodeTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-promo-codes", strings.NewReader(m.postedData.Encode()))
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminPromoCode)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.correctCode {
+			t.Errorf("Error Testing %s for adding a promo code expected %v got %v", m.testName, m.correctCode, responseRecorder.Code)
+		}
+		if m.sessionKey != "" && session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for adding a promo code, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+func TestRepository_PostAdminDeletePromoCode(t *testing.T) {
+	for _, m := range DeleteRoomTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-promo-codes/"+m.id+"/delete", nil)
+		ctx := withURLParam(getContext(rq), "id", m.id)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminDeletePromoCode)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != http.StatusSeeOther {
+			t.Errorf("Error Testing %s for deleting a promo code expected %v got %v", m.testName, http.StatusSeeOther, responseRecorder.Code)
+		}
+		if session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for deleting a promo code, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+var ExchangeRateTest = []struct {
+	testName    string
+	postedData  url.Values
+	sessionKey  string
+	correctCode int
+}{
+	{"valid-rate", url.Values{"currency": {"chf"}, "rate": {"0.88"}}, "flash", http.StatusSeeOther},
+	{"base-currency", url.Values{"currency": {"USD"}, "rate": {"1"}}, "errors", http.StatusSeeOther},
+	{"invalid-code", url.Values{"currency": {"EURO"}, "rate": {"0.92"}}, "errors", http.StatusSeeOther},
+	{"invalid-rate", url.Values{"currency": {"EUR"}, "rate": {"-0.92"}}, "errors", http.StatusSeeOther},
+	{"nan-rate", url.Values{"currency": {"EUR"}, "rate": {"NaN"}}, "errors", http.StatusSeeOther},
+	{"infinite-rate", url.Values{"currency": {"EUR"}, "rate": {"Inf"}}, "errors", http.StatusSeeOther},
+	{"positive-infinite-rate", url.Values{"currency": {"EUR"}, "rate": {"+Inf"}}, "errors", http.StatusSeeOther},
+	{"store-error", url.Values{"currency": {"XXX"}, "rate": {"2"}}, "", http.StatusInternalServerError},
+}
+
+func TestRepository_PostAdminExchangeRate(t *testing.T) {
+	for _, m := range ExchangeRateTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-currencies", strings.NewReader(m.postedData.Encode()))
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminExchangeRate)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.correctCode {
+			t.Errorf("Error Testing %s for saving an exchange rate expected %v got %v", m.testName, m.correctCode, responseRecorder.Code)
+		}
+		if m.sessionKey != "" && session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for saving an exchange rate, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+var ImportRatesTest = []struct {
+	testName    string
+	content     string
+	sessionKey  string
+	correctCode int
+}{
+	{"valid-file", "currency,rate\nEUR,0.93\nCHF,0.88\n", "flash", http.StatusSeeOther},
+	{"invalid-line", "currency,rate\nEUR,zero\n", "errors", http.StatusSeeOther},
+	{"base-currency", "currency,rate\nUSD,1\n", "errors", http.StatusSeeOther},
+	{"no-file", "", "errors", http.StatusSeeOther},
+	{"store-error", "currency,rate\nXXX,2\n", "", http.StatusInternalServerError},
+}
+
+func TestRepository_PostAdminImportExchangeRates(t *testing.T) {
+	for _, m := range ImportRatesTest {
+		body := &strings.Builder{}
+		writer := multipart.NewWriter(body)
+		if m.content != "" {
+			part, _ := writer.CreateFormFile("rates-file", "rates.csv")
+			part.Write([]byte(m.content))
+		}
+		writer.Close()
+
+		rq, _ := http.NewRequest("POST", "/admin/admin-currencies/import", strings.NewReader(body.String()))
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+		rq.Header.Set("Content-Type", writer.FormDataContentType())
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminImportExchangeRates)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.correctCode {
+			t.Errorf("Error Testing %s for importing the exchange rates expected %v got %v", m.testName, m.correctCode, responseRecorder.Code)
+		}
+		if m.sessionKey != "" && session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for importing the exchange rates, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+func TestRepository_PostAdminDeleteExchangeRate(t *testing.T) {
+	for _, m := range []struct {
+		testName   string
+		currency   string
+		sessionKey string
+	}{
+		{"stored-rate", "EUR", "flash"},
+		{"delete-error", "XXX", "errors"},
+	} {
+		rq, _ := http.NewRequest("POST", "/admin/admin-currencies/"+m.currency+"/delete", nil)
+		ctx := withURLParam(getContext(rq), "currency", m.currency)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminDeleteExchangeRate)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != http.StatusSeeOther {
+			t.Errorf("Error Testing %s for deleting an exchange rate expected %v got %v", m.testName, http.StatusSeeOther, responseRecorder.Code)
+		}
+		if session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for deleting an exchange rate, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+var BlockTest = []struct {
+	testName    string
+	id          string
+	postedData  url.Values
+	correctCode int
+	location    string
+}{
+	{
+		testName: "new-block",
+		id:       "new",
+		postedData: url.Values{
+			"room_id":    {"1"},
+			"start_date": {"2022-05-10"},
+			"end_date":   {"2022-05-14"},
+			"reason":     {"renovation"},
+			"notes":      {"New floor"},
+		},
+		correctCode: http.StatusSeeOther,
+		location:    "/admin/admin-reservation-calendar?y=2022&m=5",
+	},
+	{
+		testName: "one-night-block",
+		id:       "1",
+		postedData: url.Values{
+			"room_id":    {"2"},
+			"start_date": {"2022-06-01"},
+			"end_date":   {"2022-06-01"},
+			"reason":     {"owner stay"},
+		},
+		correctCode: http.StatusSeeOther,
+		location:    "/admin/admin-reservation-calendar?y=2022&m=6",
+	},
+	{
+		testName: "last-night-before-first",
+		id:       "new",
+		postedData: url.Values{
+			"room_id":    {"1"},
+			"start_date": {"2022-05-10"},
+			"end_date":   {"2022-05-09"},
+			"reason":     {"maintenance"},
+		},
+		correctCode: http.StatusUnprocessableEntity,
+	},
+	{
+		testName: "unknown-reason",
+		id:       "new",
+		postedData: url.Values{
+			"room_id":    {"1"},
+			"start_date": {"2022-05-10"},
+			"end_date":   {"2022-05-12"},
+			"reason":     {"party"},
+		},
+		correctCode: http.StatusUnprocessableEntity,
+	},
+	{
+		testName:    "missing-fields",
+		id:          "new",
+		postedData:  url.Values{"room_id": {"1"}},
+		correctCode: http.StatusUnprocessableEntity,
+	},
+	{
+		testName: "no-room",
+		id:       "new",
+		postedData: url.Values{
+			"room_id":    {"0"},
+			"start_date": {"2022-05-10"},
+			"end_date":   {"2022-05-12"},
+			"reason":     {"maintenance"},
+		},
+		correctCode: http.StatusUnprocessableEntity,
+	},
+	{
+		testName: "invalid-date",
+		id:       "new",
+		postedData: url.Values{
+			"room_id":    {"1"},
+			"start_date": {"2022-05-10"},
+			"end_date":   {"12/05/2022"},
+			"reason":     {"maintenance"},
+		},
+		correctCode: http.StatusUnprocessableEntity,
+	},
+	{
+		testName: "overlaps-reservation",
+		id:       "new",
+		postedData: url.Values{
+			"room_id":    {"1"},
+			"start_date": {"2025-09-08"},
+			"end_date":   {"2025-09-10"},
+			"reason":     {"maintenance"},
+		},
+		correctCode: http.StatusUnprocessableEntity,
+	},
+	{
+		testName: "update-error",
+		id:       "9",
+		postedData: url.Values{
+			"room_id":    {"1"},
+			"start_date": {"2022-05-10"},
+			"end_date":   {"2022-05-12"},
+			"reason":     {"maintenance"},
+		},
+		correctCode: http.StatusUnprocessableEntity,
+	},
+}
+
+func TestRepository_PostAdminBlock(t *testing.T) {
+	for _, m := range BlockTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-blocks/"+m.id, strings.NewReader(m.postedData.Encode()))
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := withURLParam(getContext(rq), "id", m.id)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminBlock)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.correctCode {
+			t.Errorf("Error Testing %s for saving a block expected %v got %v", m.testName, m.correctCode, responseRecorder.Code)
+		}
+		if m.location != "" && responseRecorder.Header().Get("Location") != m.location {
+			t.Errorf("Error Testing %s for saving a block expected to go to %s got %s", m.testName, m.location, responseRecorder.Header().Get("Location"))
+		}
+	}
+}
+
+func TestRepository_PostAdminDeleteBlock(t *testing.T) {
+	for _, m := range []struct {
+		testName   string
+		id         string
+		sessionKey string
+	}{
+		{"block", "1", "flash"},
+		{"missing-block", "9", "errors"},
+	} {
+		postedData := url.Values{"start_date": {"2022-05-10"}}
+		rq, _ := http.NewRequest("POST", "/admin/admin-blocks/"+m.id+"/delete", strings.NewReader(postedData.Encode()))
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := withURLParam(getContext(rq), "id", m.id)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminDeleteBlock)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != http.StatusSeeOther {
+			t.Errorf("Error Testing %s for removing a block expected %v got %v", m.testName, http.StatusSeeOther, responseRecorder.Code)
+		}
+		if responseRecorder.Header().Get("Location") != "/admin/admin-reservation-calendar?y=2022&m=5" {
+			t.Errorf("Error Testing %s for removing a block, wrong calendar month %s", m.testName, responseRecorder.Header().Get("Location"))
+		}
+		if session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for removing a block, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+var CalendarTest = []struct {
+	testName    string
+	postedData  url.Values
+	correctCode int
+	conflict    string
+}{
+	{
+		testName:    "free-nights",
+		postedData:  url.Values{"add_block_1_2022-05-07": {"1"}, "add_block_1_2022-05-08": {"1"}, "add_block_2_2022-05-02": {"1"}},
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName:    "reserved-night",
+		postedData:  url.Values{"add_block_1_2022-05-03": {"1"}, "add_block_1_2022-05-07": {"1"}},
+		correctCode: http.StatusConflict,
+		conflict:    "the room was reserved on 2022-05-03 in the meantime",
+	},
+	{
+		testName:    "blocked-night",
+		postedData:  url.Values{"add_block_1_2022-05-11": {"1"}},
+		correctCode: http.StatusConflict,
+		conflict:    "the room was blocked on 2022-05-11 in the meantime",
+	},
+	{
+		testName:    "held-night",
+		postedData:  url.Values{"add_block_1_2022-05-21": {"1"}},
+		correctCode: http.StatusConflict,
+		conflict:    "a guest is booking the room on 2022-05-21",
+	},
+	{
+		testName:    "remove-hold",
+		postedData:  url.Values{fmt.Sprintf("remove_block_1_3_%d", dbRepository.TestBlockVersion.UnixNano()): {"1"}},
+		correctCode: http.StatusConflict,
+		conflict:    "the block was already removed",
+	},
+	{
+		testName:    "unknown-room",
+		postedData:  url.Values{"add_block_9_2022-05-11": {"1"}},
+		correctCode: http.StatusConflict,
+		conflict:    "unknown room or night",
+	},
+	{
+		testName:    "invalid-night",
+		postedData:  url.Values{"add_block_1_2022-05-45": {"1"}},
+		correctCode: http.StatusBadRequest,
+	},
+	{
+		testName:    "invalid-room",
+		postedData:  url.Values{"add_block_slim_2022-05-11": {"1"}},
+		correctCode: http.StatusBadRequest,
+	},
+	{
+		testName:    "remove-block",
+		postedData:  url.Values{fmt.Sprintf("remove_block_1_2_%d", dbRepository.TestBlockVersion.UnixNano()): {"1"}},
+		correctCode: http.StatusSeeOther,
+	},
+	{
+		testName:    "remove-invalid-version",
+		postedData:  url.Values{"remove_block_1_2_yesterday": {"1"}},
+		correctCode: http.StatusBadRequest,
+	},
+	{
+		testName:    "remove-changed-block",
+		postedData:  url.Values{"remove_block_1_2_1648808000000000000": {"1"}},
+		correctCode: http.StatusConflict,
+		conflict:    "the block was changed by someone else, check it before removing it",
+	},
+	{
+		testName:    "remove-removed-block",
+		postedData:  url.Values{fmt.Sprintf("remove_block_1_7_%d", dbRepository.TestBlockVersion.UnixNano()): {"1"}},
+		correctCode: http.StatusConflict,
+		conflict:    "the block was already removed",
+	},
+}
+
+func TestRepository_PostAdminReservationCalendar(t *testing.T) {
+	for _, m := range CalendarTest {
+		m.postedData.Set("y", "2022")
+		m.postedData.Set("m", "05")
+		rq, _ := http.NewRequest("POST", "/admin/admin-reservation-calendar", strings.NewReader(m.postedData.Encode()))
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminReservationCalendar)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.correctCode {
+			t.Errorf("Error Testing %s for saving the calendar expected %v got %v", m.testName, m.correctCode, responseRecorder.Code)
+		}
+		if m.correctCode == http.StatusSeeOther {
+			if responseRecorder.Header().Get("Location") != "/admin/admin-reservation-calendar?y=2022&m=5" {
+				t.Errorf("Error Testing %s for saving the calendar, wrong month %s", m.testName, responseRecorder.Header().Get("Location"))
+			}
+			if session.GetString(ctx, "flash") == "" {
+				t.Errorf("Error Testing %s for saving the calendar, no flash message in the session", m.testName)
+			}
+		}
+		if m.conflict != "" && !strings.Contains(responseRecorder.Body.String(), m.conflict) {
+			t.Errorf("Error Testing %s for saving the calendar, the conflict %q is not shown", m.testName, m.conflict)
+		}
+	}
+}
+
+func TestNightsToBlocks(t *testing.T) {
+	night := func(date string) time.Time {
+		d, _ := time.Parse("2006-01-02", date)
+		return d
+	}
+	blocks := nightsToBlocks(map[int][]time.Time{
+		1: {night("2022-05-08"), night("2022-05-07"), night("2022-05-10")},
+		2: {night("2022-05-07")},
+	})
+	expected := []string{"1 2022-05-07 2022-05-09", "1 2022-05-10 2022-05-11", "2 2022-05-07 2022-05-08"}
+	if len(blocks) != len(expected) {
+		t.Fatalf("Error Testing the calendar blocks expected %d blocks got %d", len(expected), len(blocks))
+	}
+	for i, b := range blocks {
+		got := fmt.Sprintf("%d %s %s", b.RoomID, b.CheckInDate.Format("2006-01-02"), b.CheckOutDate.Format("2006-01-02"))
+		if got != expected[i] {
+			t.Errorf("Error Testing the calendar blocks expected %s got %s", expected[i], got)
+		}
+	}
+}
+
+func TestCalendarMonth(t *testing.T) {
+	//today is the day at the property, the calendar opens on its month
+	today := time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)
+	for _, m := range []struct {
+		values url.Values
+		want   time.Time
+	}{
+		{url.Values{}, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{url.Values{"y": {"2031"}, "m": {"7"}}, time.Date(2031, 7, 1, 0, 0, 0, 0, time.UTC)},
+		{url.Values{"y": {"2031"}, "m": {"13"}}, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{url.Values{"y": {"next"}, "m": {"2"}}, time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+	} {
+		if got := calendarMonth(m.values, today); !got.Equal(m.want) {
+			t.Errorf("Error Testing the month of the calendar for %v got %v wanted %v", m.values, got, m.want)
+		}
+	}
+}
+
+func TestRepository_AdminCalendarData(t *testing.T) {
+	rq, _ := http.NewRequest("GET", "/admin/admin-calendar-data?y=2022&m=02", nil)
+	rq = rq.WithContext(getContext(rq))
+
+	responseRecorder := httptest.NewRecorder()
+	handler := http.HandlerFunc(Repo.AdminCalendarData)
+	handler.ServeHTTP(responseRecorder, rq)
+
+	var calendar CalendarJSON
+	err := json.Unmarshal(responseRecorder.Body.Bytes(), &calendar)
+	if err != nil {
+		t.Fatalf("Error Testing the calendar data, cannot parse the json: %v", err)
+	}
+	if calendar.Start != "2022-02-01" || calendar.Days != 28 {
+		t.Errorf("Error Testing the calendar data expected 28 days from 2022-02-01 got %d from %s", calendar.Days, calendar.Start)
+	}
+	if len(calendar.Entries) != 3 {
+		t.Fatalf("Error Testing the calendar data expected 3 entries got %d", len(calendar.Entries))
+	}
+	resv, block, hold := calendar.Entries[0], calendar.Entries[1], calendar.Entries[2]
+	if resv.Kind != "reservation" || resv.ReservationID != 1 || resv.Label != "Graham Graham" || resv.CheckInDate != "2022-02-03" {
+		t.Errorf("Error Testing the calendar data, wrong reservation %+v", resv)
+	}
+	if block.Kind != "block" || block.Label != "maintenance" || block.CheckOutDate != "2022-02-13" {
+		t.Errorf("Error Testing the calendar data, wrong block %+v", block)
+	}
+	if hold.Kind != "hold" || hold.Label != "held until 10:45" || hold.CheckInDate != "2022-02-20" {
+		t.Errorf("Error Testing the calendar data, wrong hold %+v", hold)
+	}
+}
+
+var CalendarMoveTest = []struct {
+	testName   string
+	postedData url.Values
+	ok         bool
+	message    string
+}{
+	{
+		testName:   "move",
+		postedData: url.Values{"reservation_id": {"1"}, "room_id": {"2"}, "check-in": {"2022-05-04"}, "check-out": {"2022-05-07"}},
+		ok:         true,
+		message:    "reservation 1 moved",
+	},
+	{
+		testName:   "check-out-before-check-in",
+		postedData: url.Values{"reservation_id": {"1"}, "room_id": {"2"}, "check-in": {"2022-05-04"}, "check-out": {"2022-05-04"}},
+		message:    "the check-out date must be after the check-in date",
+	},
+	{
+		testName:   "missing-reservation",
+		postedData: url.Values{"reservation_id": {"0"}, "room_id": {"2"}, "check-in": {"2022-05-04"}, "check-out": {"2022-05-07"}},
+		message:    "reservation 0 doesn't exist",
+	},
+	{
+		testName:   "invalid-room",
+		postedData: url.Values{"reservation_id": {"1"}, "room_id": {"5"}, "check-in": {"2022-05-04"}, "check-out": {"2022-05-07"}},
+		message:    "invalid room id",
+	},
+	{
+		testName:   "room-reserved",
+		postedData: url.Values{"reservation_id": {"1"}, "room_id": {"2"}, "check-in": {"2025-09-08"}, "check-out": {"2025-09-11"}},
+		message:    "room 2 is reserved from 2025-09-09 to 2025-09-12 by reservation 2",
+	},
+}
+
+func TestRepository_PostAdminCalendarMove(t *testing.T) {
+	for _, m := range CalendarMoveTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-calendar-move", strings.NewReader(m.postedData.Encode()))
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		rq = rq.WithContext(getContext(rq))
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminCalendarMove)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		var resp ResponseJSON
+		err := json.Unmarshal(responseRecorder.Body.Bytes(), &resp)
+		if err != nil {
+			t.Fatalf("Error Testing %s for moving a reservation, cannot parse the json: %v", m.testName, err)
+		}
+		if resp.Ok != m.ok || resp.Message != m.message {
+			t.Errorf("Error Testing %s for moving a reservation expected %v %q got %v %q", m.testName, m.ok, m.message, resp.Ok, resp.Message)
+		}
+	}
+}
+
+//withURLParam adds the chi url parameter to the context so handlers can be called without the router
+var WaitlistTest = []struct {
+	testName           string
+	postedData         url.Values
+	expectedStatusCode int
+	expectedLocation   string
+	sessionKey         string
+}{
+	{"valid-entry", url.Values{
+		"first-name": {"Graham"}, "last-name": {"Graham"}, "email": {"graham@example.com"},
+		"check-in": {"2030-01-10"}, "check-out": {"2030-01-12"}, "adults": {"2"}, "room_id": {""},
+	}, http.StatusSeeOther, "/", "flash"},
+	{"wanted-room", url.Values{
+		"first-name": {"Graham"}, "last-name": {"Graham"}, "email": {"graham@example.com"},
+		"check-in": {"2030-01-10"}, "check-out": {"2030-01-12"}, "room_id": {"2"},
+	}, http.StatusSeeOther, "/", "flash"},
+	{"invalid-email", url.Values{
+		"first-name": {"Graham"}, "last-name": {"Graham"}, "email": {"graham"},
+		"check-in": {"2030-01-10"}, "check-out": {"2030-01-12"},
+	}, http.StatusSeeOther, "/check-availability", "errors"},
+	{"past-dates", url.Values{
+		"first-name": {"Graham"}, "last-name": {"Graham"}, "email": {"graham@example.com"},
+		"check-in": {"2020-01-10"}, "check-out": {"2020-01-12"},
+	}, http.StatusSeeOther, "/check-availability", "errors"},
+	{"reversed-dates", url.Values{
+		"first-name": {"Graham"}, "last-name": {"Graham"}, "email": {"graham@example.com"},
+		"check-in": {"2030-01-12"}, "check-out": {"2030-01-10"},
+	}, http.StatusSeeOther, "/check-availability", "errors"},
+	{"insert-error", url.Values{
+		"first-name": {"Graham"}, "last-name": {"Graham"}, "email": {"graham@example.com"},
+		"check-in": {"2030-01-10"}, "check-out": {"2030-01-12"}, "room_id": {"5"},
+	}, http.StatusInternalServerError, "", ""},
+}
+
+func TestRepository_PostWaitlist(t *testing.T) {
+	for _, m := range WaitlistTest {
+		rq, _ := http.NewRequest("POST", "/waitlist", strings.NewReader(m.postedData.Encode()))
+		rq.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		ctx := getContext(rq)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostWaitlist)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.expectedStatusCode {
+			t.Errorf("Error Testing %s for joining the waitlist expected %v got %v", m.testName, m.expectedStatusCode, responseRecorder.Code)
+		}
+		if m.expectedLocation != "" && responseRecorder.Header().Get("Location") != m.expectedLocation {
+			t.Errorf("Error Testing %s for joining the waitlist expected %s got %s", m.testName, m.expectedLocation, responseRecorder.Header().Get("Location"))
+		}
+		if m.sessionKey != "" && session.GetString(ctx, m.sessionKey) == "" {
+			t.Errorf("Error Testing %s for joining the waitlist, no %s message in the session", m.testName, m.sessionKey)
+		}
+	}
+}
+
+var WaitlistHoldTest = []struct {
+	testName         string
+	token            string
+	expectedLocation string
+}{
+	{"valid-token", "valid-token", "/make-reservation"},
+	{"expired-token", "expired-token", "/check-availability"},
+	{"claimed-token", "claimed-token", "/check-availability"},
+	{"unknown-token", "unknown-token", "/check-availability"},
+}
+
+func TestRepository_WaitlistHold(t *testing.T) {
+	for _, m := range WaitlistHoldTest {
+		rq, _ := http.NewRequest("GET", "/waitlist/hold/"+m.token, nil)
+		ctx := withURLParam(getContext(rq), "token", m.token)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.WaitlistHold)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != http.StatusSeeOther {
+			t.Errorf("Error Testing %s for the hold link expected %v got %v", m.testName, http.StatusSeeOther, responseRecorder.Code)
+		}
+		if responseRecorder.Header().Get("Location") != m.expectedLocation {
+			t.Errorf("Error Testing %s for the hold link expected %s got %s", m.testName, m.expectedLocation, responseRecorder.Header().Get("Location"))
+		}
+		_, held := session.Get(ctx, "reservation").(models.Reservation)
+		if held != (m.expectedLocation == "/make-reservation") {
+			t.Errorf("Error Testing %s for the hold link, wrong reservation in the session", m.testName)
+		}
+		//the hold made when the room was offered is the one the reservation takes
+		if holdIDs, _ := session.Get(ctx, "holds").([]int); held && (len(holdIDs) != 1 || holdIDs[0] != 1) {
+			t.Errorf("Error Testing %s for the hold link, the offered hold isn't kept %v", m.testName, holdIDs)
+		}
+	}
+}
+
+func withURLParam(ctx context.Context, key, value string) context.Context {
+	rctx := chi.NewRouteContext()
+	rctx.URLParams.Add(key, value)
+	return context.WithValue(ctx, chi.RouteCtxKey, rctx)
+}
+
+func getContext(rq *http.Request) context.Context {
+	ctx, err := session.Load(rq.Context(), rq.Header.Get("X-Session"))
+	if err != nil {
+		log.Println(err)
+	}
+	return ctx
+}
+
+var CancelResvTest = []struct {
+	testName          string
+	id                string
+	correctStatusCode int
+	flash             string
+	error             string
+}{
+	{"late-cancellation", "1", http.StatusSeeOther, "0.00 USD to refund", ""},
+	{"early-cancellation", "2", http.StatusSeeOther, "72.00 USD to refund", ""},
+	//half of the discounted price booked is kept, not half of the rate the room has now
+	{"discounted-late-cancellation", "4", http.StatusSeeOther, "12.00 USD to refund", ""},
+	{"already-cancelled", "3", http.StatusSeeOther, "", "already cancelled"},
+	{"cancel-error", "5", http.StatusSeeOther, "", "Error cannot cancel the reservation"},
+	{"invalid-id", "x", http.StatusBadRequest, "", ""},
+}
+
+func TestRepository_PostAdminCancelReservation(t *testing.T) {
+	for _, m := range CancelResvTest {
+		rq, _ := http.NewRequest("POST", "/admin/admin-cancel-reservation/all/"+m.id, nil)
+		ctx := withURLParam(getContext(rq), "id", m.id)
+		rq = rq.WithContext(ctx)
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.PostAdminCancelReservation)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.correctStatusCode {
+			t.Errorf("Error Testing %s for cancelling a reservation expected %v got %v", m.testName, m.correctStatusCode, responseRecorder.Code)
+		}
+		if flash := session.GetString(ctx, "flash"); !strings.Contains(flash, m.flash) || (m.flash == "" && flash != "") {
+			t.Errorf("Error Testing %s for cancelling a reservation got flash %q", m.testName, flash)
+		}
+		if errMsg := session.GetString(ctx, "errors"); !strings.Contains(errMsg, m.error) || (m.error == "" && errMsg != "") {
+			t.Errorf("Error Testing %s for cancelling a reservation got error %q", m.testName, errMsg)
+		}
+	}
+}
+
+var InvoiceTest = []struct {
+	testName          string
+	id                string
+	correctStatusCode int
+}{
+	{"reservation-invoice", "1", http.StatusOK},
+	{"invoice-error", "5", http.StatusInternalServerError},
+	{"invalid-id", "x", http.StatusBadRequest},
+}
+
+func TestRepository_AdminReservationInvoice(t *testing.T) {
+	for _, m := range InvoiceTest {
+		rq, _ := http.NewRequest("GET", "/admin/admin-reservation-invoice/"+m.id, nil)
+		rq = rq.WithContext(withURLParam(getContext(rq), "id", m.id))
+
+		responseRecorder := httptest.NewRecorder()
+		handler := http.HandlerFunc(Repo.AdminReservationInvoice)
+		handler.ServeHTTP(responseRecorder, rq)
+
+		if responseRecorder.Code != m.correctStatusCode {
+			t.Errorf("Error Testing %s for the invoice expected %v got %v", m.testName, m.correctStatusCode, responseRecorder.Code)
+		}
+		if m.correctStatusCode != http.StatusOK {
+			continue
+		}
+		if responseRecorder.Header().Get("Content-Type") != "application/pdf" ||
+			!strings.Contains(responseRecorder.Header().Get("Content-Disposition"), "INV-000001.pdf") {
+			t.Errorf("Error Testing %s for the invoice got headers %v", m.testName, responseRecorder.Header())
+		}
+		body := responseRecorder.Body.String()
+		if !strings.HasPrefix(body, "%PDF-") || !strings.Contains(body, "(-72.00)") {
+			t.Errorf("Error Testing %s for the invoice, the deposit is missing from the PDF", m.testName)
+		}
+	}
+}
+
+func TestRepository_invoiceAttachments(t *testing.T) {
+	attachments := Repo.invoiceAttachments(models.Reservation{ID: 1}, models.Reservation{ID: 5}, models.Reservation{ID: 2})
+	if len(attachments) != 2 || attachments[0].Name != "INV-000001.pdf" || attachments[1].Name != "INV-000002.pdf" {
+		t.Errorf("Error Testing the invoice attachments got %d attachments", len(attachments))
+	}
+	for _, attachment := range attachments {
+		if attachment.ContentType != "application/pdf" || !bytes.HasPrefix(attachment.Data, []byte("%PDF-")) {
+			t.Errorf("Error Testing the invoice attachment %s, not a PDF", attachment.Name)
+		}
 	}
-	return ctx
 }
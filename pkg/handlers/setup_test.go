@@ -16,9 +16,14 @@ import (
 	"github.com/justinas/nosurf"
 
 	"github.com/dev-ayaa/resvbooking/pkg/config"
+	"github.com/dev-ayaa/resvbooking/pkg/currency"
+	"github.com/dev-ayaa/resvbooking/pkg/dates"
 	"github.com/dev-ayaa/resvbooking/pkg/helpers"
+	"github.com/dev-ayaa/resvbooking/pkg/i18n"
 	"github.com/dev-ayaa/resvbooking/pkg/models"
+	"github.com/dev-ayaa/resvbooking/pkg/payments"
 	"github.com/dev-ayaa/resvbooking/pkg/render"
+	"github.com/dev-ayaa/resvbooking/pkg/storage"
 )
 
 var session *scs.SessionManager
@@ -31,6 +36,10 @@ var functions = template.FuncMap{
 	"format":     render.RenderFormat,
 	"iterate":    render.RenderIterate,
 	"add":        render.RenderAddUp,
+	"money":      models.FormatAmount,
+	"price":      render.RenderPrice,
+	"t":          i18n.Translate,
+	"localTime":  render.RenderLocalTime,
 }
 
 var templatesPath = "./../../templates"
@@ -42,15 +51,38 @@ func getRoutes() http.Handler {
 	gob.Register(models.RoomRestriction{})
 	gob.Register(models.User{})
 	gob.Register(models.MailData{})
+	gob.Register([]models.Reservation{})
+	gob.Register(models.BookingGroup{})
+	gob.Register([]int{})
 
 	app.InProduction = false
 
+	mailChannel := make(chan models.MailData)
+	app.MailChannel = mailChannel
+	listenForMail()
+
 	infoLogger := log.New(os.Stdout, "INFO ::\t", log.LstdFlags)
 	app.InfoLog = infoLogger
 
 	errorLogger := log.New(os.Stdout, "ERROR ::\t", log.LstdFlags|log.Lshortfile)
 	app.ErrorLog = errorLogger
 
+	uploadDir, err := os.MkdirTemp("", "uploads")
+	if err != nil {
+		log.Fatal("Cannot create the upload directory")
+	}
+	app.Storage, err = storage.NewLocalStorage(uploadDir, "/uploads")
+	if err != nil {
+		log.Fatal("Cannot create the photo storage")
+	}
+	app.Payments = payments.NewFake("test-secret")
+	app.Rates = currency.NewRates(payments.Currency)
+	app.Rates.Set([]models.ExchangeRate{{Currency: "EUR", Rate: 0.92}, {Currency: "GBP", Rate: 0.786}})
+	app.Property, _ = dates.NewProperty("Europe/Paris", "15:00", "11:00")
+	//the stays of the test database are booked from the start of 2022, some of them years ahead
+	app.Property.SetClock(func() time.Time { return time.Date(2022, 1, 1, 9, 0, 0, 0, time.UTC) })
+	app.MaxStayNights = 30
+
 	session = scs.New()
 	session.Lifetime = 24 * time.Hour              // how to keep the session of users
 	session.Cookie.Persist = true                  //To keep cookies
@@ -89,15 +121,16 @@ func getRoutes() http.Handler {
 	mux.Get("/", Repo.HomePage)
 	mux.Get("/about", Repo.AboutPage)
 	mux.Get("/contact", Repo.ContactPage)
-	mux.Get("/junior-suite", Repo.JuniorSuitePage)
-	mux.Get("/premium-suite", Repo.PremiumSuitePage)
-	mux.Get("/deluxe-suite", Repo.DeluxeSuitePage)
-	mux.Get("/penthouse-suite", Repo.PenthousePage)
-	mux.Get("/executive-suite", Repo.ExecutivePage)
+	mux.Get("/rooms", Repo.RoomsPage)
+	mux.Get("/rooms/{slug}", Repo.RoomPage)
+	mux.Get("/junior-suite", http.RedirectHandler("/rooms/junior-suite", http.StatusMovedPermanently).ServeHTTP)
+	mux.Get("/deluxe-suite", http.RedirectHandler("/rooms/deluxe-suite", http.StatusMovedPermanently).ServeHTTP)
 
 	mux.Get("/make-reservation", Repo.MakeReservationPage)
 	mux.Post("/make-reservation", Repo.PostMakeReservationPage)
 	mux.Get("/make-reservation-data", Repo.MakeReservationSummary)
+	mux.Post("/display-currency", Repo.PostDisplayCurrency)
+	mux.Post("/locale", Repo.PostLocale)
 
 	//mux.Get("/check-availability", Repo.CheckAvailabilityPage)
 	mux.Get("/check-availability", Repo.CheckAvailabilityPage)
@@ -106,6 +139,13 @@ func getRoutes() http.Handler {
 	mux.Get("/json-availability", Repo.JsonAvailabilityPage)
 	mux.Post("/json-availability", Repo.JsonAvailabilityPage)
 
+	mux.Post("/waitlist", Repo.PostWaitlist)
+	mux.Post("/payments/webhook", Repo.PaymentWebhook)
+	mux.Get("/waitlist/hold/{token}", Repo.WaitlistHold)
+
+	mux.Get("/select-available-room/{id}", Repo.SelectAvailableRoom)
+	mux.Post("/select-available-room", Repo.PostSelectAvailableRoom)
+
 	mux.Get("/login", Repo.LoginPage)
 	mux.Post("/login", Repo.PostLoginPage)
 	mux.Get("/logout", Repo.LogOutPage)
@@ -118,9 +158,41 @@ func getRoutes() http.Handler {
 	mux.Get("/admin/admin-all-reservation", Repo.AdminAllReservation)
 	mux.Get("/admin/admin-reservation-calendar", Repo.AdminReservationCalendar)
 	mux.Post("/admin/admin-reservation-calendar", Repo.PostAdminReservationCalendar)
+	mux.Get("/admin/admin-reservation-planner", Repo.AdminReservationPlanner)
+	mux.Get("/admin/admin-calendar-data", Repo.AdminCalendarData)
+	mux.Post("/admin/admin-calendar-move", Repo.PostAdminCalendarMove)
+	mux.Get("/admin/admin-blocks/{id}", Repo.AdminBlock)
+	mux.Post("/admin/admin-blocks/{id}", Repo.PostAdminBlock)
+	mux.Post("/admin/admin-blocks/{id}/delete", Repo.PostAdminDeleteBlock)
+
+	mux.Get("/admin/admin-import-reservation", Repo.AdminImportReservation)
+	mux.Post("/admin/admin-import-reservation", Repo.PostAdminImportReservation)
+	mux.Post("/admin/admin-import-reservation/confirm", Repo.PostAdminConfirmImportReservation)
+
+	mux.Get("/admin/admin-rooms", Repo.AdminRooms)
+	mux.Get("/admin/admin-rooms/{id}", Repo.AdminShowRoom)
+	mux.Post("/admin/admin-rooms/{id}", Repo.PostAdminShowRoom)
+	mux.Post("/admin/admin-rooms/{id}/delete", Repo.PostAdminDeleteRoom)
+	mux.Post("/admin/admin-rooms/{id}/photos", Repo.PostAdminRoomPhoto)
+	mux.Post("/admin/admin-rooms/{id}/photos/update", Repo.PostAdminRoomPhotos)
+	mux.Post("/admin/admin-rooms/{id}/photos/{photoID}/delete", Repo.PostAdminDeleteRoomPhoto)
+	mux.Post("/admin/admin-rooms/{id}/rules", Repo.PostAdminBookingRule)
+	mux.Post("/admin/admin-rooms/{id}/rules/{ruleID}/delete", Repo.PostAdminDeleteBookingRule)
+	mux.Get("/admin/admin-taxes", Repo.AdminTaxes)
+	mux.Post("/admin/admin-taxes", Repo.PostAdminTaxRule)
+	mux.Post("/admin/admin-taxes/{id}/delete", Repo.PostAdminDeleteTaxRule)
+	mux.Get("/admin/admin-promo-codes", Repo.AdminPromoCodes)
+	mux.Post("/admin/admin-promo-codes", Repo.PostAdminPromoCode)
+	mux.Post("/admin/admin-promo-codes/{id}/delete", Repo.PostAdminDeletePromoCode)
+	mux.Get("/admin/admin-currencies", Repo.AdminCurrencies)
+	mux.Post("/admin/admin-currencies", Repo.PostAdminExchangeRate)
+	mux.Post("/admin/admin-currencies/import", Repo.PostAdminImportExchangeRates)
+	mux.Post("/admin/admin-currencies/{currency}/delete", Repo.PostAdminDeleteExchangeRate)
 
 	mux.Get("/admin/admin-show-reservation/{src}/{id}/show", Repo.AdminShowReservation)
 	mux.Post("/admin/admin-show-reservation/{src}/{id}", Repo.PostAdminShowReservation)
+	mux.Post("/admin/admin-cancel-reservation/{src}/{id}", Repo.PostAdminCancelReservation)
+	mux.Get("/admin/admin-reservation-invoice/{id}", Repo.AdminReservationInvoice)
 
 	mux.Get("/admin/admin-delete-reservation/{src}/{id}/done", Repo.AdminDeleteReservation)
 	mux.Get("/admin/admin-process-reservation/{src}/{id}/done", Repo.AdminProcessReservation)
@@ -128,10 +200,20 @@ func getRoutes() http.Handler {
 	//This allows files static files like images and icon to display in the html
 	fileServer := http.FileServer(http.Dir("./static/"))
 	mux.Handle("/static/*", http.StripPrefix("/static", fileServer))
+	mux.Handle("/uploads/*", http.StripPrefix("/uploads", app.Storage))
 
 	return mux
 }
 
+//listenForMail drains the mail channel so handlers sending mails don't block during the tests
+func listenForMail() {
+	go func() {
+		for {
+			_ = <-app.MailChannel
+		}
+	}()
+}
+
 func NoSurf(next http.Handler) http.Handler {
 	//Cross sites examined
 	csrfHandler := nosurf.New(next)
@@ -3,6 +3,7 @@ package helpers
 import (
 	"fmt"
 	"github.com/dev-ayaa/resvbooking/pkg/config"
+	"github.com/dev-ayaa/resvbooking/pkg/i18n"
 	"net/http"
 	"runtime/debug"
 )
@@ -28,3 +29,8 @@ func IsAuthenticated(rq *http.Request) bool {
 	est := app.Session.Exists(rq.Context(), "userID")
 	return est
 }
+
+//Locale returns the language the guest chose for the visit, else the one the browser prefers
+func Locale(rq *http.Request) string {
+	return i18n.Resolve(app.Session.GetString(rq.Context(), "locale"), rq.Header.Get("Accept-Language"))
+}
@@ -0,0 +1,55 @@
+package holds
+
+import (
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/config"
+	"github.com/dev-ayaa/resvbooking/repository"
+)
+
+//Duration how long a room selected by a guest stays held while the reservation form is filled
+const Duration = 15 * time.Minute
+
+//Interval how often the expired holds are swept
+const Interval = time.Minute
+
+//Sweeper frees the rooms of the holds which expired before the guest booked them
+type Sweeper struct {
+	App *config.AppConfig
+	DB  repository.DatabaseRepository
+}
+
+//NewSweeper returns a sweeper removing the expired holds from the database
+func NewSweeper(app *config.AppConfig, db repository.DatabaseRepository) *Sweeper {
+	return &Sweeper{
+		App: app,
+		DB:  db,
+	}
+}
+
+//Start sweeps the expired holds every interval in the background
+func (s *Sweeper) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			released, err := s.Run(now)
+			if err != nil {
+				s.App.ErrorLog.Println("holds:", err)
+			}
+			if released > 0 {
+				s.App.InfoLog.Printf("holds: %d expired holds released", released)
+			}
+		}
+	}()
+}
+
+//Run removes the holds which expired before now and returns how many were removed
+func (s *Sweeper) Run(now time.Time) (int, error) {
+	return s.DB.DeleteExpiredHolds(now)
+}
+
+//ExpiresAt returns when a hold placed now runs out
+func ExpiresAt(now time.Time) time.Time {
+	return now.Add(Duration)
+}
@@ -0,0 +1,52 @@
+package holds
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/config"
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+	"github.com/dev-ayaa/resvbooking/repository"
+)
+
+//fakeDB keeps the holds in memory
+type fakeDB struct {
+	repository.DatabaseRepository
+	holds []models.RoomRestriction
+}
+
+func (f *fakeDB) DeleteExpiredHolds(now time.Time) (int, error) {
+	var kept []models.RoomRestriction
+	for _, hold := range f.holds {
+		if hold.ExpiresAt.After(now) {
+			kept = append(kept, hold)
+		}
+	}
+	removed := len(f.holds) - len(kept)
+	f.holds = kept
+	return removed, nil
+}
+
+func TestSweeper_Run(t *testing.T) {
+	now := time.Date(2030, 1, 10, 12, 0, 0, 0, time.UTC)
+	db := &fakeDB{holds: []models.RoomRestriction{
+		{ID: 1, RoomID: 1, RestrictionID: models.HoldRestrictionID, ExpiresAt: now.Add(-time.Minute)},
+		{ID: 2, RoomID: 2, RestrictionID: models.HoldRestrictionID, ExpiresAt: now},
+		{ID: 3, RoomID: 1, RestrictionID: models.HoldRestrictionID, ExpiresAt: ExpiresAt(now)},
+	}}
+	s := NewSweeper(&config.AppConfig{}, db)
+
+	released, err := s.Run(now)
+	if err != nil {
+		t.Fatalf("Error Testing the holds sweep: %v", err)
+	}
+	if released != 2 {
+		t.Errorf("Error Testing the holds sweep expected 2 released holds got %d", released)
+	}
+	if len(db.holds) != 1 || db.holds[0].ID != 3 {
+		t.Errorf("Error Testing the holds sweep, wrong holds left %+v", db.holds)
+	}
+	if !ExpiresAt(now).Equal(now.Add(Duration)) {
+		t.Errorf("Error Testing the hold expiry got %v", ExpiresAt(now))
+	}
+}
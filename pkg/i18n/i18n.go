@@ -0,0 +1,155 @@
+package i18n
+
+import (
+	"embed"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//DefaultLocale the locale the messages are written in, it needs no catalogue
+const DefaultLocale = "en"
+
+//files the catalogues of the messages, one file per locale named after it, fr.json
+//go:embed locales/*.json
+var files embed.FS
+
+//catalogues the translations of the messages by locale, a catalogue maps the english message to its translation
+var catalogues = mustLoad()
+
+//mustLoad reads the embedded catalogues, a broken file is a mistake of the build
+func mustLoad() map[string]map[string]string {
+	entries, err := files.ReadDir("locales")
+	if err != nil {
+		panic(err)
+	}
+	loaded := make(map[string]map[string]string)
+	for _, entry := range entries {
+		content, err := files.ReadFile(path.Join("locales", entry.Name()))
+		if err != nil {
+			panic(err)
+		}
+		messages := make(map[string]string)
+		if err := json.Unmarshal(content, &messages); err != nil {
+			panic(fmt.Sprintf("cannot read the catalogue %s: %v", entry.Name(), err))
+		}
+		loaded[strings.TrimSuffix(entry.Name(), ".json")] = messages
+	}
+	return loaded
+}
+
+//Locales returns the locales the pages can be shown in, the default one first
+func Locales() []string {
+	locales := []string{DefaultLocale}
+	for locale := range catalogues {
+		if locale != DefaultLocale {
+			locales = append(locales, locale)
+		}
+	}
+	sort.Strings(locales[1:])
+	return locales
+}
+
+//Supported returns true when the pages can be shown in the locale
+func Supported(locale string) bool {
+	_, ok := catalogues[locale]
+	return locale == DefaultLocale || ok
+}
+
+//Translate returns the message in the locale, formatted with the arguments when there are some.
+//A message missing from the catalogue of the locale is kept in english
+func Translate(locale, message string, args ...interface{}) string {
+	if translated, ok := catalogues[locale][message]; ok && translated != "" {
+		message = translated
+	}
+	if len(args) > 0 {
+		return fmt.Sprintf(message, args...)
+	}
+	return message
+}
+
+//Message a message of the catalogue kept with its arguments until the locale of the guest is known,
+//it reads in english as an error
+type Message struct {
+	Text string
+	Args []interface{}
+}
+
+//NewMessage returns the message of the catalogue with the arguments it is formatted with
+func NewMessage(text string, args ...interface{}) Message {
+	return Message{Text: text, Args: args}
+}
+
+//Error returns the message in english
+func (m Message) Error() string {
+	return Translate(DefaultLocale, m.Text, m.Args...)
+}
+
+//In returns the message in the locale
+func (m Message) In(locale string) string {
+	return Translate(locale, m.Text, m.Args...)
+}
+
+//TranslateError returns the error in the locale, the message of an error which isn't a Message is looked up
+//in the catalogue as it is
+func TranslateError(locale string, err error) string {
+	var message Message
+	if errors.As(err, &message) {
+		return message.In(locale)
+	}
+	return Translate(locale, err.Error())
+}
+
+//Negotiate returns the supported locale the browser prefers from its Accept-Language header,
+//"fr-CH, fr;q=0.9, en;q=0.8". The regional tags match their language and no match is the default locale
+func Negotiate(acceptLanguage string) string {
+	type preference struct {
+		language string
+		quality  float64
+	}
+	var preferences []preference
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		fields := strings.Split(strings.TrimSpace(part), ";")
+		tag := strings.ToLower(strings.TrimSpace(fields[0]))
+		if tag == "" || tag == "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range fields[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				q, err := strconv.ParseFloat(strings.TrimPrefix(param, "q="), 64)
+				if err != nil {
+					q = 0
+				}
+				quality = q
+			}
+		}
+		if quality <= 0 {
+			continue
+		}
+		language := strings.SplitN(tag, "-", 2)[0]
+		preferences = append(preferences, preference{language: language, quality: quality})
+	}
+	sort.SliceStable(preferences, func(i, j int) bool {
+		return preferences[i].quality > preferences[j].quality
+	})
+	for _, p := range preferences {
+		if Supported(p.language) {
+			return p.language
+		}
+	}
+	return DefaultLocale
+}
+
+//Resolve returns the locale chosen by the guest when it is supported, else the one negotiated with the browser
+func Resolve(choice, acceptLanguage string) string {
+	if choice != "" && Supported(choice) {
+		return choice
+	}
+	return Negotiate(acceptLanguage)
+}
@@ -0,0 +1,98 @@
+package i18n
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestLocales(t *testing.T) {
+	if locales := Locales(); strings.Join(locales, ",") != "en,es,fr" {
+		t.Errorf("Error Testing the locales got %v", locales)
+	}
+	if !Supported("en") || !Supported("fr") || Supported("de") || Supported("") {
+		t.Error("Error Testing the supported locales")
+	}
+}
+
+func TestTranslate(t *testing.T) {
+	for _, m := range []struct {
+		locale  string
+		message string
+		args    []interface{}
+		want    string
+	}{
+		{"fr", "Invalid Email Address", nil, "Adresse e-mail invalide"},
+		{"es", "This field must have at least %d character long", []interface{}{3}, "Este campo debe tener al menos 3 caracteres"},
+		{"en", "This field must have at least %d character long", []interface{}{3}, "This field must have at least 3 character long"},
+		{"fr", "A message nobody translated", nil, "A message nobody translated"},
+		{"de", "Invalid Email Address", nil, "Invalid Email Address"},
+	} {
+		if got := Translate(m.locale, m.message, m.args...); got != m.want {
+			t.Errorf("Error Testing the translation of %q in %s got %q wanted %q", m.message, m.locale, got, m.want)
+		}
+	}
+}
+
+func TestTranslateError(t *testing.T) {
+	for _, m := range []struct {
+		locale string
+		err    error
+		want   string
+	}{
+		{"fr", NewMessage("unknown room %d", 4), "chambre 4 inconnue"},
+		{"en", NewMessage("unknown room %d", 4), "unknown room 4"},
+		{"es", fmt.Errorf("cannot book: %w", NewMessage("the card was declined")), "la tarjeta fue rechazada"},
+		{"fr", errors.New("Invalid Email Address"), "Adresse e-mail invalide"},
+		{"fr", errors.New("connection refused"), "connection refused"},
+	} {
+		if got := TranslateError(m.locale, m.err); got != m.want {
+			t.Errorf("Error Testing the translation of the error %q in %s got %q wanted %q", m.err, m.locale, got, m.want)
+		}
+	}
+}
+
+//TestCatalogues the translations keep the formatting verbs of the english message in the same order
+func TestCatalogues(t *testing.T) {
+	verbs := regexp.MustCompile(`%[a-z]`)
+	for locale, messages := range catalogues {
+		for message, translated := range messages {
+			if strings.Join(verbs.FindAllString(message, -1), "") != strings.Join(verbs.FindAllString(translated, -1), "") {
+				t.Errorf("Error Testing the %s catalogue, %q doesn't keep the verbs of %q", locale, translated, message)
+			}
+		}
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	for _, m := range []struct {
+		header string
+		want   string
+	}{
+		{"", "en"},
+		{"fr-CH, fr;q=0.9, en;q=0.8, *;q=0.5", "fr"},
+		{"de-DE, es;q=0.7, fr;q=0.3", "es"},
+		{"en;q=0.5, es;q=0.9", "es"},
+		{"fr;q=0, de", "en"},
+		{"ES-es", "es"},
+		{"*", "en"},
+	} {
+		if got := Negotiate(m.header); got != m.want {
+			t.Errorf("Error Testing the negotiation of %q got %s wanted %s", m.header, got, m.want)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	if got := Resolve("es", "fr"); got != "es" {
+		t.Errorf("Error Testing the chosen locale got %s", got)
+	}
+	if got := Resolve("de", "fr"); got != "fr" {
+		t.Errorf("Error Testing an unsupported choice got %s", got)
+	}
+	if got := Resolve("", ""); got != DefaultLocale {
+		t.Errorf("Error Testing no locale got %s", got)
+	}
+}
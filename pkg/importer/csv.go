@@ -0,0 +1,144 @@
+package importer
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/dev-ayaa/resvbooking/pkg/forms"
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+//Columns the header expected on the first line of an imported reservation file
+var Columns = []string{"first-name", "last-name", "email", "phone-number", "check-in", "check-out", "room_id"}
+
+//OptionalColumns the columns which may be left out of the file, one adult and no child are imported then
+var OptionalColumns = []string{"adults", "children"}
+
+//Row a single line of the imported file together with its validation result
+type Row struct {
+	Line        int
+	Reservation models.Reservation
+	Form        *forms.Form
+	Errors      []string
+}
+
+//Valid returns true when the row passed every validation rule
+func (r *Row) Valid() bool {
+	return r.Form.FormValid() && len(r.Errors) == 0
+}
+
+//AddError records an error for the row which is not tied to a form field
+func (r *Row) AddError(message string) {
+	r.Errors = append(r.Errors, message)
+}
+
+//ParseReservationCSV reads the reservations in the uploaded file and validates every row
+//with the same forms rules used by the make-reservation page
+func ParseReservationCSV(rd io.Reader) ([]Row, error) {
+	var rows []Row
+
+	reader := csv.NewReader(rd)
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err == io.EOF {
+		return rows, fmt.Errorf("the uploaded file is empty")
+	}
+	if err != nil {
+		return rows, err
+	}
+
+	position := make(map[string]int)
+	for i, col := range header {
+		position[strings.ToLower(strings.TrimSpace(col))] = i
+	}
+	for _, col := range Columns {
+		if _, ok := position[col]; !ok {
+			return rows, fmt.Errorf("missing column %q in the file header", col)
+		}
+	}
+
+	reader.FieldsPerRecord = len(header)
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			return rows, fmt.Errorf("line %d: %v", line, err)
+		}
+
+		values := url.Values{}
+		for _, col := range Columns {
+			values.Set(col, strings.TrimSpace(record[position[col]]))
+		}
+		for _, col := range OptionalColumns {
+			if i, ok := position[col]; ok {
+				values.Set(col, strings.TrimSpace(record[i]))
+			}
+		}
+		rows = append(rows, validateRow(line, values))
+	}
+	return rows, nil
+}
+
+func validateRow(line int, values url.Values) Row {
+	row := Row{
+		Line: line,
+		Form: forms.NewForm(values),
+	}
+
+	row.Form.Require(Columns...)
+	row.Form.ValidLenCharacter("first-name", 3)
+	row.Form.ValidLenCharacter("last-name", 3)
+	row.Form.ValidEmail("email")
+
+	resv := models.Reservation{
+		FirstName:   values.Get("first-name"),
+		LastName:    values.Get("last-name"),
+		Email:       values.Get("email"),
+		PhoneNumber: values.Get("phone-number"),
+	}
+
+	checkInDate := row.Form.Date("check-in")
+	checkOutDate := row.Form.Date("check-out")
+	if !checkInDate.IsZero() && !checkOutDate.IsZero() && !checkOutDate.After(checkInDate) {
+		row.Form.Error.Set("check-out", "Check-out date must be after the check-in date")
+	}
+
+	roomID, err := strconv.Atoi(values.Get("room_id"))
+	if err != nil || roomID <= 0 {
+		row.Form.Error.Set("room_id", "Invalid room id")
+	}
+
+	resv.Adults, resv.Children = 1, 0
+	if value := values.Get("adults"); value != "" {
+		resv.Adults, err = strconv.Atoi(value)
+		if err != nil || resv.Adults < 1 {
+			row.Form.Error.Set("adults", "Invalid number of adults")
+		}
+	}
+	if value := values.Get("children"); value != "" {
+		resv.Children, err = strconv.Atoi(value)
+		if err != nil || resv.Children < 0 {
+			row.Form.Error.Set("children", "Invalid number of children")
+		}
+	}
+
+	resv.CheckInDate = checkInDate
+	resv.CheckOutDate = checkOutDate
+	resv.RoomID = roomID
+	row.Reservation = resv
+	return row
+}
+
+//Overlaps returns true when two reservations occupy the same room on at least one night
+func Overlaps(a, b models.Reservation) bool {
+	return a.RoomID == b.RoomID && a.CheckInDate.Before(b.CheckOutDate) && b.CheckInDate.Before(a.CheckOutDate)
+}
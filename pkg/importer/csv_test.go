@@ -0,0 +1,135 @@
+package importer
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/dates"
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+var csvTests = []struct {
+	testName     string
+	content      string
+	correctRows  int
+	correctValid int
+	isError      bool
+}{
+	{
+		testName: "valid rows",
+		content: "first-name,last-name,email,phone-number,check-in,check-out,room_id\n" +
+			"Graham,Graham,Grahams@gmail.com,20229028844,2022-09-09,2022-09-10,1\n" +
+			"Yusuf,Akinleye,dev-ayaa007@admin.com,09088765312,2022-09-11,2022-09-15,2\n",
+		correctRows:  2,
+		correctValid: 2,
+	},
+	{
+		testName: "columns in any order",
+		content: "room_id,check-out,check-in,phone-number,email,last-name,first-name\n" +
+			"1,2022-09-10,2022-09-09,20229028844,Grahams@gmail.com,Graham,Graham\n",
+		correctRows:  1,
+		correctValid: 1,
+	},
+	{
+		testName: "invalid rows",
+		content: "first-name,last-name,email,phone-number,check-in,check-out,room_id\n" +
+			"G,Graham,Grahams@gmail.com,20229028844,2022-09-09,2022-09-10,1\n" +
+			"Graham,Graham,invalid,20229028844,2022-09-09,2022-09-10,1\n" +
+			"Graham,Graham,Grahams@gmail.com,20229028844,invalid,2022-09-10,1\n" +
+			"Graham,Graham,Grahams@gmail.com,20229028844,2022-09-10,2022-09-09,1\n" +
+			"Graham,Graham,Grahams@gmail.com,20229028844,2022-09-09,2022-09-10,slim\n" +
+			"Graham,Graham,Grahams@gmail.com,,2022-09-09,2022-09-10,1\n",
+		correctRows:  6,
+		correctValid: 0,
+	},
+	{
+		testName: "optional guest columns",
+		content: "first-name,last-name,email,phone-number,check-in,check-out,room_id,adults,children\n" +
+			"Graham,Graham,Grahams@gmail.com,20229028844,2022-09-09,2022-09-10,1,2,1\n" +
+			"Graham,Graham,Grahams@gmail.com,20229028844,2022-09-09,2022-09-10,1,0,1\n" +
+			"Graham,Graham,Grahams@gmail.com,20229028844,2022-09-09,2022-09-10,1,2,slim\n",
+		correctRows:  3,
+		correctValid: 1,
+	},
+	{
+		testName: "missing column",
+		content:  "first-name,last-name,email,check-in,check-out,room_id\n",
+		isError:  true,
+	},
+	{
+		testName: "empty file",
+		content:  "",
+		isError:  true,
+	},
+	{
+		testName: "wrong number of fields",
+		content: "first-name,last-name,email,phone-number,check-in,check-out,room_id\n" +
+			"Graham,Graham,Grahams@gmail.com\n",
+		isError: true,
+	},
+}
+
+func TestParseReservationCSV(t *testing.T) {
+	for _, c := range csvTests {
+		rows, err := ParseReservationCSV(strings.NewReader(c.content))
+		if c.isError {
+			if err == nil {
+				t.Errorf("Error Testing %s: expected an error but got none", c.testName)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Error Testing %s: %v", c.testName, err)
+			continue
+		}
+		if len(rows) != c.correctRows {
+			t.Errorf("Error Testing %s: got %d rows wanted %d", c.testName, len(rows), c.correctRows)
+		}
+		valid := 0
+		for _, r := range rows {
+			if r.Valid() {
+				valid++
+			}
+		}
+		if valid != c.correctValid {
+			t.Errorf("Error Testing %s: got %d valid rows wanted %d", c.testName, valid, c.correctValid)
+		}
+	}
+}
+
+func TestParseReservationCSV_Reservation(t *testing.T) {
+	content := "first-name,last-name,email,phone-number,check-in,check-out,room_id\n" +
+		"Graham,Graham,Grahams@gmail.com,20229028844,2022-09-09,2022-09-10,2\n"
+	rows, err := ParseReservationCSV(strings.NewReader(content))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resv := rows[0].Reservation
+	if resv.RoomID != 2 || resv.Email != "Grahams@gmail.com" || resv.CheckInDate.Format(dates.Layout) != "2022-09-09" {
+		t.Errorf("Error Testing the parsed reservation got %+v", resv)
+	}
+	if resv.Adults != 1 || resv.Children != 0 {
+		t.Errorf("Error Testing the default guests got %d adults and %d children", resv.Adults, resv.Children)
+	}
+	if rows[0].Line != 2 {
+		t.Errorf("Error Testing the row line number got %d wanted 2", rows[0].Line)
+	}
+}
+
+func TestOverlaps(t *testing.T) {
+	day := func(d int) time.Time {
+		return time.Date(2022, 9, d, 0, 0, 0, 0, time.UTC)
+	}
+	a := models.Reservation{RoomID: 1, CheckInDate: day(9), CheckOutDate: day(12)}
+
+	if !Overlaps(a, models.Reservation{RoomID: 1, CheckInDate: day(11), CheckOutDate: day(13)}) {
+		t.Error("Error Testing overlapping reservations for the same room")
+	}
+	if Overlaps(a, models.Reservation{RoomID: 1, CheckInDate: day(12), CheckOutDate: day(13)}) {
+		t.Error("Error Testing a check-in on the check-out day should not overlap")
+	}
+	if Overlaps(a, models.Reservation{RoomID: 2, CheckInDate: day(9), CheckOutDate: day(12)}) {
+		t.Error("Error Testing reservations of different rooms should not overlap")
+	}
+}
@@ -0,0 +1,196 @@
+package invoice
+
+import (
+	"fmt"
+
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+	"github.com/dev-ayaa/resvbooking/pkg/payments"
+)
+
+//Seller the name printed at the top of the invoices
+const Seller = "Rest Tavern Inn"
+
+//Line a charge of the folio, the amounts are in cents
+type Line struct {
+	Description string
+	Quantity    int
+	UnitAmount  int
+	Amount      int
+}
+
+//Folio the charges and payments of a reservation printed on its invoice
+type Folio struct {
+	Invoice     models.Invoice
+	Reservation models.Reservation
+	Currency    string
+	Lines       []Line
+	//Taxes the taxes and fees charged on top of the lines
+	Taxes    []Line
+	Payments []models.Payment
+}
+
+//NewFolio returns the folio of the reservation, the charges kept with the reservation are printed as they
+//were booked. The stay of a cancelled reservation is replaced by the part of the payments kept under the
+//cancellation policy
+func NewFolio(inv models.Invoice, resv models.Reservation, room models.Room, resvPayments []models.Payment) Folio {
+	f := Folio{
+		Invoice:     inv,
+		Reservation: resv,
+		Currency:    payments.Currency,
+	}
+	f.Reservation.Room.RoomName = room.RoomName
+	for _, payment := range resvPayments {
+		if payment.Status == models.PaymentSucceeded {
+			f.Payments = append(f.Payments, payment)
+		}
+	}
+
+	if resv.IsCancelled() {
+		kept := payments.Paid(f.Payments) - resv.RefundAmount
+		f.Lines = append(f.Lines, Line{
+			Description: fmt.Sprintf("Cancellation of the stay in the %s on %s", room.RoomName, resv.CancelledAt.Format("2006-01-02")),
+			Quantity:    1,
+			UnitAmount:  kept,
+			Amount:      kept,
+		})
+		return f
+	}
+	//the price kept when the stay was booked, the reservations booked before it was kept are charged at
+	//the rate of the room
+	if len(resv.Charges) > 0 {
+		for _, charge := range resv.Charges {
+			line := Line{Description: charge.Name, Quantity: charge.Quantity, UnitAmount: charge.Amount, Amount: charge.Amount}
+			if charge.Quantity > 0 && charge.Amount%charge.Quantity == 0 {
+				line.UnitAmount = charge.Amount / charge.Quantity
+			} else {
+				line.Quantity = 1
+			}
+			if charge.Kind == models.ChargeRoom {
+				f.Lines = append(f.Lines, line)
+			} else {
+				f.Taxes = append(f.Taxes, line)
+			}
+		}
+		return f
+	}
+	for d := resv.CheckInDate; d.Before(resv.CheckOutDate); d = d.AddDate(0, 0, 1) {
+		f.Lines = append(f.Lines, Line{
+			Description: fmt.Sprintf("%s, night of %s", room.RoomName, d.Format("2006-01-02")),
+			Quantity:    1,
+			UnitAmount:  room.NightlyRate,
+			Amount:      room.NightlyRate,
+		})
+	}
+	return f
+}
+
+//Charges returns the lines followed by the taxes
+func (f Folio) Charges() []Line {
+	var charges []Line
+	charges = append(charges, f.Lines...)
+	return append(charges, f.Taxes...)
+}
+
+//Total returns the charges of the folio with their taxes
+func (f Folio) Total() int {
+	total := 0
+	for _, line := range f.Charges() {
+		total += line.Amount
+	}
+	return total
+}
+
+//Paid returns the amount paid for the reservation
+func (f Folio) Paid() int {
+	return payments.Paid(f.Payments)
+}
+
+//Balance returns what the guest still owes, the refund of a cancelled reservation settles it
+func (f Folio) Balance() int {
+	return f.Total() - f.Paid() + f.Reservation.RefundAmount
+}
+
+//FileName returns the name the invoice is downloaded and attached as
+func (f Folio) FileName() string {
+	return f.Invoice.Code() + ".pdf"
+}
+
+//the columns of the table of the charges
+const (
+	left     = 50.0
+	right    = PageWidth - 50
+	quantity = 360.0
+	unit     = 450.0
+	bottom   = PageHeight - 60
+)
+
+//PDF returns the invoice of the folio
+func (f Folio) PDF() []byte {
+	d := NewDocument()
+	resv := f.Reservation
+
+	d.Text(left, 70, 20, true, Seller)
+	d.TextRight(right, 70, 14, true, "Invoice "+f.Invoice.Code())
+	d.TextRight(right, 88, 10, false, "Issued on "+f.Invoice.IssuedAt.Format("2006-01-02"))
+
+	d.Text(left, 120, 10, true, "Billed to")
+	d.Text(left, 135, 10, false, resv.FirstName+" "+resv.LastName)
+	d.Text(left, 149, 10, false, resv.Email)
+	d.Text(320, 120, 10, true, fmt.Sprintf("Reservation %d", resv.ID))
+	d.Text(320, 135, 10, false, "Room: "+resv.Room.RoomName)
+	d.Text(320, 149, 10, false, fmt.Sprintf("Stay: %s to %s, %d nights", resv.CheckInDate.Format("2006-01-02"),
+		resv.CheckOutDate.Format("2006-01-02"), resv.Nights()))
+
+	y := 190.0
+	header := func() {
+		d.Text(left, y, 10, true, "Description")
+		d.TextRight(quantity, y, 10, true, "Qty")
+		d.TextRight(unit, y, 10, true, "Unit")
+		d.TextRight(right, y, 10, true, "Amount ("+f.Currency+")")
+		d.Rule(left, right, y+6)
+		y += 22
+	}
+	//next moves to the following row, on a new page when the page is full
+	next := func(height float64) {
+		y += height
+		if y > bottom {
+			d.AddPage()
+			y = 70
+			header()
+		}
+	}
+
+	header()
+	for _, line := range f.Charges() {
+		d.Text(left, y, 10, false, line.Description)
+		d.TextRight(quantity, y, 10, false, fmt.Sprint(line.Quantity))
+		d.TextRight(unit, y, 10, false, models.FormatAmount(line.UnitAmount))
+		d.TextRight(right, y, 10, false, models.FormatAmount(line.Amount))
+		next(16)
+	}
+	d.Rule(unit-60, right, y-10)
+	d.Text(unit-60, y, 10, true, "Total")
+	d.TextRight(right, y, 10, true, models.FormatAmount(f.Total()))
+	next(28)
+
+	if len(f.Payments) > 0 {
+		d.Text(left, y, 10, true, "Payments")
+		next(16)
+		for _, payment := range f.Payments {
+			d.Text(left, y, 10, false, fmt.Sprintf("%s %s, %s %s", payment.CreatedAt.Format("2006-01-02"), payment.Kind,
+				payment.Provider, payment.Reference))
+			d.TextRight(right, y, 10, false, "-"+models.FormatAmount(payment.Amount))
+			next(16)
+		}
+	}
+	if resv.RefundAmount > 0 {
+		d.Text(left, y, 10, false, "Refund of the cancellation")
+		d.TextRight(right, y, 10, false, models.FormatAmount(resv.RefundAmount))
+		next(16)
+	}
+	d.Rule(unit-60, right, y-10)
+	d.Text(unit-60, y, 10, true, "Balance due")
+	d.TextRight(right, y, 10, true, models.FormatAmount(f.Balance()))
+
+	return d.Bytes()
+}
@@ -0,0 +1,103 @@
+package invoice
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+func testFolio(nights int) Folio {
+	checkIn := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	resv := models.Reservation{ID: 7, FirstName: "Graham", LastName: "Graham", Email: "graham@example.com",
+		CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, nights)}
+	room := models.Room{ID: 1, RoomName: "Deluxe suite", NightlyRate: 12050}
+	return NewFolio(models.Invoice{Number: 42, ReservationID: 7, IssuedAt: checkIn}, resv, room, []models.Payment{
+		{Kind: models.PaymentDeposit, Provider: "fake", Reference: "fake_1", Amount: 10845, Status: models.PaymentSucceeded},
+		{Kind: models.PaymentDeposit, Provider: "fake", Reference: "fake_2", Amount: 10845, Status: models.PaymentFailed},
+	})
+}
+
+func TestFolio(t *testing.T) {
+	f := testFolio(3)
+	if len(f.Lines) != 3 || f.Lines[0].Description != "Deluxe suite, night of 2030-01-10" {
+		t.Errorf("Error Testing the folio lines got %+v", f.Lines)
+	}
+	if f.Total() != 36150 || f.Paid() != 10845 || f.Balance() != 25305 {
+		t.Errorf("Error Testing the folio amounts got total %d paid %d balance %d", f.Total(), f.Paid(), f.Balance())
+	}
+	if f.FileName() != "INV-000042.pdf" {
+		t.Errorf("Error Testing the invoice file name got %s", f.FileName())
+	}
+
+	cancelled := testFolio(3)
+	cancelled.Reservation.CancelledAt = time.Date(2030, 1, 9, 0, 0, 0, 0, time.UTC)
+	cancelled.Reservation.RefundAmount = 845
+	cancelled = NewFolio(cancelled.Invoice, cancelled.Reservation, models.Room{RoomName: "Deluxe suite"}, cancelled.Payments)
+	if len(cancelled.Lines) != 1 || cancelled.Total() != 10000 || cancelled.Balance() != 0 {
+		t.Errorf("Error Testing the cancelled folio got %+v balance %d", cancelled.Lines, cancelled.Balance())
+	}
+}
+
+func TestFolio_charges(t *testing.T) {
+	f := testFolio(2)
+	f.Reservation.Charges = []models.ReservationCharge{
+		{Name: "Deluxe suite, 2 nights", Kind: models.ChargeRoom, Quantity: 2, Amount: 24000},
+		{Name: "City tax", Kind: models.ChargeTax, Quantity: 2, Amount: 500},
+		{Name: "VAT", Kind: models.ChargeTax, Quantity: 3, Amount: 2401},
+		{Name: "Cleaning", Kind: models.ChargeFee, Quantity: 1, Amount: 3000},
+	}
+	f = NewFolio(f.Invoice, f.Reservation, models.Room{RoomName: "Deluxe suite", NightlyRate: 99999}, f.Payments)
+	if len(f.Lines) != 1 || f.Lines[0].UnitAmount != 12000 || len(f.Taxes) != 3 {
+		t.Fatalf("Error Testing the folio of the kept charges got %+v and %+v", f.Lines, f.Taxes)
+	}
+	//an amount which doesn't split evenly is charged once
+	if f.Taxes[1].Quantity != 1 || f.Taxes[1].UnitAmount != 2401 {
+		t.Errorf("Error Testing the uneven charge got %+v", f.Taxes[1])
+	}
+	if f.Total() != 29901 {
+		t.Errorf("Error Testing the total of the kept charges got %d", f.Total())
+	}
+}
+
+func TestFolio_PDF(t *testing.T) {
+	for _, nights := range []int{2, 60} {
+		pdf := testFolio(nights).PDF()
+		if !bytes.HasPrefix(pdf, []byte("%PDF-1.4\n")) || !bytes.HasSuffix(pdf, []byte("%%EOF\n")) {
+			t.Fatalf("Error Testing the invoice of %d nights, not a PDF file", nights)
+		}
+		for _, want := range []string{"(Invoice INV-000042)", "(Graham Graham)", "(-108.45)"} {
+			if !bytes.Contains(pdf, []byte(want)) {
+				t.Errorf("Error Testing the invoice of %d nights, %s missing", nights, want)
+			}
+		}
+
+		//the cross reference table points at the objects
+		startxref := regexp.MustCompile(`startxref\n(\d+)\n`).FindSubmatch(pdf)
+		xref, _ := strconv.Atoi(string(startxref[1]))
+		if !bytes.HasPrefix(pdf[xref:], []byte("xref\n")) {
+			t.Fatalf("Error Testing the invoice of %d nights, startxref points at %q", nights, pdf[xref:xref+10])
+		}
+		offsets := regexp.MustCompile(`(\d{10}) 00000 n`).FindAllSubmatch(pdf, -1)
+		for i, offset := range offsets {
+			at, _ := strconv.Atoi(string(offset[1]))
+			if !bytes.HasPrefix(pdf[at:], []byte(fmt.Sprintf("%d 0 obj", i+1))) {
+				t.Errorf("Error Testing the invoice of %d nights, object %d isn't at %d", nights, i+1, at)
+			}
+		}
+		pages := bytes.Count(pdf, []byte("/Type /Page "))
+		if (nights == 60) != (pages > 1) {
+			t.Errorf("Error Testing the invoice of %d nights got %d pages", nights, pages)
+		}
+	}
+}
+
+func TestEscape(t *testing.T) {
+	if got := escape(`Café (room) \ 日`); got != `Caf\351 \(room\) \\ ?` {
+		t.Errorf("Error Testing the PDF string escape got %s", got)
+	}
+}
@@ -1,16 +1,21 @@
 package models
 
-import "time"
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
 
 //used to store models in the Database
 
-//Reservation reservation data
+//Reservation reservation data, the guest details are bound from the reservation forms
 type Reservation struct {
 	ID          int
-	FirstName   string
-	LastName    string
-	Email       string
-	PhoneNumber string
+	FirstName   string `form:"first-name" rules:"required,min=3,max=255"`
+	LastName    string `form:"last-name" rules:"required,min=3,max=255"`
+	Email       string `form:"email" rules:"required,email,max=255"`
+	PhoneNumber string `form:"phone-number" rules:"required,phone"`
 	//Password        string
 	//ConfirmPassword string
 	RoomID       int
@@ -20,14 +25,124 @@ type Reservation struct {
 	CreatedAt    time.Time
 	UpdatedAt    time.Time
 	Processed    int
+	//BookingGroupID the parent booking when several rooms were reserved together
+	BookingGroupID int
+	Adults         int
+	Children       int
+	//CancelledAt when the reservation was cancelled, zero while it stands
+	CancelledAt time.Time
+	//RefundAmount what is given back of the paid amount on cancellation, in cents
+	RefundAmount int
+	//Charges the price of the stay, the room nights followed by the taxes and fees
+	Charges []ReservationCharge
+}
+
+//IsCancelled returns true when the reservation was cancelled
+func (r Reservation) IsCancelled() bool {
+	return !r.CancelledAt.IsZero()
+}
+
+//Guests the number of adults and children staying
+func (r Reservation) Guests() int {
+	return r.Adults + r.Children
+}
+
+//Nights the number of nights of the stay
+func (r Reservation) Nights() int {
+	return int(r.CheckOutDate.Sub(r.CheckInDate).Hours() / 24)
+}
+
+//BookingGroup several rooms reserved together by a guest with a single confirmation
+type BookingGroup struct {
+	ID           int
+	Email        string
+	Reservations []Reservation
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
 }
 
 //Room rooms model
 type Room struct {
-	ID        int
-	RoomName  string
-	CreatedAt time.Time
-	UpdatedAt time.Time
+	ID          int
+	RoomName    string
+	Slug        string
+	Description string
+	Amenities   string
+	MaxAdults   int
+	MaxChildren int
+	//NightlyRate the price of a night in cents
+	NightlyRate  int
+	Cancellation CancellationPolicy
+	Photos       []RoomPhoto
+	CreatedAt    time.Time
+	UpdatedAt    time.Time
+}
+
+//AmenityList returns the amenities of the room, one per line in the database
+func (r Room) AmenityList() []string {
+	var amenities []string
+	for _, line := range strings.Split(r.Amenities, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			amenities = append(amenities, line)
+		}
+	}
+	return amenities
+}
+
+//Cover returns the photo shown first for the room, the zero photo when it has none
+func (r Room) Cover() RoomPhoto {
+	if len(r.Photos) == 0 {
+		return RoomPhoto{}
+	}
+	return r.Photos[0]
+}
+
+//RoomPhoto a photo in the gallery of a room
+type RoomPhoto struct {
+	ID            int
+	RoomID        int
+	Path          string
+	ThumbnailPath string
+	Caption       string
+	Position      int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+//Thumb returns the path of the thumbnail, the photo itself for the photos added before thumbnails
+func (p RoomPhoto) Thumb() string {
+	if p.ThumbnailPath == "" {
+		return p.Path
+	}
+	return p.ThumbnailPath
+}
+
+//Fits returns true when the room can hold the guests, children may take the place of an adult
+//but not the other way round
+func (r Room) Fits(adults, children int) bool {
+	return adults <= r.MaxAdults && adults+children <= r.MaxAdults+r.MaxChildren
+}
+
+//BookingRule the conditions a stay must meet to book a room, the rule applies from the start date to
+//the end date included. A zero number of nights or days means no limit
+type BookingRule struct {
+	ID                int
+	RoomID            int
+	StartDate         time.Time
+	EndDate           time.Time
+	MinNights         int
+	MaxNights         int
+	ClosedToArrival   bool
+	ClosedToDeparture bool
+	LeadDays          int
+	MaxAdvanceDays    int
+	CreatedAt         time.Time
+	UpdatedAt         time.Time
+}
+
+//Covers returns true when the date is in the date range of the rule
+func (br BookingRule) Covers(date time.Time) bool {
+	return !date.Before(br.StartDate) && !date.After(br.EndDate)
 }
 
 //User user model
@@ -58,16 +173,358 @@ type RoomRestriction struct {
 	RestrictionID int
 	CheckInDate   time.Time
 	CheckOutDate  time.Time
+	Reason        string
+	Notes         string
+	ExpiresAt     time.Time
 	CreatedAt     time.Time
 	UpdatedAt     time.Time
 	Room          Room
 	Restriction   Restriction
 	Reservation   Reservation
 }
+
+//ReservationRestrictionID the restriction id of the nights of a reservation
+const ReservationRestrictionID = 1
+
+//OwnerRestrictionID the restriction id of the blocks added by the owner
+const OwnerRestrictionID = 2
+
+//HoldRestrictionID the restriction id of the rooms held for a guest filling the reservation form, a
+//hold frees the room again at ExpiresAt unless the guest books it first
+const HoldRestrictionID = 3
+
+//BlockReasons the reasons an admin can give when blocking a room
+var BlockReasons = []string{"maintenance", "owner stay", "renovation", "other"}
+
+//IsHold returns true when the restriction is a temporary hold rather than a reservation or a block
+func (r RoomRestriction) IsHold() bool {
+	return r.RestrictionID == HoldRestrictionID
+}
+
+//LastNight returns the last blocked or booked night, the check-out date being the first free one
+func (r RoomRestriction) LastNight() time.Time {
+	return r.CheckOutDate.AddDate(0, 0, -1)
+}
+
+//WaitlistEntry a guest waiting for a room to free up on sold-out dates, the room is optional. When a
+//room frees up it is held for the guest, HoldID, who is offered it through a hold link valid until HoldExpiresAt
+type WaitlistEntry struct {
+	ID            int
+	FirstName     string
+	LastName      string
+	Email         string
+	CheckInDate   time.Time
+	CheckOutDate  time.Time
+	RoomID        int
+	Adults        int
+	Children      int
+	Status        string
+	Token         string
+	OfferedRoomID int
+	HoldID        int
+	HoldExpiresAt time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+	Room          Room
+}
+
+//The states of a waitlist entry
+const (
+	WaitlistWaiting = "waiting"
+	WaitlistOffered = "offered"
+	WaitlistClaimed = "claimed"
+	WaitlistExpired = "expired"
+)
+
+//Overlaps returns true when the stay of the entry shares at least one night with the dates
+func (w WaitlistEntry) Overlaps(checkInDate, checkOutDate time.Time) bool {
+	return w.CheckInDate.Before(checkOutDate) && checkInDate.Before(w.CheckOutDate)
+}
+
 type MailData struct {
 	Sender       string
 	Receiver     string
 	MailContent  string
 	MailSubject  string
 	MailTemplate string
+	Attachments  []MailAttachment
+}
+
+//MailAttachment a file sent along with a mail
+type MailAttachment struct {
+	Name        string
+	ContentType string
+	Data        []byte
+}
+
+//RoomOccupancy the state of a room on a given night
+type RoomOccupancy struct {
+	Room          Room
+	ReservationID int
+	RestrictionID int
+	FirstName     string
+	LastName      string
+	CheckOutDate  time.Time
+}
+
+//Occupied returns true when the room is reserved or blocked for the night
+func (o RoomOccupancy) Occupied() bool {
+	return o.RestrictionID != 0
+}
+
+//OccupancyStats aggregated booking figures over a period of days
+type OccupancyStats struct {
+	Days         int
+	RoomNights   int
+	BookedNights int
+	NewBookings  int
+	AverageStay  float64
+}
+
+//OccupancyRate the percentage of the available room nights which were booked
+func (s OccupancyStats) OccupancyRate() float64 {
+	if s.RoomNights == 0 {
+		return 0
+	}
+	return float64(s.BookedNights) * 100 / float64(s.RoomNights)
+}
+
+//RoomNights the nights a room is occupied by a reservation or a block, keyed by date in the format 2006-01-02
+type RoomNights struct {
+	Room     Room
+	Occupied map[string]bool
+}
+
+//IsFree returns true when none of the nights from the check-in date up to the check-out date is occupied
+func (rn RoomNights) IsFree(checkInDate, checkOutDate time.Time) bool {
+	for d := checkInDate; d.Before(checkOutDate); d = d.AddDate(0, 0, 1) {
+		if rn.Occupied[d.Format("2006-01-02")] {
+			return false
+		}
+	}
+	return true
+}
+
+//Payment an amount charged through the payment provider for a reservation, the reference is the id
+//of the charge at the provider. A deposit for several rooms booked together is linked to the first
+//reservation and to the booking group
+type Payment struct {
+	ID             int
+	ReservationID  int
+	BookingGroupID int
+	Provider       string
+	Reference      string
+	Kind           string
+	Amount         int
+	Currency       string
+	Status         string
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+//The states of a payment, a pending payment is completed later by a webhook of the provider
+const (
+	PaymentPending   = "pending"
+	PaymentSucceeded = "succeeded"
+	PaymentFailed    = "failed"
+)
+
+//PaymentDeposit the kind of the payment taken when booking
+const PaymentDeposit = "deposit"
+
+//FormatAmount returns the amount in cents with two decimals, 12050 is 120.50
+func FormatAmount(cents int) string {
+	sign := ""
+	if cents < 0 {
+		sign = "-"
+		cents = -cents
+	}
+	return fmt.Sprintf("%s%d.%02d", sign, cents/100, cents%100)
+}
+
+//ParseAmount reads an amount with at most two decimals and returns it in cents
+func ParseAmount(value string) (int, error) {
+	value = strings.TrimSpace(value)
+	whole, fraction, found := strings.Cut(value, ".")
+	if value == "" || (found && (len(fraction) == 0 || len(fraction) > 2)) {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	for len(fraction) < 2 {
+		fraction += "0"
+	}
+	units, err := strconv.Atoi(whole)
+	if err != nil || units < 0 {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	cents, err := strconv.Atoi(fraction)
+	if err != nil || cents < 0 {
+		return 0, fmt.Errorf("invalid amount %q", value)
+	}
+	return units*100 + cents, nil
+}
+
+//CancellationPolicy the terms a reservation of a room is cancelled on, the guest cancels for free until
+//FreeDays days before the check-in, after that the penalty is kept from what was paid
+type CancellationPolicy struct {
+	FreeDays int
+	Penalty  string
+	//PenaltyPercent the part of the stay total kept by a percent penalty
+	PenaltyPercent int
+}
+
+//The penalties of a late cancellation
+const (
+	PenaltyNone       = "none"
+	PenaltyPercent    = "percent"
+	PenaltyFirstNight = "first_night"
+)
+
+//Deadline returns the first day a cancellation is no longer free
+func (p CancellationPolicy) Deadline(checkInDate time.Time) time.Time {
+	return checkInDate.AddDate(0, 0, -p.FreeDays)
+}
+
+//Terms describes the policy to the guest staying from the check-in date
+func (p CancellationPolicy) Terms(checkInDate time.Time) string {
+	deadline := p.Deadline(checkInDate).Format("2006-01-02")
+	switch p.Penalty {
+	case PenaltyPercent:
+		return fmt.Sprintf("Free cancellation before %s, after that %d%% of the stay is charged", deadline, p.PenaltyPercent)
+	case PenaltyFirstNight:
+		return fmt.Sprintf("Free cancellation before %s, after that the first night is charged", deadline)
+	}
+	return "Free cancellation up to the check-in"
+}
+
+//Invoice the numbered invoice of a reservation, the numbers follow each other without gaps
+type Invoice struct {
+	ID            int
+	Number        int
+	ReservationID int
+	IssuedAt      time.Time
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+//Code returns the number printed on the invoice
+func (i Invoice) Code() string {
+	return fmt.Sprintf("INV-%06d", i.Number)
+}
+
+//TaxRule a tax or a fee added to the price of the stays, a rule without a room applies to every room. A
+//night is charged when it falls from the start date to the end date, a rule without an end date has no
+//end and a rule charged per stay follows the check-in date
+type TaxRule struct {
+	ID     int
+	RoomID int
+	Name   string
+	Kind   string
+	Basis  string
+	Per    string
+	//Rate the percentage of a percent rule in hundredths of a percent, 1250 is 12.50%
+	Rate int
+	//Amount the amount in cents of a fixed rule
+	Amount    int
+	StartDate time.Time
+	EndDate   time.Time
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+//The kinds, bases and periods of the tax rules
+const (
+	ChargeRoom     = "room"
+	ChargeTax      = "tax"
+	ChargeFee      = "fee"
+	ChargeDiscount = "discount"
+
+	BasisPercent = "percent"
+	BasisFixed   = "fixed"
+
+	PerNight = "night"
+	PerStay  = "stay"
+)
+
+//Covers returns true when the date is in the date range of the rule
+func (r TaxRule) Covers(date time.Time) bool {
+	return !date.Before(r.StartDate) && (r.EndDate.IsZero() || !date.After(r.EndDate))
+}
+
+//AppliesTo returns true when the rule applies to the room
+func (r TaxRule) AppliesTo(roomID int) bool {
+	return r.RoomID == 0 || r.RoomID == roomID
+}
+
+//Describe returns how the rule is charged, "12.50% per night" or "30.00 per stay"
+func (r TaxRule) Describe() string {
+	if r.Basis == BasisPercent {
+		return fmt.Sprintf("%s%% per %s", FormatAmount(r.Rate), r.Per)
+	}
+	return fmt.Sprintf("%s per %s", FormatAmount(r.Amount), r.Per)
+}
+
+//ReservationCharge a line of the price of a reservation, kept as it was when the stay was booked
+type ReservationCharge struct {
+	ID            int
+	ReservationID int
+	Name          string
+	Kind          string
+	Quantity      int
+	Amount        int
+	CreatedAt     time.Time
+	UpdatedAt     time.Time
+}
+
+//PromoCode a discount taken off the price of the stays booked with the code from the start date to the end
+//date included, a code without an end date has no end. MaxRedemptions 0 doesn't limit the uses
+type PromoCode struct {
+	ID          int
+	Code        string
+	Description string
+	Basis       string
+	//Rate the discount of a percent code in hundredths of a percent, 1500 is 15.00%
+	Rate int
+	//Amount the discount in cents of a fixed code
+	Amount         int
+	MinNights      int
+	MaxRedemptions int
+	Redemptions    int
+	StartDate      time.Time
+	EndDate        time.Time
+	CreatedAt      time.Time
+	UpdatedAt      time.Time
+}
+
+//Problem returns why the code can't be used for a stay of the nights booked at the date, nothing when
+//it can be used. The reason is an english message of the catalogue followed by the arguments it is formatted with
+func (p PromoCode) Problem(nights int, bookedAt time.Time) (string, []interface{}) {
+	day := time.Date(bookedAt.Year(), bookedAt.Month(), bookedAt.Day(), 0, 0, 0, 0, time.UTC)
+	switch {
+	case day.Before(p.StartDate):
+		return "This promo code isn't valid yet", nil
+	case !p.EndDate.IsZero() && day.After(p.EndDate):
+		return "This promo code has expired", nil
+	case p.MaxRedemptions > 0 && p.Redemptions >= p.MaxRedemptions:
+		return "This promo code was used up", nil
+	case nights < p.MinNights:
+		return "This promo code needs a stay of at least %d nights", []interface{}{p.MinNights}
+	}
+	return "", nil
+}
+
+//Describe returns the discount of the code, "15.00% off" or "20.00 off"
+func (p PromoCode) Describe() string {
+	if p.Basis == BasisPercent {
+		return fmt.Sprintf("%s%% off", FormatAmount(p.Rate))
+	}
+	return fmt.Sprintf("%s off", FormatAmount(p.Amount))
+}
+
+//ExchangeRate the value of one unit of the currency of the property in another currency
+type ExchangeRate struct {
+	ID        int
+	Currency  string
+	Rate      float64
+	CreatedAt time.Time
+	UpdatedAt time.Time
 }
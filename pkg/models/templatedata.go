@@ -15,4 +15,15 @@ type TemplateData struct {
 	Error      string
 	Form       *forms.Form
 	IsAuth     int
+	//Currency the currency the guest displays the prices in, BaseCurrency the one they are charged in
+	Currency     string
+	BaseCurrency string
+	Currencies   []string
+	//Locale the language the page is written in, Locales the ones the guest can choose
+	Locale  string
+	Locales []string
+	//CheckInTime and CheckOutTime the times of the day the guests arrive from and leave by in the TimeZone of the property
+	CheckInTime  string
+	CheckOutTime string
+	TimeZone     string
 }
@@ -0,0 +1,225 @@
+package payments
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+//Currency the currency the rooms are priced and charged in when the property doesn't set one
+const Currency = "USD"
+
+//DepositPercent the part of the stay total taken when booking
+const DepositPercent = 30
+
+//SignatureHeader the header holding the signature of the body of a webhook
+const SignatureHeader = "X-Payment-Signature"
+
+//ErrBadSignature the webhook wasn't signed with the secret shared with the gateway
+var ErrBadSignature = errors.New("invalid webhook signature")
+
+//ErrNoSecret no secret is shared with the gateway, anyone could sign a webhook with the empty key
+var ErrNoSecret = errors.New("no secret is shared with the payment gateway to sign its webhooks")
+
+//StayTotal returns the price of the nights from the check-in date up to the check-out date
+func StayTotal(nightlyRate int, checkInDate, checkOutDate time.Time) int {
+	nights := models.Reservation{CheckInDate: checkInDate, CheckOutDate: checkOutDate}.Nights()
+	if nights < 0 {
+		return 0
+	}
+	return nightlyRate * nights
+}
+
+//Deposit returns the deposit taken for the stay total, rounded up to the cent
+func Deposit(total int) int {
+	return (total*DepositPercent + 99) / 100
+}
+
+//ChargeRequest the amount in cents to take from the card token collected by the booking form
+type ChargeRequest struct {
+	Amount      int
+	Currency    string
+	Source      string
+	Description string
+	//IdempotencyKey makes a retried charge return the first one instead of charging twice
+	IdempotencyKey string
+}
+
+//Result the outcome of a charge, a pending charge is settled later by a webhook
+type Result struct {
+	Reference string
+	Status    string
+}
+
+//Event a change of the status of a charge sent by the gateway
+type Event struct {
+	Reference string
+	Status    string
+}
+
+//Provider takes the payments of the guests
+type Provider interface {
+	//Name identifies the provider in the payments table
+	Name() string
+	//Charge takes the amount, the gateway may answer with a pending status
+	Charge(rq ChargeRequest) (Result, error)
+	//Webhook reads and authenticates a status change posted by the gateway
+	Webhook(rq *http.Request) (Event, error)
+}
+
+//Gateway the calls offered by the client of a payment gateway, an Adapter turns it into a Provider
+type Gateway interface {
+	//CreateCharge returns the id of the charge and its state in the words of the gateway
+	CreateCharge(amount int, currency, source, idempotencyKey string) (string, string, error)
+	//ParseEvent returns the charge id and its new state from the body of a webhook
+	ParseEvent(body []byte) (string, string, error)
+}
+
+//Adapter plugs a gateway into the booking flow, mapping its states to the payment statuses and
+//checking the signature of its webhooks. A state missing from States is pending
+type Adapter struct {
+	name    string
+	Gateway Gateway
+	Secret  string
+	States  map[string]string
+}
+
+//NewAdapter returns the provider for the gateway, the webhooks are signed with the secret
+func NewAdapter(name string, gateway Gateway, secret string, states map[string]string) *Adapter {
+	return &Adapter{
+		name:    name,
+		Gateway: gateway,
+		Secret:  secret,
+		States:  states,
+	}
+}
+
+//Name returns the name of the gateway
+func (a *Adapter) Name() string {
+	return a.name
+}
+
+//Charge asks the gateway to take the amount
+func (a *Adapter) Charge(rq ChargeRequest) (Result, error) {
+	if rq.Amount <= 0 {
+		return Result{}, fmt.Errorf("invalid amount %d", rq.Amount)
+	}
+	id, state, err := a.Gateway.CreateCharge(rq.Amount, rq.Currency, rq.Source, rq.IdempotencyKey)
+	if err != nil {
+		return Result{}, err
+	}
+	return Result{Reference: id, Status: a.status(state)}, nil
+}
+
+//Webhook checks the signature of the body before handing it to the gateway
+func (a *Adapter) Webhook(rq *http.Request) (Event, error) {
+	if a.Secret == "" {
+		return Event{}, ErrNoSecret
+	}
+	body, err := io.ReadAll(io.LimitReader(rq.Body, 64<<10))
+	if err != nil {
+		return Event{}, err
+	}
+	expected := Sign(a.Secret, body)
+	if !hmac.Equal([]byte(expected), []byte(rq.Header.Get(SignatureHeader))) {
+		return Event{}, ErrBadSignature
+	}
+	id, state, err := a.Gateway.ParseEvent(body)
+	if err != nil {
+		return Event{}, err
+	}
+	return Event{Reference: id, Status: a.status(state)}, nil
+}
+
+func (a *Adapter) status(state string) string {
+	if status, ok := a.States[state]; ok {
+		return status
+	}
+	return models.PaymentPending
+}
+
+//NewProvider returns the provider chosen in the configuration, its webhooks are signed with the secret.
+//The fake gateway takes no money, it is refused in production
+func NewProvider(name, secret string, inProduction bool) (Provider, error) {
+	if secret == "" {
+		return nil, ErrNoSecret
+	}
+	switch name {
+	case "":
+		return nil, errors.New("no payment provider is configured")
+	case "fake":
+		if inProduction {
+			return nil, errors.New("the fake payment gateway takes no money, it can't be used in production")
+		}
+		return NewFake(secret), nil
+	}
+	return nil, fmt.Errorf("unknown payment provider %q", name)
+}
+
+//Sign returns the signature of the webhook body, the hex encoded HMAC-SHA256 with the shared secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+//FakeGateway a local gateway for development and tests, no money moves. The card token decides the
+//outcome: "tok_declined" is refused, "tok_pending" is settled later by a webhook, any other token is paid
+type FakeGateway struct{}
+
+//FakeEvent the body of the webhooks of the fake gateway
+type FakeEvent struct {
+	Reference string `json:"reference"`
+	State     string `json:"state"`
+}
+
+//NewFake returns the provider of the fake gateway
+func NewFake(secret string) *Adapter {
+	return NewAdapter("fake", FakeGateway{}, secret, map[string]string{
+		"paid":     models.PaymentSucceeded,
+		"declined": models.PaymentFailed,
+		"pending":  models.PaymentPending,
+	})
+}
+
+//CreateCharge answers with the state chosen by the card token
+func (FakeGateway) CreateCharge(amount int, currency, source, idempotencyKey string) (string, string, error) {
+	if source == "" {
+		return "", "", errors.New("no card was given")
+	}
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", "", err
+	}
+	id := "fake_" + hex.EncodeToString(b)
+	switch source {
+	case "tok_declined":
+		return id, "declined", nil
+	case "tok_pending":
+		return id, "pending", nil
+	}
+	return id, "paid", nil
+}
+
+//ParseEvent reads a FakeEvent
+func (FakeGateway) ParseEvent(body []byte) (string, string, error) {
+	var event FakeEvent
+	err := json.Unmarshal(body, &event)
+	if err != nil {
+		return "", "", err
+	}
+	if event.Reference == "" {
+		return "", "", errors.New("the event has no reference")
+	}
+	return event.Reference, event.State, nil
+}
@@ -0,0 +1,183 @@
+package payments
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+func TestStayTotalAndDeposit(t *testing.T) {
+	checkIn := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	total := StayTotal(12050, checkIn, checkIn.AddDate(0, 0, 3))
+	if total != 36150 {
+		t.Errorf("Error Testing the stay total expected 36150 got %d", total)
+	}
+	if deposit := Deposit(total); deposit != 10845 {
+		t.Errorf("Error Testing the deposit expected 10845 got %d", deposit)
+	}
+	if deposit := Deposit(1001); deposit != 301 {
+		t.Errorf("Error Testing the deposit is rounded up expected 301 got %d", deposit)
+	}
+}
+
+func TestFakeCharge(t *testing.T) {
+	provider := NewFake("secret")
+	for _, m := range []struct {
+		source string
+		status string
+		fails  bool
+	}{
+		{"tok_visa", models.PaymentSucceeded, false},
+		{"tok_declined", models.PaymentFailed, false},
+		{"tok_pending", models.PaymentPending, false},
+		{"", "", true},
+	} {
+		result, err := provider.Charge(ChargeRequest{Amount: 1000, Currency: Currency, Source: m.source})
+		if (err != nil) != m.fails {
+			t.Errorf("Error Testing the charge with %q got error %v", m.source, err)
+			continue
+		}
+		if result.Status != m.status || (!m.fails && !strings.HasPrefix(result.Reference, "fake_")) {
+			t.Errorf("Error Testing the charge with %q got %+v", m.source, result)
+		}
+	}
+	if _, err := provider.Charge(ChargeRequest{Amount: 0, Source: "tok_visa"}); err == nil {
+		t.Errorf("Error Testing the charge of nothing, no error")
+	}
+}
+
+func TestFakeWebhook(t *testing.T) {
+	provider := NewFake("secret")
+	body := `{"reference":"fake_1","state":"paid"}`
+
+	rq := httptest.NewRequest("POST", "/payments/webhook", strings.NewReader(body))
+	rq.Header.Set(SignatureHeader, Sign("secret", []byte(body)))
+	event, err := provider.Webhook(rq)
+	if err != nil || event.Reference != "fake_1" || event.Status != models.PaymentSucceeded {
+		t.Errorf("Error Testing the signed webhook got %+v %v", event, err)
+	}
+
+	rq = httptest.NewRequest("POST", "/payments/webhook", strings.NewReader(body))
+	rq.Header.Set(SignatureHeader, Sign("another secret", []byte(body)))
+	if _, err = provider.Webhook(rq); err != ErrBadSignature {
+		t.Errorf("Error Testing the webhook signed with another secret got %v", err)
+	}
+}
+
+func TestNewProvider(t *testing.T) {
+	for _, m := range []struct {
+		testName     string
+		name         string
+		secret       string
+		inProduction bool
+		fails        bool
+	}{
+		{"fake-in-development", "fake", "secret", false, false},
+		{"fake-in-production", "fake", "secret", true, true},
+		{"no-secret", "fake", "", false, true},
+		{"no-provider", "", "secret", false, true},
+		{"unknown-provider", "paypal", "secret", true, true},
+	} {
+		provider, err := NewProvider(m.name, m.secret, m.inProduction)
+		if (err != nil) != m.fails || (err == nil && provider.Name() != m.name) {
+			t.Errorf("Error Testing %s for the payment provider got %v %v", m.testName, provider, err)
+		}
+	}
+
+	//a webhook signed with the empty key is refused by an adapter built without a secret
+	body := `{"reference":"fake_1","state":"paid"}`
+	rq := httptest.NewRequest("POST", "/payments/webhook", strings.NewReader(body))
+	rq.Header.Set(SignatureHeader, Sign("", []byte(body)))
+	if _, err := NewFake("").Webhook(rq); err != ErrNoSecret {
+		t.Errorf("Error Testing the webhook without a secret got %v", err)
+	}
+}
+
+func TestPenaltyAndRefund(t *testing.T) {
+	checkIn := time.Date(2030, 1, 10, 0, 0, 0, 0, time.UTC)
+	room := models.ReservationCharge{Name: "Deluxe suite, 3 nights", Kind: models.ChargeRoom, Quantity: 3, Amount: 30000}
+	resv := models.Reservation{CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 3),
+		Charges: []models.ReservationCharge{room}}
+	//the stay was booked with a tax and a promo code
+	discounted := resv
+	discounted.Charges = []models.ReservationCharge{room,
+		{Name: "VAT", Kind: models.ChargeTax, Quantity: 3, Amount: 3000},
+		{Name: "Promo code SUMMER", Kind: models.ChargeDiscount, Quantity: 1, Amount: -13000},
+	}
+	//the discount takes the stay below the price of a night
+	bargain := resv
+	bargain.Charges = []models.ReservationCharge{room,
+		{Name: "Promo code WELCOME", Kind: models.ChargeDiscount, Quantity: 1, Amount: -25000},
+	}
+	unpriced := models.Reservation{CheckInDate: resv.CheckInDate, CheckOutDate: resv.CheckOutDate}
+	percent := models.CancellationPolicy{FreeDays: 7, Penalty: models.PenaltyPercent, PenaltyPercent: 50}
+	firstNight := models.CancellationPolicy{FreeDays: 2, Penalty: models.PenaltyFirstNight}
+
+	for _, m := range []struct {
+		testName    string
+		policy      models.CancellationPolicy
+		resv        models.Reservation
+		cancelledAt time.Time
+		penalty     int
+	}{
+		{"flexible", models.CancellationPolicy{}, resv, checkIn, 0},
+		{"percent-in-time", percent, resv, checkIn.AddDate(0, 0, -8), 0},
+		{"percent-late", percent, resv, checkIn.AddDate(0, 0, -7), 15000},
+		{"percent-of-discounted-stay", percent, discounted, checkIn.AddDate(0, 0, -7), 10000},
+		{"first-night-in-time", firstNight, resv, checkIn.AddDate(0, 0, -3), 0},
+		{"first-night-late", firstNight, resv, checkIn.AddDate(0, 0, -1), 10000},
+		{"first-night-of-discounted-stay", firstNight, discounted, checkIn.AddDate(0, 0, -1), 10000},
+		{"first-night-above-total", firstNight, bargain, checkIn.AddDate(0, 0, -1), 5000},
+		{"no-charges", percent, unpriced, checkIn, 0},
+	} {
+		if penalty := Penalty(m.policy, m.resv, m.cancelledAt); penalty != m.penalty {
+			t.Errorf("Error Testing the %s penalty expected %d got %d", m.testName, m.penalty, penalty)
+		}
+	}
+
+	paid := Paid([]models.Payment{
+		{Amount: 9000, Status: models.PaymentSucceeded},
+		{Amount: 9000, Status: models.PaymentFailed},
+	})
+	if paid != 9000 {
+		t.Errorf("Error Testing the paid amount expected 9000 got %d", paid)
+	}
+	if refund := Refund(paid, 10000); refund != 0 {
+		t.Errorf("Error Testing the refund of a penalty above the paid amount got %d", refund)
+	}
+	if refund := Refund(paid, 4000); refund != 5000 {
+		t.Errorf("Error Testing the refund expected 5000 got %d", refund)
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	for _, m := range []struct {
+		testName string
+		amount   int
+		totals   []int
+		parts    []int
+	}{
+		{"one reservation", 10845, []int{36150}, []int{10845}},
+		{"in proportion", 9000, []int{20000, 10000}, []int{6000, 3000}},
+		{"rounded cents", 1000, []int{1, 1, 1}, []int{334, 333, 333}},
+		{"free room", 3000, []int{0, 10000}, []int{0, 3000}},
+		{"discounted room", 3000, []int{-500, 10000}, []int{0, 3000}},
+		{"no totals", 1001, []int{0, 0}, []int{501, 500}},
+		{"no reservation", 1000, nil, []int{}},
+	} {
+		parts := Allocate(m.amount, m.totals)
+		if len(parts) != len(m.parts) {
+			t.Errorf("Error Testing %s for the allocation got %v", m.testName, parts)
+			continue
+		}
+		for i := range parts {
+			if parts[i] != m.parts[i] {
+				t.Errorf("Error Testing %s for the allocation got %v wanted %v", m.testName, parts, m.parts)
+				break
+			}
+		}
+	}
+}
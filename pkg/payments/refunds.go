@@ -0,0 +1,94 @@
+package payments
+
+import (
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+	"github.com/dev-ayaa/resvbooking/pkg/pricing"
+)
+
+//Penalty returns the part of the stay total kept when the reservation is cancelled at the date, nothing
+//before the deadline of the policy. The total is what the stay was charged with the charges of the
+//reservation, its taxes, fees and discounts included, and the first night is the one of its room charge
+func Penalty(policy models.CancellationPolicy, resv models.Reservation, cancelledAt time.Time) int {
+	if cancelledAt.Before(policy.Deadline(resv.CheckInDate)) {
+		return 0
+	}
+	total := pricing.Total(resv.Charges)
+	if total <= 0 {
+		return 0
+	}
+	switch policy.Penalty {
+	case models.PenaltyPercent:
+		return (total*policy.PenaltyPercent + 99) / 100
+	case models.PenaltyFirstNight:
+		firstNight := total
+		for _, charge := range resv.Charges {
+			if charge.Kind == models.ChargeRoom && charge.Quantity > 0 {
+				firstNight = charge.Amount / charge.Quantity
+				break
+			}
+		}
+		if firstNight < total {
+			return firstNight
+		}
+		return total
+	}
+	return 0
+}
+
+//Paid returns the amount the payments succeeded for
+func Paid(payments []models.Payment) int {
+	paid := 0
+	for _, payment := range payments {
+		if payment.Status == models.PaymentSucceeded {
+			paid += payment.Amount
+		}
+	}
+	return paid
+}
+
+//Refund returns what is given back of the paid amount once the penalty is kept
+func Refund(paid, penalty int) int {
+	if penalty >= paid {
+		return 0
+	}
+	return paid - penalty
+}
+
+//Allocate splits the amount between the reservations booked together in proportion to their totals, the
+//cents left by the rounding go to the first ones so the parts add up to the amount. Reservations without
+//a total share the amount evenly
+func Allocate(amount int, totals []int) []int {
+	parts := make([]int, len(totals))
+	if len(totals) == 0 {
+		return parts
+	}
+	weights := make([]int, len(totals))
+	sum := 0
+	for i, total := range totals {
+		if total > 0 {
+			weights[i] = total
+			sum += total
+		}
+	}
+	if sum == 0 {
+		for i := range weights {
+			weights[i] = 1
+		}
+		sum = len(weights)
+	}
+
+	left := amount
+	for i, weight := range weights {
+		parts[i] = int(int64(amount) * int64(weight) / int64(sum))
+		left -= parts[i]
+	}
+	for i := 0; left > 0; i = (i + 1) % len(parts) {
+		if weights[i] > 0 {
+			parts[i]++
+			left--
+		}
+	}
+	return parts
+}
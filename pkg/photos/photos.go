@@ -0,0 +1,160 @@
+package photos
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	_ "image/png"
+	"io"
+	"net/http"
+)
+
+//MaxSize the largest photo accepted from the admin
+const MaxSize = 5 << 20
+
+//MaxPixels the most pixels a photo may decode to, a small file can otherwise unpack to gigabytes
+const MaxPixels = 50_000_000
+
+//ThumbnailWidth and ThumbnailHeight the box the thumbnails are fitted in
+const (
+	ThumbnailWidth  = 400
+	ThumbnailHeight = 300
+)
+
+//Extensions the accepted content types and the extension the file is stored with
+var Extensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/gif":  ".gif",
+}
+
+//Upload a validated photo together with its thumbnail
+type Upload struct {
+	Data        []byte
+	ContentType string
+	Ext         string
+	Thumbnail   []byte
+	Width       int
+	Height      int
+}
+
+//Process checks the size, the content type and the dimensions of the uploaded file, decodes it and creates the
+//jpeg thumbnail. The content type is sniffed from the data, the name and header sent by the browser
+//are not trusted
+func Process(rd io.Reader) (Upload, error) {
+	var upload Upload
+
+	data, err := io.ReadAll(io.LimitReader(rd, MaxSize+1))
+	if err != nil {
+		return upload, err
+	}
+	if len(data) == 0 {
+		return upload, fmt.Errorf("the photo is empty")
+	}
+	if len(data) > MaxSize {
+		return upload, fmt.Errorf("the photo is larger than %d MB", MaxSize>>20)
+	}
+
+	contentType := http.DetectContentType(data)
+	ext, ok := Extensions[contentType]
+	if !ok {
+		return upload, fmt.Errorf("only JPEG, PNG and GIF photos are accepted, got %s", contentType)
+	}
+
+	//the size is read from the header before the pixels are decoded
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return upload, fmt.Errorf("the photo can't be read: %v", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return upload, fmt.Errorf("the photo is %dx%d pixels, at most %d megapixels are accepted", cfg.Width, cfg.Height,
+			MaxPixels/1_000_000)
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return upload, fmt.Errorf("the photo can't be read: %v", err)
+	}
+
+	//jpeg has no transparency, the transparent parts of png and gif photos are laid on white
+	small := Thumbnail(img, ThumbnailWidth, ThumbnailHeight)
+	flat := image.NewRGBA(small.Bounds())
+	draw.Draw(flat, flat.Bounds(), image.White, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), small, image.Point{}, draw.Over)
+
+	var thumb bytes.Buffer
+	err = jpeg.Encode(&thumb, flat, &jpeg.Options{Quality: 85})
+	if err != nil {
+		return upload, err
+	}
+
+	upload = Upload{
+		Data:        data,
+		ContentType: contentType,
+		Ext:         ext,
+		Thumbnail:   thumb.Bytes(),
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+	}
+	return upload, nil
+}
+
+//Thumbnail scales the image down to fit in the box keeping its aspect ratio, every pixel of the
+//thumbnail is the average of the source pixels it covers. Smaller images are only copied
+func Thumbnail(src image.Image, maxWidth, maxHeight int) *image.RGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+
+	if w <= maxWidth && h <= maxHeight {
+		dst := image.NewRGBA(image.Rect(0, 0, w, h))
+		draw.Draw(dst, dst.Bounds(), src, b.Min, draw.Src)
+		return dst
+	}
+
+	scale := float64(maxWidth) / float64(w)
+	if s := float64(maxHeight) / float64(h); s < scale {
+		scale = s
+	}
+	tw := int(float64(w)*scale + 0.5)
+	th := int(float64(h)*scale + 0.5)
+	if tw < 1 {
+		tw = 1
+	}
+	if th < 1 {
+		th = 1
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, tw, th))
+	for y := 0; y < th; y++ {
+		sy0, sy1 := y*h/th, (y+1)*h/th
+		if sy1 == sy0 {
+			sy1 = sy0 + 1
+		}
+		for x := 0; x < tw; x++ {
+			sx0, sx1 := x*w/tw, (x+1)*w/tw
+			if sx1 == sx0 {
+				sx1 = sx0 + 1
+			}
+
+			var r, g, bl, a, n uint64
+			for sy := sy0; sy < sy1; sy++ {
+				for sx := sx0; sx < sx1; sx++ {
+					pr, pg, pb, pa := src.At(b.Min.X+sx, b.Min.Y+sy).RGBA()
+					r, g, bl, a = r+uint64(pr), g+uint64(pg), bl+uint64(pb), a+uint64(pa)
+					n++
+				}
+			}
+			dst.SetRGBA64(x, y, color.RGBA64{
+				R: uint16(r / n),
+				G: uint16(g / n),
+				B: uint16(bl / n),
+				A: uint16(a / n),
+			})
+		}
+	}
+	return dst
+}
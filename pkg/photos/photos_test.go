@@ -0,0 +1,108 @@
+package photos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+//pngPhoto returns a png encoded gradient of the size
+func pngPhoto(w, h int) []byte {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 200, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, img)
+	return buf.Bytes()
+}
+
+//pngHeader returns the start of a png of the size, enough to read its size but not its pixels
+func pngHeader(w, h int) []byte {
+	ihdr := make([]byte, 17)
+	copy(ihdr, "IHDR")
+	binary.BigEndian.PutUint32(ihdr[4:], uint32(w))
+	binary.BigEndian.PutUint32(ihdr[8:], uint32(h))
+	ihdr[12], ihdr[13] = 8, 2
+
+	var buf bytes.Buffer
+	buf.WriteString("\x89PNG\r\n\x1a\n")
+	_ = binary.Write(&buf, binary.BigEndian, uint32(13))
+	buf.Write(ihdr)
+	_ = binary.Write(&buf, binary.BigEndian, crc32.ChecksumIEEE(ihdr))
+	return buf.Bytes()
+}
+
+var ProcessTest = []struct {
+	testName     string
+	data         []byte
+	correctError string
+	correctExt   string
+	thumbWidth   int
+	thumbHeight  int
+}{
+	{testName: "large-photo", data: pngPhoto(800, 400), correctExt: ".png", thumbWidth: 400, thumbHeight: 200},
+	{testName: "tall-photo", data: pngPhoto(300, 600), correctExt: ".png", thumbWidth: 150, thumbHeight: 300},
+	{testName: "small-photo", data: pngPhoto(40, 30), correctExt: ".png", thumbWidth: 40, thumbHeight: 30},
+	{testName: "text-file", data: []byte("first-name,last-name\nGraham,Graham\n"), correctError: "only JPEG, PNG and GIF"},
+	{testName: "empty-file", data: []byte{}, correctError: "the photo is empty"},
+	{testName: "too-large", data: make([]byte, MaxSize+1), correctError: "larger than 5 MB"},
+	{testName: "broken-png", data: pngPhoto(40, 30)[:60], correctError: "can't be read"},
+	{testName: "decompression-bomb", data: pngHeader(100000, 100000), correctError: "at most 50 megapixels"},
+}
+
+func TestProcess(t *testing.T) {
+	for _, p := range ProcessTest {
+		upload, err := Process(bytes.NewReader(p.data))
+		if p.correctError != "" {
+			if err == nil || !strings.Contains(err.Error(), p.correctError) {
+				t.Errorf("Error Testing %s expected error %q got %v", p.testName, p.correctError, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Error Testing %s got error %v", p.testName, err)
+			continue
+		}
+		if upload.Ext != p.correctExt {
+			t.Errorf("Error Testing %s expected extension %s got %s", p.testName, p.correctExt, upload.Ext)
+		}
+		thumb, err := jpeg.Decode(bytes.NewReader(upload.Thumbnail))
+		if err != nil {
+			t.Errorf("Error Testing %s the thumbnail is not a jpeg: %v", p.testName, err)
+			continue
+		}
+		if thumb.Bounds().Dx() != p.thumbWidth || thumb.Bounds().Dy() != p.thumbHeight {
+			t.Errorf("Error Testing %s expected a %dx%d thumbnail got %v", p.testName, p.thumbWidth, p.thumbHeight, thumb.Bounds())
+		}
+	}
+}
+
+func TestThumbnail_Average(t *testing.T) {
+	//a black and white checker board shrunk by two is grey everywhere
+	src := image.NewGray(image.Rect(0, 0, 4, 4))
+	for y := 0; y < 4; y++ {
+		for x := 0; x < 4; x++ {
+			if (x+y)%2 == 0 {
+				src.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	thumb := Thumbnail(src, 2, 2)
+	for y := 0; y < 2; y++ {
+		for x := 0; x < 2; x++ {
+			c := thumb.RGBAAt(x, y)
+			if c.R < 126 || c.R > 128 || c.A != 255 {
+				t.Errorf("Error Testing the thumbnail pixel %d,%d expected grey got %v", x, y, c)
+			}
+		}
+	}
+}
@@ -0,0 +1,121 @@
+package pricing
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+//PromoCodeCharge starts the name of the discount line of a promo code, the code follows it
+const PromoCodeCharge = "Promo code "
+
+//Quote the price of a stay in a room, the nights at the rate of the room followed by the taxes and fees
+type Quote struct {
+	Room     models.Room
+	Nights   int
+	Subtotal int
+	Charges  []models.ReservationCharge
+}
+
+//Price returns the quote of the stay from the check-in date up to the check-out date with the rules
+//applying to the room
+func Price(room models.Room, checkInDate, checkOutDate time.Time, rules []models.TaxRule) Quote {
+	q := Quote{Room: room}
+	var nights []time.Time
+	for d := checkInDate; d.Before(checkOutDate); d = d.AddDate(0, 0, 1) {
+		nights = append(nights, d)
+	}
+	q.Nights = len(nights)
+	q.Subtotal = room.NightlyRate * q.Nights
+
+	for _, rule := range rules {
+		if !rule.AppliesTo(room.ID) {
+			continue
+		}
+		charge := models.ReservationCharge{Name: rule.Name, Kind: rule.Kind}
+		if rule.Per == models.PerStay {
+			if !rule.Covers(checkInDate) {
+				continue
+			}
+			charge.Quantity = 1
+			charge.Amount = rule.Amount
+			if rule.Basis == models.BasisPercent {
+				charge.Amount = percent(q.Subtotal, rule.Rate)
+			}
+		} else {
+			for _, night := range nights {
+				if !rule.Covers(night) {
+					continue
+				}
+				charge.Quantity++
+				if rule.Basis == models.BasisPercent {
+					charge.Amount += percent(room.NightlyRate, rule.Rate)
+				} else {
+					charge.Amount += rule.Amount
+				}
+			}
+		}
+		if charge.Amount > 0 {
+			q.Charges = append(q.Charges, charge)
+		}
+	}
+	return q
+}
+
+//percent returns the part of the amount at the rate in hundredths of a percent, rounded to the cent
+func percent(amount, rate int) int {
+	return (amount*rate + 5000) / 10000
+}
+
+//Extras returns the taxes and fees of the quote
+func (q Quote) Extras() int {
+	extras := 0
+	for _, charge := range q.Charges {
+		extras += charge.Amount
+	}
+	return extras
+}
+
+//Total returns the price of the stay with its taxes and fees
+func (q Quote) Total() int {
+	return q.Subtotal + q.Extras()
+}
+
+//Breakdown returns the lines of the price kept with the reservation, the room nights come first
+func (q Quote) Breakdown() []models.ReservationCharge {
+	breakdown := []models.ReservationCharge{{
+		Name:     fmt.Sprintf("%s, %d nights", q.Room.RoomName, q.Nights),
+		Kind:     models.ChargeRoom,
+		Quantity: q.Nights,
+		Amount:   q.Subtotal,
+	}}
+	return append(breakdown, q.Charges...)
+}
+
+//Total returns the sum of the lines of a breakdown
+func Total(breakdown []models.ReservationCharge) int {
+	total := 0
+	for _, charge := range breakdown {
+		total += charge.Amount
+	}
+	return total
+}
+
+//Discount returns the line taking the promo code off the total of a breakdown, a discount never takes
+//more than the total
+func Discount(promo models.PromoCode, total int) models.ReservationCharge {
+	amount := promo.Amount
+	if promo.Basis == models.BasisPercent {
+		amount = percent(total, promo.Rate)
+	}
+	if amount > total {
+		amount = total
+	}
+	return models.ReservationCharge{
+		Name:     PromoCodeCharge + promo.Code,
+		Kind:     models.ChargeDiscount,
+		Quantity: 1,
+		Amount:   -amount,
+	}
+}
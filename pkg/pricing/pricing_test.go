@@ -0,0 +1,68 @@
+package pricing
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+)
+
+func TestPrice(t *testing.T) {
+	checkIn := time.Date(2030, 1, 30, 0, 0, 0, 0, time.UTC)
+	rules := []models.TaxRule{
+		{Name: "City tax", Kind: models.ChargeTax, Basis: models.BasisFixed, Per: models.PerNight, Amount: 250,
+			StartDate: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+		{Name: "VAT", Kind: models.ChargeTax, Basis: models.BasisPercent, Per: models.PerNight, Rate: 1000,
+			StartDate: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2030, 1, 31, 0, 0, 0, 0, time.UTC)},
+		{Name: "Cleaning", Kind: models.ChargeFee, Basis: models.BasisFixed, Per: models.PerStay, Amount: 3000, RoomID: 1},
+		{Name: "Resort fee", Kind: models.ChargeFee, Basis: models.BasisPercent, Per: models.PerStay, Rate: 550, RoomID: 2},
+		{Name: "Old tax", Kind: models.ChargeTax, Basis: models.BasisFixed, Per: models.PerNight, Amount: 100,
+			StartDate: time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC), EndDate: time.Date(2020, 12, 31, 0, 0, 0, 0, time.UTC)},
+	}
+
+	q := Price(models.Room{ID: 1, RoomName: "Deluxe suite", NightlyRate: 12050}, checkIn, checkIn.AddDate(0, 0, 3), rules)
+	if q.Nights != 3 || q.Subtotal != 36150 {
+		t.Errorf("Error Testing the room nights got %d nights for %d", q.Nights, q.Subtotal)
+	}
+	want := []models.ReservationCharge{
+		{Name: "City tax", Kind: models.ChargeTax, Quantity: 3, Amount: 750},
+		//the VAT ends on the second night
+		{Name: "VAT", Kind: models.ChargeTax, Quantity: 2, Amount: 2410},
+		{Name: "Cleaning", Kind: models.ChargeFee, Quantity: 1, Amount: 3000},
+	}
+	if len(q.Charges) != len(want) {
+		t.Fatalf("Error Testing the charges got %+v", q.Charges)
+	}
+	for i := range want {
+		if q.Charges[i] != want[i] {
+			t.Errorf("Error Testing the charge %d expected %+v got %+v", i, want[i], q.Charges[i])
+		}
+	}
+	if q.Total() != 36150+750+2410+3000 {
+		t.Errorf("Error Testing the total got %d", q.Total())
+	}
+
+	breakdown := q.Breakdown()
+	if len(breakdown) != 4 || breakdown[0].Name != "Deluxe suite, 3 nights" || Total(breakdown) != q.Total() {
+		t.Errorf("Error Testing the breakdown got %+v", breakdown)
+	}
+
+	q = Price(models.Room{ID: 2, NightlyRate: 10000}, checkIn, checkIn.AddDate(0, 0, 2), rules)
+	if len(q.Charges) != 3 || q.Charges[2].Name != "Resort fee" || q.Charges[2].Amount != 1100 {
+		t.Errorf("Error Testing the percent fee per stay got %+v", q.Charges)
+	}
+}
+
+func TestDiscount(t *testing.T) {
+	percentOff := models.PromoCode{Code: "SUMMER", Basis: models.BasisPercent, Rate: 1500}
+	if d := Discount(percentOff, 29900); d.Amount != -4485 || d.Kind != models.ChargeDiscount || d.Name != "Promo code SUMMER" {
+		t.Errorf("Error Testing the percent discount got %+v", d)
+	}
+	fixed := models.PromoCode{Code: "WELCOME", Basis: models.BasisFixed, Amount: 5000}
+	if d := Discount(fixed, 29900); d.Amount != -5000 {
+		t.Errorf("Error Testing the fixed discount got %+v", d)
+	}
+	if d := Discount(fixed, 3000); d.Amount != -3000 {
+		t.Errorf("Error Testing the discount over the total got %+v", d)
+	}
+}
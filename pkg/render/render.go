@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/dev-ayaa/resvbooking/pkg/config"
+	"github.com/dev-ayaa/resvbooking/pkg/i18n"
 	"github.com/dev-ayaa/resvbooking/pkg/models"
 	"github.com/justinas/nosurf"
 	"html/template"
@@ -21,6 +22,10 @@ var functions = template.FuncMap{
 	"format":     RenderFormat,
 	"iterate":    RenderIterate,
 	"add":        RenderAddUp,
+	"money":      models.FormatAmount,
+	"price":      RenderPrice,
+	"t":          i18n.Translate,
+	"localTime":  RenderLocalTime,
 }
 
 // fuction that are added up before the templates files are parsed
@@ -47,6 +52,22 @@ func RenderAddUp(valueA, valueB int) int {
 
 }
 
+//RenderPrice formats an amount in cents of the property currency in the currency the guest selected
+func RenderPrice(cents int, code string) string {
+	if app == nil || app.Rates == nil {
+		return models.FormatAmount(cents)
+	}
+	return app.Rates.Format(cents, code)
+}
+
+//RenderLocalTime formats an instant on the clock of the property
+func RenderLocalTime(t time.Time, layout string) string {
+	if app == nil || app.Property == nil {
+		return t.Format(layout)
+	}
+	return app.Property.Local(t).Format(layout)
+}
+
 /*Storing the templates Cache into the AppConfig struct type, Import the AppConfig as a pointer in the render package back
 now use the type store in the AppConfig in the render package ,To keep the stored data updated import the function
 where the AppConfig is store in the render package to the main package
@@ -67,6 +88,24 @@ func AddDefaultData(td *models.TemplateData, rq *http.Request) *models.TemplateD
 	if app.Session.Exists(rq.Context(), "userID") {
 		td.IsAuth = 1
 	}
+	//the display currency selected by the guest, while its rate is still stored
+	if app.Rates != nil {
+		td.BaseCurrency = app.Rates.Base()
+		td.Currencies = app.Rates.Codes()
+		td.Currency = td.BaseCurrency
+		if code := app.Session.GetString(rq.Context(), "currency"); app.Rates.Known(code) {
+			td.Currency = code
+		}
+	}
+	//the times the guests arrive from and leave by, on the clock of the property
+	if app.Property != nil {
+		td.CheckInTime = app.Property.CheckIn().String()
+		td.CheckOutTime = app.Property.CheckOut().String()
+		td.TimeZone = app.Property.Location().String()
+	}
+	//the language chosen by the guest, else the one the browser prefers
+	td.Locale = i18n.Resolve(app.Session.GetString(rq.Context(), "locale"), rq.Header.Get("Accept-Language"))
+	td.Locales = i18n.Locales()
 	return td
 }
 
@@ -1,6 +1,7 @@
 package render
 
 import (
+	"github.com/dev-ayaa/resvbooking/pkg/currency"
 	"github.com/dev-ayaa/resvbooking/pkg/models"
 	"net/http"
 	"testing"
@@ -24,6 +25,49 @@ func TestAddDefaultData(t *testing.T) {
 
 }
 
+func TestRenderPrice(t *testing.T) {
+	app.Rates = currency.NewRates("USD")
+	defer func() { app.Rates = nil }()
+	app.Rates.Set([]models.ExchangeRate{{Currency: "EUR", Rate: 0.92}})
+
+	if got := RenderPrice(12000, "EUR"); got != "110.40 EUR" {
+		t.Errorf("Error Testing the price in euros got %s", got)
+	}
+	if got := RenderPrice(12000, "JPY"); got != "120.00 USD" {
+		t.Errorf("Error Testing the price in an unknown currency got %s", got)
+	}
+
+	rq, err := getSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	session.Put(rq.Context(), "currency", "EUR")
+	var data models.TemplateData
+	AddDefaultData(&data, rq)
+	if data.Currency != "EUR" || data.BaseCurrency != "USD" || len(data.Currencies) != 2 {
+		t.Errorf("Error Testing the display currency of the session got %+v", data)
+	}
+}
+
+func TestAddDefaultData_Locale(t *testing.T) {
+	rq, err := getSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	rq.Header.Set("Accept-Language", "es-ES, fr;q=0.8")
+	var data models.TemplateData
+	AddDefaultData(&data, rq)
+	if data.Locale != "es" || len(data.Locales) < 2 {
+		t.Errorf("Error Testing the locale of the browser got %s", data.Locale)
+	}
+
+	session.Put(rq.Context(), "locale", "fr")
+	AddDefaultData(&data, rq)
+	if data.Locale != "fr" {
+		t.Errorf("Error Testing the locale chosen by the guest got %s", data.Locale)
+	}
+}
+
 func getSession() (*http.Request, error) {
 	//Setting up request with a session
 	rq, err := http.NewRequest("GET", "/", nil)
@@ -53,7 +97,7 @@ func TestTemplate(t *testing.T) {
 		t.Error("Error rendering Templates")
 	}
 
-	err = Template(&wr, "deluxe.page.tmpl", &td, rq)
+	err = Template(&wr, "room.page.tmpl", &td, rq)
 	if err != nil {
 		t.Error("Error getting the templates")
 		t.Error(err)
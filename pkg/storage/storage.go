@@ -0,0 +1,96 @@
+package storage
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+//Storage keeps the files uploaded by the admin and serves them back to the browser
+type Storage interface {
+	//Save stores the data under the name and returns the url path the file is served from
+	Save(name string, data []byte) (string, error)
+	//Delete removes the file served from the url path, files the storage doesn't own are left alone
+	Delete(urlPath string) error
+	http.Handler
+}
+
+//LocalStorage stores the files in a directory on the local disk
+type LocalStorage struct {
+	Dir string
+	URL string
+}
+
+//NewLocalStorage creates the directory if needed, the files are served under the url prefix
+func NewLocalStorage(dir, url string) (*LocalStorage, error) {
+	err := os.MkdirAll(dir, 0755)
+	if err != nil {
+		return nil, err
+	}
+	return &LocalStorage{Dir: dir, URL: strings.TrimSuffix(url, "/")}, nil
+}
+
+//Save writes the file in the storage directory
+func (ls *LocalStorage) Save(name string, data []byte) (string, error) {
+	name = filepath.Base(name)
+	err := os.WriteFile(filepath.Join(ls.Dir, name), data, 0644)
+	if err != nil {
+		return "", err
+	}
+	return path.Join(ls.URL, name), nil
+}
+
+//Delete removes the file from the storage directory, a file already gone is not an error
+func (ls *LocalStorage) Delete(urlPath string) error {
+	if !strings.HasPrefix(urlPath, ls.URL+"/") {
+		return nil
+	}
+	err := os.Remove(filepath.Join(ls.Dir, filepath.Base(urlPath)))
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
+}
+
+//ServeHTTP serves the stored files, the url prefix must be stripped by the router. The directories are
+//not listed
+func (ls *LocalStorage) ServeHTTP(wr http.ResponseWriter, rq *http.Request) {
+	http.FileServer(filesOnly{http.Dir(ls.Dir)}).ServeHTTP(wr, rq)
+}
+
+//filesOnly a file system opening only the files, the directories are reported as missing
+type filesOnly struct {
+	fs http.FileSystem
+}
+
+//Open opens the file, a directory is not found
+func (fo filesOnly) Open(name string) (http.File, error) {
+	f, err := fo.fs.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	if info.IsDir() {
+		f.Close()
+		return nil, os.ErrNotExist
+	}
+	return f, nil
+}
+
+//UniqueName returns a random file name starting with the prefix and ending with the extension
+func UniqueName(prefix, ext string) (string, error) {
+	b := make([]byte, 8)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return prefix + "-" + hex.EncodeToString(b) + ext, nil
+}
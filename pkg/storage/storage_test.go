@@ -0,0 +1,64 @@
+package storage
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestLocalStorage(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "uploads")
+	ls, err := NewLocalStorage(dir, "/uploads/")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	urlPath, err := ls.Save("../room-1.jpg", []byte("photo"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if urlPath != "/uploads/room-1.jpg" {
+		t.Errorf("Error Testing the saved file url got %s", urlPath)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "room-1.jpg")); err != nil {
+		t.Errorf("Error Testing the saved file is not in the storage directory: %v", err)
+	}
+
+	rq := httptest.NewRequest("GET", "/room-1.jpg", nil)
+	responseRecorder := httptest.NewRecorder()
+	ls.ServeHTTP(responseRecorder, rq)
+	if responseRecorder.Code != http.StatusOK || responseRecorder.Body.String() != "photo" {
+		t.Errorf("Error Testing serving the saved file got %v %q", responseRecorder.Code, responseRecorder.Body.String())
+	}
+
+	rq = httptest.NewRequest("GET", "/", nil)
+	responseRecorder = httptest.NewRecorder()
+	ls.ServeHTTP(responseRecorder, rq)
+	if responseRecorder.Code != http.StatusNotFound || strings.Contains(responseRecorder.Body.String(), "room-1.jpg") {
+		t.Errorf("Error Testing the storage directory is listed got %v %q", responseRecorder.Code, responseRecorder.Body.String())
+	}
+
+	if err = ls.Delete(urlPath); err != nil {
+		t.Errorf("Error Testing deleting the saved file: %v", err)
+	}
+	if _, err = os.Stat(filepath.Join(dir, "room-1.jpg")); !os.IsNotExist(err) {
+		t.Errorf("Error Testing the deleted file is still in the storage directory")
+	}
+	if err = ls.Delete(urlPath); err != nil {
+		t.Errorf("Error Testing deleting a file already gone: %v", err)
+	}
+	if err = ls.Delete("/static/images/pexels-pixabay-164595.jpg"); err != nil {
+		t.Errorf("Error Testing deleting a file outside the storage: %v", err)
+	}
+}
+
+func TestUniqueName(t *testing.T) {
+	a, _ := UniqueName("room-1", ".jpg")
+	b, _ := UniqueName("room-1", ".jpg")
+	if a == b || !strings.HasPrefix(a, "room-1-") || !strings.HasSuffix(a, ".jpg") {
+		t.Errorf("Error Testing unique names got %s and %s", a, b)
+	}
+}
@@ -0,0 +1,168 @@
+package waitlist
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/config"
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+	"github.com/dev-ayaa/resvbooking/repository"
+)
+
+//HoldDuration how long a waitlisted guest has to book the room offered by the hold link
+const HoldDuration = 24 * time.Hour
+
+//Interval how often the waitlist is checked for rooms freed by cancellations or released blocks
+const Interval = 5 * time.Minute
+
+//Watcher offers the rooms freed up to the guests of the waitlist, the oldest entry first
+type Watcher struct {
+	App     *config.AppConfig
+	DB      repository.DatabaseRepository
+	BaseURL string
+	Hold    time.Duration
+}
+
+//NewWatcher returns a watcher sending hold links to the site at the base url
+func NewWatcher(app *config.AppConfig, db repository.DatabaseRepository, baseURL string) *Watcher {
+	return &Watcher{
+		App:     app,
+		DB:      db,
+		BaseURL: baseURL,
+		Hold:    HoldDuration,
+	}
+}
+
+//Start checks the waitlist every interval in the background
+func (w *Watcher) Start(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for now := range ticker.C {
+			offered, err := w.Run(now)
+			if err != nil {
+				w.App.ErrorLog.Println("waitlist:", err)
+			}
+			if offered > 0 {
+				w.App.InfoLog.Printf("waitlist: %d rooms offered", offered)
+			}
+		}
+	}()
+}
+
+//Run closes the expired offers, then offers a free room to every waiting guest in turn and returns how
+//many rooms were offered. A room is never offered twice for the same night, neither to two guests of
+//this run nor while a previous offer for it is still open
+func (w *Watcher) Run(now time.Time) (int, error) {
+	_, err := w.DB.ExpireWaitlistOffers(now)
+	if err != nil {
+		return 0, err
+	}
+
+	openOffers, err := w.DB.WaitlistEntries(models.WaitlistOffered)
+	if err != nil {
+		return 0, err
+	}
+	waiting, err := w.DB.WaitlistEntries(models.WaitlistWaiting)
+	if err != nil {
+		return 0, err
+	}
+
+	today := w.App.Property.Date(now)
+	offered := 0
+	for _, entry := range waiting {
+		//the stay started without a room freeing up
+		if entry.CheckInDate.Before(today) {
+			err = w.DB.UpdateWaitlistStatus(entry.ID, models.WaitlistExpired)
+			if err != nil {
+				return offered, err
+			}
+			continue
+		}
+
+		rooms, err := w.DB.SearchForAvailableRoom(entry.CheckInDate, entry.CheckOutDate)
+		if err != nil {
+			return offered, err
+		}
+		room, ok := pickRoom(entry, rooms, openOffers)
+		if !ok {
+			continue
+		}
+
+		token, err := newToken()
+		if err != nil {
+			return offered, err
+		}
+		//the room is held for the guest as long as the offer is open
+		expiresAt := now.Add(w.Hold)
+		holdID, err := w.DB.OfferWaitlistEntry(entry.ID, room.ID, token, expiresAt)
+		if err != nil {
+			return offered, err
+		}
+		entry.Status = models.WaitlistOffered
+		entry.OfferedRoomID = room.ID
+		entry.HoldID = holdID
+		entry.Room = room
+		entry.Token = token
+		entry.HoldExpiresAt = expiresAt
+		openOffers = append(openOffers, entry)
+		offered++
+
+		w.App.MailChannel <- w.offerMail(entry)
+	}
+	return offered, nil
+}
+
+//pickRoom returns the first free room wanted by the entry which holds its guests and isn't already offered
+//to another guest for one of its nights
+func pickRoom(entry models.WaitlistEntry, rooms []models.Room, openOffers []models.WaitlistEntry) (models.Room, bool) {
+	for _, room := range rooms {
+		if (entry.RoomID != 0 && room.ID != entry.RoomID) || !room.Fits(entry.Adults, entry.Children) {
+			continue
+		}
+		taken := false
+		for _, offer := range openOffers {
+			if offer.OfferedRoomID == room.ID && offer.Overlaps(entry.CheckInDate, entry.CheckOutDate) {
+				taken = true
+				break
+			}
+		}
+		if !taken {
+			return room, true
+		}
+	}
+	return models.Room{}, false
+}
+
+//HoldLink returns the page the guest books the offered room from
+func (w *Watcher) HoldLink(token string) string {
+	return fmt.Sprintf("%s/waitlist/hold/%s", w.BaseURL, token)
+}
+
+func (w *Watcher) offerMail(entry models.WaitlistEntry) models.MailData {
+	content := fmt.Sprintf(`<strong>A room freed up at Rest Tavern</strong><br>
+		Dear %s,<br>
+		%s is available from %s to %s. It is held for you until %s, book it with
+		<a href="%s">this link</a>.`, entry.FirstName, entry.Room.RoomName,
+		entry.CheckInDate.Format("2006-01-02"), entry.CheckOutDate.Format("2006-01-02"),
+		entry.HoldExpiresAt.Format("2006-01-02 15:04 MST"), w.HoldLink(entry.Token))
+
+	return models.MailData{
+		MailSubject:  "A room is available at Rest Tavern Inn",
+		Receiver:     entry.Email,
+		Sender:       "ayaaakinleye@gmail.com",
+		MailContent:  content,
+		MailTemplate: "mailTemplate.html",
+	}
+}
+
+func newToken() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
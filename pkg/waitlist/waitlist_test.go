@@ -0,0 +1,143 @@
+package waitlist
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/dev-ayaa/resvbooking/pkg/config"
+	"github.com/dev-ayaa/resvbooking/pkg/dates"
+	"github.com/dev-ayaa/resvbooking/pkg/models"
+	"github.com/dev-ayaa/resvbooking/repository"
+)
+
+//fakeDB keeps the waitlist in memory, every room is free
+type fakeDB struct {
+	repository.DatabaseRepository
+	entries []models.WaitlistEntry
+	rooms   []models.Room
+	expired int
+	holds   int
+}
+
+func (f *fakeDB) ExpireWaitlistOffers(now time.Time) (int, error) {
+	f.expired++
+	return 0, nil
+}
+
+func (f *fakeDB) WaitlistEntries(status string) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	for _, e := range f.entries {
+		if e.Status == status {
+			entries = append(entries, e)
+		}
+	}
+	return entries, nil
+}
+
+func (f *fakeDB) SearchForAvailableRoom(checkInDate, checkOutDate time.Time) ([]models.Room, error) {
+	return f.rooms, nil
+}
+
+func (f *fakeDB) OfferWaitlistEntry(id, roomID int, token string, expiresAt time.Time) (int, error) {
+	f.holds++
+	for i := range f.entries {
+		if f.entries[i].ID == id {
+			f.entries[i].Status = models.WaitlistOffered
+			f.entries[i].OfferedRoomID = roomID
+			f.entries[i].Token = token
+			f.entries[i].HoldID = f.holds
+			f.entries[i].HoldExpiresAt = expiresAt
+		}
+	}
+	return f.holds, nil
+}
+
+func (f *fakeDB) UpdateWaitlistStatus(id int, status string) error {
+	for i := range f.entries {
+		if f.entries[i].ID == id {
+			f.entries[i].Status = status
+		}
+	}
+	return nil
+}
+
+func date(value string) time.Time {
+	d, _ := time.Parse("2006-01-02", value)
+	return d
+}
+
+func TestWatcher_Run(t *testing.T) {
+	db := &fakeDB{
+		rooms: []models.Room{{ID: 1, RoomName: "Deluxe suite"}, {ID: 2, RoomName: "Junior suite"}},
+		entries: []models.WaitlistEntry{
+			{ID: 1, Email: "a@example.com", CheckInDate: date("2030-01-10"), CheckOutDate: date("2030-01-12"), Status: models.WaitlistWaiting},
+			{ID: 2, Email: "b@example.com", CheckInDate: date("2030-01-11"), CheckOutDate: date("2030-01-13"), RoomID: 2, Status: models.WaitlistWaiting},
+			{ID: 3, Email: "c@example.com", CheckInDate: date("2030-01-11"), CheckOutDate: date("2030-01-12"), Status: models.WaitlistWaiting},
+			{ID: 4, Email: "d@example.com", CheckInDate: date("2029-12-01"), CheckOutDate: date("2029-12-03"), Status: models.WaitlistWaiting},
+			{ID: 5, Email: "e@example.com", CheckInDate: date("2030-02-01"), CheckOutDate: date("2030-02-03"), Status: models.WaitlistOffered, OfferedRoomID: 1},
+			{ID: 6, Email: "f@example.com", CheckInDate: date("2030-02-02"), CheckOutDate: date("2030-02-04"), RoomID: 1, Status: models.WaitlistWaiting},
+			{ID: 7, Email: "g@example.com", CheckInDate: date("2030-02-02"), CheckOutDate: date("2030-02-04"), Status: models.WaitlistWaiting},
+		},
+	}
+	mails := make(chan models.MailData, 10)
+	property, _ := dates.NewProperty("UTC", "15:00", "11:00")
+	w := NewWatcher(&config.AppConfig{MailChannel: mails, Property: property}, db, "https://rest.example.com")
+
+	now := time.Date(2029, 12, 20, 9, 0, 0, 0, time.UTC)
+	offered, err := w.Run(now)
+	if err != nil {
+		t.Fatalf("Error Testing the waitlist run: %v", err)
+	}
+	if offered != 3 || len(mails) != 3 {
+		t.Errorf("Error Testing the waitlist run expected 3 offers and mails got %d offers and %d mails", offered, len(mails))
+	}
+	if db.expired != 1 {
+		t.Errorf("Error Testing the waitlist run, the expired offers were not closed")
+	}
+
+	expected := map[int]struct {
+		status string
+		room   int
+	}{
+		1: {models.WaitlistOffered, 1},
+		2: {models.WaitlistOffered, 2},
+		3: {models.WaitlistWaiting, 0},
+		4: {models.WaitlistExpired, 0},
+		6: {models.WaitlistWaiting, 0},
+		7: {models.WaitlistOffered, 2},
+	}
+	for _, e := range db.entries {
+		want, ok := expected[e.ID]
+		if !ok {
+			continue
+		}
+		if e.Status != want.status || e.OfferedRoomID != want.room {
+			t.Errorf("Error Testing the waitlist entry %d expected %s room %d got %s room %d", e.ID, want.status, want.room, e.Status, e.OfferedRoomID)
+		}
+		if e.Status == models.WaitlistOffered && !e.HoldExpiresAt.Equal(now.Add(HoldDuration)) {
+			t.Errorf("Error Testing the waitlist entry %d, the hold expires at %v", e.ID, e.HoldExpiresAt)
+		}
+		if (e.Status == models.WaitlistOffered) != (e.HoldID > 0) {
+			t.Errorf("Error Testing the waitlist entry %d in status %s got hold %d", e.ID, e.Status, e.HoldID)
+		}
+	}
+
+	mail := <-mails
+	if mail.Receiver != "a@example.com" || !strings.Contains(mail.MailContent, "https://rest.example.com/waitlist/hold/"+db.entries[0].Token) {
+		t.Errorf("Error Testing the waitlist mail, wrong receiver or hold link: %s %s", mail.Receiver, mail.MailContent)
+	}
+}
+
+func TestPickRoom(t *testing.T) {
+	rooms := []models.Room{{ID: 1, MaxAdults: 2}, {ID: 2, MaxAdults: 4, MaxChildren: 2}}
+	entry := models.WaitlistEntry{CheckInDate: date("2030-01-10"), CheckOutDate: date("2030-01-12"), Adults: 3, Children: 1}
+	room, ok := pickRoom(entry, rooms, nil)
+	if !ok || room.ID != 2 {
+		t.Errorf("Error Testing the room offered to 3 adults and a child got %d", room.ID)
+	}
+	entry.RoomID = 1
+	if room, ok = pickRoom(entry, rooms, nil); ok {
+		t.Errorf("Error Testing the wanted room too small for the guests, room %d was offered", room.ID)
+	}
+}
@@ -2,10 +2,13 @@ package dbRepository
 
 import (
 	"context"
+	"database/sql"
+	"github.com/dev-ayaa/resvbooking/pkg/i18n"
 	"github.com/dev-ayaa/resvbooking/pkg/models"
 	"github.com/pkg/errors"
 	"golang.org/x/crypto/bcrypt"
 	"log"
+	"sort"
 	"time"
 )
 
@@ -15,14 +18,18 @@ func (pg *PostgresDBRepository) AllRoom() ([]models.Room, error) {
 	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelCtx()
 
-	query := `select id, room_name, created_at, updated_at from rooms order by room_name`
+	query := `select id, room_name, slug, description, amenities, max_adults, max_children, nightly_rate,
+       cancel_free_days, cancel_penalty, cancel_penalty_percent, created_at, updated_at
+       from rooms order by room_name`
 	rows, err := pg.DB.QueryContext(ctx, query)
 	if err != nil {
 		return allRooms, err
 	}
 	defer rows.Close()
 	for rows.Next() {
-		err := rows.Scan(&room.ID, &room.RoomName, &room.CreatedAt, &room.UpdatedAt)
+		err := rows.Scan(&room.ID, &room.RoomName, &room.Slug, &room.Description, &room.Amenities,
+			&room.MaxAdults, &room.MaxChildren, &room.NightlyRate,
+			&room.Cancellation.FreeDays, &room.Cancellation.Penalty, &room.Cancellation.PenaltyPercent, &room.CreatedAt, &room.UpdatedAt)
 		if err != nil {
 			return allRooms, err
 		}
@@ -31,9 +38,40 @@ func (pg *PostgresDBRepository) AllRoom() ([]models.Room, error) {
 	if err = rows.Err(); err != nil {
 		return allRooms, err
 	}
+
+	photos, err := pg.roomPhotos(ctx, 0)
+	if err != nil {
+		return allRooms, err
+	}
+	for i := range allRooms {
+		allRooms[i].Photos = photos[allRooms[i].ID]
+	}
 	return allRooms, nil
 }
 
+//roomPhotos returns the photos of the room in gallery order keyed by room id, the photos of every
+//room when the room id is 0
+func (pg *PostgresDBRepository) roomPhotos(ctx context.Context, roomID int) (map[int][]models.RoomPhoto, error) {
+	photos := make(map[int][]models.RoomPhoto)
+	query := `select id, room_id, path, thumbnail_path, caption, position, created_at, updated_at
+       from room_photo where $1 = 0 or room_id = $1 order by room_id, position, id`
+	rows, err := pg.DB.QueryContext(ctx, query, roomID)
+	if err != nil {
+		return photos, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var photo models.RoomPhoto
+		err = rows.Scan(&photo.ID, &photo.RoomID, &photo.Path, &photo.ThumbnailPath, &photo.Caption, &photo.Position,
+			&photo.CreatedAt, &photo.UpdatedAt)
+		if err != nil {
+			return photos, err
+		}
+		photos[photo.RoomID] = append(photos[photo.RoomID], photo)
+	}
+	return photos, rows.Err()
+}
+
 //InsertReservation Insert a Reservation data into the database
 func (pg *PostgresDBRepository) InsertReservation(resv models.Reservation) (int, error) {
 
@@ -41,8 +79,8 @@ func (pg *PostgresDBRepository) InsertReservation(resv models.Reservation) (int,
 
 	defer cancelCtx()
 	stmt := `insert into reservation (first_name, last_name, email, phone_number, check_in_date, 
-                         check_out_date, room_id, created_at, updated_at) 
-              values ($1,$2,$3,$4,$5,$6,$7,$8,$9) returning id`
+                         check_out_date, room_id, adults, children, created_at, updated_at) 
+              values ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) returning id`
 
 	// to get the id of newly insert reservation we need to querythe database
 	var NewID int
@@ -55,6 +93,8 @@ func (pg *PostgresDBRepository) InsertReservation(resv models.Reservation) (int,
 		resv.CheckInDate,
 		resv.CheckOutDate,
 		resv.RoomID,
+		resv.Adults,
+		resv.Children,
 		time.Now(),
 		time.Now(),
 	).Scan(&NewID)
@@ -95,8 +135,9 @@ func (pg *PostgresDBRepository) SearchRoomAvailabileByRoomID(roomID int, checkIn
 
 	defer cancelCtx()
 	var rowCount int
-	queryStmt := `select count(id) from room_restriction where room_id = $1 and $2 < check_out_date and $3 > check_in_date`
-	row := pg.DB.QueryRowContext(ctx, queryStmt, roomID, checkInDate, checkOutDate)
+	queryStmt := `select count(id) from room_restriction where room_id = $1 and $2 < check_out_date and $3 > check_in_date
+                  and (expires_at is null or expires_at > $4)`
+	row := pg.DB.QueryRowContext(ctx, queryStmt, roomID, checkInDate, checkOutDate, time.Now())
 	err := row.Scan(&rowCount)
 	if err != nil {
 		return false, err
@@ -107,24 +148,28 @@ func (pg *PostgresDBRepository) SearchRoomAvailabileByRoomID(roomID int, checkIn
 	return false, nil
 }
 
+//SearchForAvailableRoom returns the rooms free for the whole stay with their capacity, the guests are
+//checked against the rooms they select so a group finds the rooms no one of which holds them all
 func (pg *PostgresDBRepository) SearchForAvailableRoom(checkInDate, checkOutDate time.Time) ([]models.Room, error) {
 	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
 
 	defer cancelCtx()
 	var rooms []models.Room
 
-	queryStmt := `select r.id, r.room_name from rooms r
+	queryStmt := `select r.id, r.room_name, r.max_adults, r.max_children from rooms r
                   where r.id not in (select rr.room_id from room_restriction rr
-                  where $1 < rr.check_out_date and $2 > rr.check_in_date);`
+                  where $1 < rr.check_out_date and $2 > rr.check_in_date
+                  and (rr.expires_at is null or rr.expires_at > $3))
+                  order by r.id;`
 
-	rows, err := pg.DB.QueryContext(ctx, queryStmt, checkInDate, checkOutDate)
+	rows, err := pg.DB.QueryContext(ctx, queryStmt, checkInDate, checkOutDate, time.Now())
 	if err != nil {
 		return rooms, err
 	}
 	defer rows.Close()
 	for rows.Next() {
 		var room models.Room
-		err = rows.Scan(&room.ID, &room.RoomName)
+		err = rows.Scan(&room.ID, &room.RoomName, &room.MaxAdults, &room.MaxChildren)
 		if err != nil {
 			return rooms, err
 		}
@@ -142,294 +187,1125 @@ func (pg *PostgresDBRepository) GetRooms(room_id int) (models.Room, error) {
 	var room models.Room
 	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelCtx()
-	query := `select id, room_name , created_at, updated_at from rooms where id = $1`
+	query := `select id, room_name, slug, description, amenities, max_adults, max_children, nightly_rate,
+       cancel_free_days, cancel_penalty, cancel_penalty_percent, created_at, updated_at
+       from rooms where id = $1`
 
 	rooms := pg.DB.QueryRowContext(ctx, query, room_id)
 
-	err := rooms.Scan(&room.ID, &room.RoomName, &room.CreatedAt, &room.UpdatedAt)
+	err := rooms.Scan(&room.ID, &room.RoomName, &room.Slug, &room.Description, &room.Amenities,
+		&room.MaxAdults, &room.MaxChildren, &room.NightlyRate,
+		&room.Cancellation.FreeDays, &room.Cancellation.Penalty, &room.Cancellation.PenaltyPercent, &room.CreatedAt, &room.UpdatedAt)
+	if err != nil {
+		return room, err
+	}
+	photos, err := pg.roomPhotos(ctx, room.ID)
 	if err != nil {
 		return room, err
 	}
+	room.Photos = photos[room.ID]
 	return room, nil
 
 }
 
-func (pg *PostgresDBRepository) GetUserInfoByID(userID int) (models.User, error) {
-	var user models.User
+//GetRoomBySlug returns the room shown at /rooms/{slug} together with its photos
+func (pg *PostgresDBRepository) GetRoomBySlug(slug string) (models.Room, error) {
+	var room models.Room
 	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelCtx()
+	query := `select id, room_name, slug, description, amenities, max_adults, max_children, nightly_rate,
+       cancel_free_days, cancel_penalty, cancel_penalty_percent, created_at, updated_at
+       from rooms where slug = $1`
 
-	query := `select id, first_name, last_name, email, password, created_at, updated_at, access_level from user where id = $1`
-	row := pg.DB.QueryRowContext(ctx, query, userID)
-	err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.AccessLevel)
-
+	err := pg.DB.QueryRowContext(ctx, query, slug).Scan(&room.ID, &room.RoomName, &room.Slug, &room.Description,
+		&room.Amenities, &room.MaxAdults, &room.MaxChildren, &room.NightlyRate,
+		&room.Cancellation.FreeDays, &room.Cancellation.Penalty, &room.Cancellation.PenaltyPercent, &room.CreatedAt, &room.UpdatedAt)
 	if err != nil {
-		return models.User{}, err
+		return room, err
 	}
-	return user, nil
+	photos, err := pg.roomPhotos(ctx, room.ID)
+	if err != nil {
+		return room, err
+	}
+	room.Photos = photos[room.ID]
+	return room, nil
 }
 
-//UpdateUserInfo to Update the users information or details in the database
-func (pg *PostgresDBRepository) UpdateUserInfo(user models.User) error {
-	//var user models.User
+//InsertRoom adds a room to the catalogue and returns its id
+func (pg *PostgresDBRepository) InsertRoom(room models.Room) (int, error) {
 	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelCtx()
 
-	query := `update users set first_name= $1, last_name=$2,email=$3,updated_at=$4, access_level=$5`
-	_, err := pg.DB.ExecContext(ctx, query, user.FirstName, user.LastName, user.Email, user.UpdatedAt, user.AccessLevel)
+	stmt := `insert into rooms (room_name, slug, description, amenities, max_adults, max_children, nightly_rate,
+                         cancel_free_days, cancel_penalty, cancel_penalty_percent, created_at, updated_at)
+              values ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12) returning id`
+	var newID int
+	err := pg.DB.QueryRowContext(ctx, stmt,
+		room.RoomName,
+		room.Slug,
+		room.Description,
+		room.Amenities,
+		room.MaxAdults,
+		room.MaxChildren,
+		room.NightlyRate,
+		room.Cancellation.FreeDays,
+		room.Cancellation.Penalty,
+		room.Cancellation.PenaltyPercent,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
 	if err != nil {
-		return err
+		return 0, err
 	}
-	return nil
+	return newID, nil
 }
 
-//AuthenticateUser to Athenticate the user by verifying the email and the Password
-func (pg *PostgresDBRepository) AuthenticateUser(typedPassword, email string) (int, string, error) {
-	var userID int
-	var hashedPassword string
+//UpdateRoom saves the catalogue details of a room
+func (pg *PostgresDBRepository) UpdateRoom(room models.Room) error {
 	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelCtx()
-	query := `select id, password from users where email= $1`
-	row := pg.DB.QueryRowContext(ctx, query, email)
-
-	//scan the database respectively with the query parameters
-	err := row.Scan(&userID, &hashedPassword)
-	if err != nil {
-		return userID, "", err
-	}
-	err = bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(typedPassword))
-	//If the password did not match
-	if err == bcrypt.ErrMismatchedHashAndPassword {
-		return 0, "", errors.New("incorrect password!")
-	} else if err != nil {
-		return 0, "", err
-	}
-	return userID, hashedPassword, nil
 
+	query := `update rooms set room_name = $1, slug = $2, description = $3, amenities = $4, max_adults = $5,
+                 max_children = $6, nightly_rate = $7, cancel_free_days = $8, cancel_penalty = $9,
+                 cancel_penalty_percent = $10, updated_at = $11 where id = $12`
+	_, err := pg.DB.ExecContext(ctx, query,
+		room.RoomName,
+		room.Slug,
+		room.Description,
+		room.Amenities,
+		room.MaxAdults,
+		room.MaxChildren,
+		room.NightlyRate,
+		room.Cancellation.FreeDays,
+		room.Cancellation.Penalty,
+		room.Cancellation.PenaltyPercent,
+		time.Now(),
+		room.ID,
+	)
+	return err
 }
 
-/*DataBase Functions for the administration pages */
-
-//AllReservation this show all the registered resservations in the database
-func (pg *PostgresDBRepository) AllReservation() ([]models.Reservation, error) {
-	var allResv []models.Reservation
+//DeleteRoom removes a room from the catalogue, a room which was ever reserved or blocked is kept so
+//the history of the reservations stays intact
+func (pg *PostgresDBRepository) DeleteRoom(id int) error {
 	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelCtx()
-	query := `select r.id,
-       r.first_name,
-       r.last_name,
-       r.email,
-       r.phone_number,
-       r.room_id,
-       r.check_in_date,
-       r.check_out_date,
-       r.updated_at,
-       r.created_at,
-       r.processed
-       from reservation r
-         left join rooms rm on (r.room_id = rm.id)
-       order by r.check_in_date`
-	row, err := pg.DB.QueryContext(ctx, query)
+
+	tx, err := pg.DB.BeginTx(ctx, nil)
 	if err != nil {
-		return allResv, err
+		return err
 	}
-	defer row.Close()
+	defer tx.Rollback()
 
-	for row.Next() {
-		var rs models.Reservation
-		//scan the database respectively with the query parameters
-
-		err = row.Scan(
-			&rs.ID,
-			&rs.FirstName,
-			&rs.LastName,
-			&rs.Email,
-			&rs.PhoneNumber,
-			&rs.RoomID,
-			&rs.CheckInDate,
-			&rs.CheckOutDate,
-			//&rs.Room.RoomName,
-			&rs.CreatedAt,
-			&rs.UpdatedAt,
-			&rs.Processed,
-		)
-		if err != nil {
-			return allResv, err
-		}
-		allResv = append(allResv, rs)
+	var rowCount int
+	query := `select (select count(id) from reservation where room_id = $1) +
+                     (select count(id) from room_restriction where room_id = $1)`
+	err = tx.QueryRowContext(ctx, query, id).Scan(&rowCount)
+	if err != nil {
+		return err
 	}
-	if err = row.Err(); err != nil {
-		return allResv, err
+	if rowCount > 0 {
+		return errors.Errorf("room %d has reservations or blocks and cannot be deleted", id)
 	}
-	return allResv, nil
-}
 
-//AllNewReservation this helps to get all the newly registered reservation in the database
-func (pg *PostgresDBRepository) AllNewReservation() ([]models.Reservation, error) {
-	var newResv []models.Reservation
-	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancelCtx()
-	query := `select r.id,
-       r.first_name,
-       r.last_name,
-       r.email,
-       r.phone_number,
-       r.room_id,
-       r.check_in_date,
-       r.check_out_date,
-       r.updated_at, 
-       r.created_at,
-       r.processed
-       from reservation r
-           left join rooms rm on (r.room_id = rm.id)
-           where r.processed = 0
-           order by r.check_in_date`
-	row, err := pg.DB.QueryContext(ctx, query)
+	_, err = tx.ExecContext(ctx, `delete from rooms where id = $1`, id)
 	if err != nil {
-		return newResv, err
-	}
-	defer row.Close()
-
-	for row.Next() {
-		var rs models.Reservation
-		//scan the database respectively with the query parameters
-		err = row.Scan(
-			&rs.ID,
-			&rs.FirstName,
-			&rs.LastName,
-			&rs.Email,
-			&rs.PhoneNumber,
-			&rs.RoomID,
-			&rs.CheckInDate,
-			&rs.CheckOutDate,
-			&rs.UpdatedAt,
-			&rs.CreatedAt,
-			&rs.Processed,
-		)
-		if err != nil {
-			return newResv, err
-		}
-		newResv = append(newResv, rs)
-	}
-	if err = row.Err(); err != nil {
-		return newResv, err
+		return err
 	}
-	return newResv, nil
+	return tx.Commit()
 }
 
-func (pg *PostgresDBRepository) ShowUserReservation(id int) (models.Reservation, error) {
-	var userResv models.Reservation
+//InsertRoomPhoto adds the photo at the end of the gallery of its room
+func (pg *PostgresDBRepository) InsertRoomPhoto(photo models.RoomPhoto) (int, error) {
 	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelCtx()
 
-	query := `select r.id,
-       r.first_name,
-       r.last_name,
-       r.email,
-       r.phone_number,
-       r.room_id,
-       r.check_in_date,
-       r.check_out_date,
-       r.updated_at,
-       r.created_at,
-       r.processed,
-       rm.room_name,
-       rm.id
-from reservation r
-         left join rooms rm on (rm.id = r.room_id)
-where r.id = $1`
-	row := pg.DB.QueryRowContext(ctx, query, id)
-	err := row.Scan(
-		&userResv.ID,
-		&userResv.FirstName,
-		&userResv.LastName,
-		&userResv.Email,
-		&userResv.PhoneNumber,
-		&userResv.RoomID,
-		&userResv.CheckInDate,
-		&userResv.CheckOutDate,
-		&userResv.UpdatedAt,
-		&userResv.CreatedAt,
-		&userResv.Processed,
-		&userResv.Room.RoomName,
-		&userResv.Room.ID,
-	)
+	stmt := `insert into room_photo (room_id, path, thumbnail_path, caption, position, created_at, updated_at)
+              values ($1, $2, $3, $4,
+                      (select coalesce(max(position), 0) + 1 from room_photo where room_id = $1), $5, $6)
+              returning id`
+	var newID int
+	err := pg.DB.QueryRowContext(ctx, stmt,
+		photo.RoomID,
+		photo.Path,
+		photo.ThumbnailPath,
+		photo.Caption,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
 	if err != nil {
-		return userResv, err
+		return 0, err
 	}
-	//if err = row.Err(); err != nil {
-	//	return userResv, err
-	//}
-	return userResv, nil
-
+	return newID, nil
 }
 
-//UpdateUserReservation this modify the user reservation details from the administration
-func (pg *PostgresDBRepository) UpdateUserReservation(resv models.Reservation) error {
+//UpdateRoomPhotos saves the caption and the position of every photo of the gallery in one transaction
+func (pg *PostgresDBRepository) UpdateRoomPhotos(roomID int, photos []models.RoomPhoto) error {
 	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelCtx()
 
-	query := `update reservation set id= $1,first_name=$2, last_name=$3, email=$4, phone_number=$5, updated_at=$6 where id = $1`
-
-	_, err := pg.DB.ExecContext(ctx, query,
-		resv.ID, resv.FirstName, resv.LastName, resv.Email, resv.PhoneNumber, resv.UpdatedAt)
+	tx, err := pg.DB.BeginTx(ctx, nil)
 	if err != nil {
-		log.Println("error updateing user reservation")
 		return err
 	}
-	return nil
+	defer tx.Rollback()
+
+	query := `update room_photo set caption = $1, position = $2, updated_at = $3 where id = $4 and room_id = $5`
+	for _, photo := range photos {
+		result, err := tx.ExecContext(ctx, query, photo.Caption, photo.Position, time.Now(), photo.ID, roomID)
+		if err != nil {
+			return err
+		}
+		if n, _ := result.RowsAffected(); n == 0 {
+			return errors.Errorf("photo %d is not in the gallery of room %d", photo.ID, roomID)
+		}
+	}
+	return tx.Commit()
 }
 
-//DeleteUserReservation  this delete the user reservation from the administration
-func (pg *PostgresDBRepository) DeleteUserReservation(id int) error {
+//DeleteRoomPhoto removes the photo from the gallery of the room and returns it so its files can be removed
+func (pg *PostgresDBRepository) DeleteRoomPhoto(roomID, photoID int) (models.RoomPhoto, error) {
+	var photo models.RoomPhoto
 	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelCtx()
 
-	query := `delete from reservation where id = $1`
-	_, err := pg.DB.ExecContext(ctx, query, id)
+	query := `delete from room_photo where id = $1 and room_id = $2
+              returning id, room_id, path, thumbnail_path, caption, position, created_at, updated_at`
+	err := pg.DB.QueryRowContext(ctx, query, photoID, roomID).Scan(&photo.ID, &photo.RoomID, &photo.Path,
+		&photo.ThumbnailPath, &photo.Caption, &photo.Position, &photo.CreatedAt, &photo.UpdatedAt)
 	if err != nil {
-		log.Println("error cannot delete reservation as requested")
-		return err
+		return photo, err
 	}
-	return nil
+	return photo, nil
 }
 
-//
-func (pg *PostgresDBRepository) ProcessedUpdateReservation(id int, processed int) error {
+//InsertWaitlistEntry adds the guest at the end of the waitlist, a zero room id means any room
+func (pg *PostgresDBRepository) InsertWaitlistEntry(entry models.WaitlistEntry) (int, error) {
 	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelCtx()
 
-	query := `update reservation set processed = $1 where id = $2`
-
-	_, err := pg.DB.ExecContext(ctx, query, processed, id)
+	stmt := `insert into waitlist (first_name, last_name, email, check_in_date, check_out_date, room_id, adults,
+                     children, status, created_at, updated_at)
+              values ($1, $2, $3, $4, $5, nullif($6, 0), $7, $8, $9, $10, $11) returning id`
+	var newID int
+	err := pg.DB.QueryRowContext(ctx, stmt,
+		entry.FirstName,
+		entry.LastName,
+		entry.Email,
+		entry.CheckInDate,
+		entry.CheckOutDate,
+		entry.RoomID,
+		entry.Adults,
+		entry.Children,
+		models.WaitlistWaiting,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
 	if err != nil {
-		log.Println("error processing user reservation update")
-		return err
+		return 0, err
 	}
-	return nil
+	return newID, nil
 }
 
-// GetRestrictionsForRoomByDate returns restrictions for a room by date range
-func (pg *PostgresDBRepository) GetRestrictionsForRoomByDate(roomID int, checkInDate, checkOutDate time.Time) ([]models.RoomRestriction, error) {
-	var restrictions []models.RoomRestriction
+//waitlistColumns the columns scanned by scanWaitlistEntry
+const waitlistColumns = `w.id, w.first_name, w.last_name, w.email, w.check_in_date, w.check_out_date,
+       coalesce(w.room_id, 0), w.adults, w.children, w.status, w.token, coalesce(w.offered_room_id, 0),
+       coalesce(w.hold_id, 0), w.hold_expires_at, w.created_at, w.updated_at, coalesce(r.room_name, '')`
 
-	ctx, cancelCtx := context.WithTimeout(context.Background(), 3*time.Second)
-	defer cancelCtx()
+func scanWaitlistEntry(row interface{ Scan(...interface{}) error }) (models.WaitlistEntry, error) {
+	var entry models.WaitlistEntry
+	var expiresAt sql.NullTime
+	err := row.Scan(&entry.ID, &entry.FirstName, &entry.LastName, &entry.Email, &entry.CheckInDate,
+		&entry.CheckOutDate, &entry.RoomID, &entry.Adults, &entry.Children, &entry.Status, &entry.Token,
+		&entry.OfferedRoomID, &entry.HoldID, &expiresAt, &entry.CreatedAt, &entry.UpdatedAt, &entry.Room.RoomName)
+	if expiresAt.Valid {
+		entry.HoldExpiresAt = expiresAt.Time
+	}
+	entry.Room.ID = entry.OfferedRoomID
+	return entry, err
+}
 
-	query := `
-		select id, coalesce(reservation_id, 0), restriction_id, room_id, check_in_date, check_out_date
-		from room_restriction where $1 < check_out_date and $2 >= check_in_date
-		and room_id = $3
-`
+//WaitlistEntries returns the entries in the status, the oldest first, the room is the offered one
+func (pg *PostgresDBRepository) WaitlistEntries(status string) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
 
-	rows, err := pg.DB.QueryContext(ctx, query, checkInDate, checkOutDate, roomID)
+	query := `select ` + waitlistColumns + `
+              from waitlist w
+              left join rooms r on (r.id = w.offered_room_id)
+              where w.status = $1
+              order by w.created_at, w.id`
+	rows, err := pg.DB.QueryContext(ctx, query, status)
 	if err != nil {
-		return nil, err
+		return entries, err
 	}
 	defer rows.Close()
 
 	for rows.Next() {
-		var r models.RoomRestriction
+		entry, err := scanWaitlistEntry(rows)
+		if err != nil {
+			return entries, err
+		}
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+//GetWaitlistEntryByToken returns the entry the hold link was sent to
+func (pg *PostgresDBRepository) GetWaitlistEntryByToken(token string) (models.WaitlistEntry, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `select ` + waitlistColumns + `
+              from waitlist w
+              left join rooms r on (r.id = w.offered_room_id)
+              where w.token = $1 and w.token <> ''`
+	return scanWaitlistEntry(pg.DB.QueryRowContext(ctx, query, token))
+}
+
+//OfferWaitlistEntry holds the room for the stay of a waiting guest until the hold expires and offers it to
+//the guest in the same transaction, the id of the hold is returned
+func (pg *PostgresDBRepository) OfferWaitlistEntry(id, roomID int, token string, expiresAt time.Time) (int, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	tx, err := pg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	hold := models.RoomRestriction{RoomID: roomID, ExpiresAt: expiresAt}
+	query := `select check_in_date, check_out_date from waitlist where id = $1 and status = $2 for update`
+	err = tx.QueryRowContext(ctx, query, id, models.WaitlistWaiting).Scan(&hold.CheckInDate, &hold.CheckOutDate)
+	if err == sql.ErrNoRows {
+		return 0, errors.Errorf("waitlist entry %d is no longer waiting", id)
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	holdID, err := insertHold(ctx, tx, hold)
+	if err != nil {
+		return 0, err
+	}
+
+	stmt := `update waitlist set status = $1, offered_room_id = $2, token = $3, hold_id = $4, hold_expires_at = $5,
+             updated_at = $6 where id = $7`
+	_, err = tx.ExecContext(ctx, stmt, models.WaitlistOffered, roomID, token, holdID, expiresAt, time.Now(), id)
+	if err != nil {
+		return 0, err
+	}
+	return holdID, tx.Commit()
+}
+
+//ExpireWaitlistOffers closes the offers whose hold expired before now and returns how many were closed
+func (pg *PostgresDBRepository) ExpireWaitlistOffers(now time.Time) (int, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	stmt := `update waitlist set status = $1, updated_at = $2 where status = $3 and hold_expires_at < $4`
+	result, err := pg.DB.ExecContext(ctx, stmt, models.WaitlistExpired, time.Now(), models.WaitlistOffered, now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+//UpdateWaitlistStatus moves the entry to the status
+func (pg *PostgresDBRepository) UpdateWaitlistStatus(id int, status string) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	_, err := pg.DB.ExecContext(ctx, `update waitlist set status = $1, updated_at = $2 where id = $3`,
+		status, time.Now(), id)
+	return err
+}
+
+//bookingRuleColumns the columns scanned by scanBookingRules
+const bookingRuleColumns = `id, room_id, start_date, end_date, min_nights, max_nights, closed_to_arrival,
+       closed_to_departure, lead_days, max_advance_days, created_at, updated_at`
+
+func scanBookingRules(rows *sql.Rows) ([]models.BookingRule, error) {
+	var rules []models.BookingRule
+	defer rows.Close()
+	for rows.Next() {
+		var rule models.BookingRule
+		err := rows.Scan(&rule.ID, &rule.RoomID, &rule.StartDate, &rule.EndDate, &rule.MinNights, &rule.MaxNights,
+			&rule.ClosedToArrival, &rule.ClosedToDeparture, &rule.LeadDays, &rule.MaxAdvanceDays,
+			&rule.CreatedAt, &rule.UpdatedAt)
+		if err != nil {
+			return rules, err
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+//BookingRules returns the booking rules of every room in force on at least one day between the start
+//date and the end date included, keyed by room id
+func (pg *PostgresDBRepository) BookingRules(startDate, endDate time.Time) (map[int][]models.BookingRule, error) {
+	byRoom := make(map[int][]models.BookingRule)
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `select ` + bookingRuleColumns + ` from booking_rule
+       where start_date <= $2 and end_date >= $1 order by room_id, start_date`
+	rows, err := pg.DB.QueryContext(ctx, query, startDate, endDate)
+	if err != nil {
+		return byRoom, err
+	}
+	rules, err := scanBookingRules(rows)
+	if err != nil {
+		return byRoom, err
+	}
+	for _, rule := range rules {
+		byRoom[rule.RoomID] = append(byRoom[rule.RoomID], rule)
+	}
+	return byRoom, nil
+}
+
+//BookingRulesByRoom returns every booking rule of the room
+func (pg *PostgresDBRepository) BookingRulesByRoom(roomID int) ([]models.BookingRule, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `select ` + bookingRuleColumns + ` from booking_rule where room_id = $1 order by start_date, id`
+	rows, err := pg.DB.QueryContext(ctx, query, roomID)
+	if err != nil {
+		return nil, err
+	}
+	return scanBookingRules(rows)
+}
+
+//InsertBookingRule adds a booking rule to a room
+func (pg *PostgresDBRepository) InsertBookingRule(rule models.BookingRule) (int, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	stmt := `insert into booking_rule (room_id, start_date, end_date, min_nights, max_nights, closed_to_arrival,
+                          closed_to_departure, lead_days, max_advance_days, created_at, updated_at)
+              values ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) returning id`
+	var newID int
+	err := pg.DB.QueryRowContext(ctx, stmt,
+		rule.RoomID,
+		rule.StartDate,
+		rule.EndDate,
+		rule.MinNights,
+		rule.MaxNights,
+		rule.ClosedToArrival,
+		rule.ClosedToDeparture,
+		rule.LeadDays,
+		rule.MaxAdvanceDays,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+	return newID, nil
+}
+
+//DeleteBookingRule removes a booking rule of the room
+func (pg *PostgresDBRepository) DeleteBookingRule(roomID, ruleID int) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	_, err := pg.DB.ExecContext(ctx, `delete from booking_rule where id = $1 and room_id = $2`, ruleID, roomID)
+	return err
+}
+
+//taxRuleColumns the columns scanned by scanTaxRules
+const taxRuleColumns = `id, coalesce(room_id, 0), name, kind, basis, per, rate, amount, start_date, end_date,
+       created_at, updated_at`
+
+//scanTaxRules reads the tax rules selected with taxRuleColumns
+func scanTaxRules(rows *sql.Rows) ([]models.TaxRule, error) {
+	var rules []models.TaxRule
+	defer rows.Close()
+
+	for rows.Next() {
+		var rule models.TaxRule
+		var endDate sql.NullTime
+		err := rows.Scan(&rule.ID, &rule.RoomID, &rule.Name, &rule.Kind, &rule.Basis, &rule.Per, &rule.Rate,
+			&rule.Amount, &rule.StartDate, &endDate, &rule.CreatedAt, &rule.UpdatedAt)
+		if err != nil {
+			return rules, err
+		}
+		rule.EndDate = endDate.Time
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+//TaxRules returns the taxes and fees in force on at least one day between the start date and the end
+//date included, the taxes first
+func (pg *PostgresDBRepository) TaxRules(startDate, endDate time.Time) ([]models.TaxRule, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `select ` + taxRuleColumns + `
+       from tax_rule
+       where start_date <= $2 and (end_date is null or end_date >= $1)
+       order by kind desc, name, id`
+	rows, err := pg.DB.QueryContext(ctx, query, startDate, endDate)
+	if err != nil {
+		return nil, err
+	}
+	return scanTaxRules(rows)
+}
+
+//AllTaxRules returns every tax and fee, the latest first
+func (pg *PostgresDBRepository) AllTaxRules() ([]models.TaxRule, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `select ` + taxRuleColumns + ` from tax_rule order by start_date desc, kind desc, name`
+	rows, err := pg.DB.QueryContext(ctx, query)
+	if err != nil {
+		return nil, err
+	}
+	return scanTaxRules(rows)
+}
+
+//InsertTaxRule adds a tax or a fee
+func (pg *PostgresDBRepository) InsertTaxRule(rule models.TaxRule) (int, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	var endDate sql.NullTime
+	if !rule.EndDate.IsZero() {
+		endDate = sql.NullTime{Time: rule.EndDate, Valid: true}
+	}
+	stmt := `insert into tax_rule (room_id, name, kind, basis, per, rate, amount, start_date, end_date,
+                      created_at, updated_at)
+              values (nullif($1, 0),$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) returning id`
+	var newID int
+	err := pg.DB.QueryRowContext(ctx, stmt,
+		rule.RoomID,
+		rule.Name,
+		rule.Kind,
+		rule.Basis,
+		rule.Per,
+		rule.Rate,
+		rule.Amount,
+		rule.StartDate,
+		endDate,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+	return newID, nil
+}
+
+//DeleteTaxRule removes a tax or a fee, the reservations keep the charges they were booked with
+func (pg *PostgresDBRepository) DeleteTaxRule(id int) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	_, err := pg.DB.ExecContext(ctx, `delete from tax_rule where id = $1`, id)
+	return err
+}
+
+//promoCodeColumns the columns scanned by scanPromoCode
+const promoCodeColumns = `id, code, description, basis, rate, amount, min_nights, max_redemptions, redemptions,
+       start_date, end_date, created_at, updated_at`
+
+//scanPromoCode reads a promo code selected with promoCodeColumns
+func scanPromoCode(row interface{ Scan(...interface{}) error }) (models.PromoCode, error) {
+	var promo models.PromoCode
+	var endDate sql.NullTime
+	err := row.Scan(&promo.ID, &promo.Code, &promo.Description, &promo.Basis, &promo.Rate, &promo.Amount,
+		&promo.MinNights, &promo.MaxRedemptions, &promo.Redemptions, &promo.StartDate, &endDate,
+		&promo.CreatedAt, &promo.UpdatedAt)
+	promo.EndDate = endDate.Time
+	return promo, err
+}
+
+//AllPromoCodes returns every promo code with its redemptions, the latest first
+func (pg *PostgresDBRepository) AllPromoCodes() ([]models.PromoCode, error) {
+	var promos []models.PromoCode
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	rows, err := pg.DB.QueryContext(ctx, `select `+promoCodeColumns+` from promo_code order by start_date desc, code`)
+	if err != nil {
+		return promos, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		promo, err := scanPromoCode(rows)
+		if err != nil {
+			return promos, err
+		}
+		promos = append(promos, promo)
+	}
+	return promos, rows.Err()
+}
+
+//GetPromoCodeByCode returns the promo code typed by a guest, the case of the code doesn't matter
+func (pg *PostgresDBRepository) GetPromoCodeByCode(code string) (models.PromoCode, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	row := pg.DB.QueryRowContext(ctx, `select `+promoCodeColumns+` from promo_code where code = upper($1)`, code)
+	return scanPromoCode(row)
+}
+
+//InsertPromoCode adds a promo code, the code is kept in upper case
+func (pg *PostgresDBRepository) InsertPromoCode(promo models.PromoCode) (int, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	var endDate sql.NullTime
+	if !promo.EndDate.IsZero() {
+		endDate = sql.NullTime{Time: promo.EndDate, Valid: true}
+	}
+	stmt := `insert into promo_code (code, description, basis, rate, amount, min_nights, max_redemptions,
+                      start_date, end_date, created_at, updated_at)
+              values (upper($1),$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) returning id`
+	var newID int
+	err := pg.DB.QueryRowContext(ctx, stmt,
+		promo.Code,
+		promo.Description,
+		promo.Basis,
+		promo.Rate,
+		promo.Amount,
+		promo.MinNights,
+		promo.MaxRedemptions,
+		promo.StartDate,
+		endDate,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+	return newID, nil
+}
+
+//DeletePromoCode removes a promo code, the reservations keep the discount they were booked with
+func (pg *PostgresDBRepository) DeletePromoCode(id int) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	_, err := pg.DB.ExecContext(ctx, `delete from promo_code where id = $1`, id)
+	return err
+}
+
+//RedeemPromoCode counts a use of the promo code, a code used up by another booking in the meantime
+//isn't counted and returns an error
+func (pg *PostgresDBRepository) RedeemPromoCode(id int) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	stmt := `update promo_code set redemptions = redemptions + 1, updated_at = $2
+             where id = $1 and (max_redemptions = 0 or redemptions < max_redemptions)`
+	result, err := pg.DB.ExecContext(ctx, stmt, id, time.Now())
+	if err != nil {
+		return err
+	}
+	redeemed, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if redeemed == 0 {
+		return errors.New("the promo code was used up")
+	}
+	return nil
+}
+
+//ReleasePromoCode gives back a use of the promo code counted for a booking that wasn't made
+func (pg *PostgresDBRepository) ReleasePromoCode(id int) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	stmt := `update promo_code set redemptions = redemptions - 1, updated_at = $2
+             where id = $1 and redemptions > 0`
+	_, err := pg.DB.ExecContext(ctx, stmt, id, time.Now())
+	return err
+}
+
+//AllExchangeRates returns the stored exchange rates by currency
+func (pg *PostgresDBRepository) AllExchangeRates() ([]models.ExchangeRate, error) {
+	var rates []models.ExchangeRate
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	rows, err := pg.DB.QueryContext(ctx, `select id, currency, rate, created_at, updated_at from exchange_rate order by currency`)
+	if err != nil {
+		return rates, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var rate models.ExchangeRate
+		err := rows.Scan(&rate.ID, &rate.Currency, &rate.Rate, &rate.CreatedAt, &rate.UpdatedAt)
+		if err != nil {
+			return rates, err
+		}
+		rates = append(rates, rate)
+	}
+	return rates, rows.Err()
+}
+
+//UpsertExchangeRates stores the rates, the rate of a currency already stored is replaced. Either every
+//rate is stored or none
+func (pg *PostgresDBRepository) UpsertExchangeRates(rates []models.ExchangeRate) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	tx, err := pg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt := `insert into exchange_rate (currency, rate, created_at, updated_at) values ($1,$2,$3,$3)
+              on conflict (currency) do update set rate = excluded.rate, updated_at = excluded.updated_at`
+	for _, rate := range rates {
+		_, err = tx.ExecContext(ctx, stmt, rate.Currency, rate.Rate, time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+//DeleteExchangeRate removes the rate of the currency, the prices can't be displayed in it anymore
+func (pg *PostgresDBRepository) DeleteExchangeRate(currency string) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	_, err := pg.DB.ExecContext(ctx, `delete from exchange_rate where currency = $1`, currency)
+	return err
+}
+
+//InsertReservationCharges keeps the price of the reservation as it was booked
+func (pg *PostgresDBRepository) InsertReservationCharges(resvID int, charges []models.ReservationCharge) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	tx, err := pg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	stmt := `insert into reservation_charge (reservation_id, name, kind, quantity, amount, created_at, updated_at)
+              values ($1,$2,$3,$4,$5,$6,$7)`
+	for _, charge := range charges {
+		_, err = tx.ExecContext(ctx, stmt, resvID, charge.Name, charge.Kind, charge.Quantity, charge.Amount,
+			time.Now(), time.Now())
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+//ReservationCharges returns the price of the reservation, the room nights first
+func (pg *PostgresDBRepository) ReservationCharges(resvID int) ([]models.ReservationCharge, error) {
+	var charges []models.ReservationCharge
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `select id, reservation_id, name, kind, quantity, amount, created_at, updated_at
+       from reservation_charge where reservation_id = $1
+       order by kind <> 'room', id`
+	rows, err := pg.DB.QueryContext(ctx, query, resvID)
+	if err != nil {
+		return charges, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var charge models.ReservationCharge
+		err := rows.Scan(&charge.ID, &charge.ReservationID, &charge.Name, &charge.Kind, &charge.Quantity,
+			&charge.Amount, &charge.CreatedAt, &charge.UpdatedAt)
+		if err != nil {
+			return charges, err
+		}
+		charges = append(charges, charge)
+	}
+	return charges, rows.Err()
+}
+
+//RoomOccupiedNights returns every room with the nights between the start date and the end date
+//(not included) on which the room is reserved or blocked
+func (pg *PostgresDBRepository) RoomOccupiedNights(startDate, endDate time.Time) ([]models.RoomNights, error) {
+	var rooms []models.RoomNights
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `select rm.id, rm.room_name, rm.max_adults, rm.max_children, n.night
+       from rooms rm
+         left join room_restriction rr on (rr.room_id = rm.id and rr.check_in_date < $2 and rr.check_out_date > $1
+                                           and (rr.expires_at is null or rr.expires_at > $3))
+         left join lateral generate_series(greatest(rr.check_in_date, $1::date),
+                                           least(rr.check_out_date, $2::date) - 1,
+                                           interval '1 day') as n(night) on true
+       order by rm.room_name, rm.id`
+	rows, err := pg.DB.QueryContext(ctx, query, startDate, endDate, time.Now())
+	if err != nil {
+		return rooms, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var room models.Room
+		var night sql.NullTime
+		err = rows.Scan(&room.ID, &room.RoomName, &room.MaxAdults, &room.MaxChildren, &night)
+		if err != nil {
+			return rooms, err
+		}
+		if len(rooms) == 0 || rooms[len(rooms)-1].Room.ID != room.ID {
+			rooms = append(rooms, models.RoomNights{Room: room, Occupied: make(map[string]bool)})
+		}
+		if night.Valid {
+			rooms[len(rooms)-1].Occupied[night.Time.Format("2006-01-02")] = true
+		}
+	}
+	if err = rows.Err(); err != nil {
+		return rooms, err
+	}
+	return rooms, nil
+}
+
+func (pg *PostgresDBRepository) GetUserInfoByID(userID int) (models.User, error) {
+	var user models.User
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `select id, first_name, last_name, email, password, created_at, updated_at, access_level from user where id = $1`
+	row := pg.DB.QueryRowContext(ctx, query, userID)
+	err := row.Scan(&user.ID, &user.FirstName, &user.LastName, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt, &user.AccessLevel)
+
+	if err != nil {
+		return models.User{}, err
+	}
+	return user, nil
+}
+
+//UpdateUserInfo to Update the users information or details in the database
+func (pg *PostgresDBRepository) UpdateUserInfo(user models.User) error {
+	//var user models.User
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `update users set first_name= $1, last_name=$2,email=$3,updated_at=$4, access_level=$5`
+	_, err := pg.DB.ExecContext(ctx, query, user.FirstName, user.LastName, user.Email, user.UpdatedAt, user.AccessLevel)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+//AuthenticateUser to Athenticate the user by verifying the email and the Password
+func (pg *PostgresDBRepository) AuthenticateUser(typedPassword, email string) (int, string, error) {
+	var userID int
+	var hashedPassword string
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+	query := `select id, password from users where email= $1`
+	row := pg.DB.QueryRowContext(ctx, query, email)
+
+	//scan the database respectively with the query parameters
+	err := row.Scan(&userID, &hashedPassword)
+	if err != nil {
+		return userID, "", err
+	}
+	err = bcrypt.CompareHashAndPassword([]byte(hashedPassword), []byte(typedPassword))
+	//If the password did not match
+	if err == bcrypt.ErrMismatchedHashAndPassword {
+		return 0, "", errors.New("incorrect password!")
+	} else if err != nil {
+		return 0, "", err
+	}
+	return userID, hashedPassword, nil
+
+}
+
+/*DataBase Functions for the administration pages */
+
+//AllReservation this show all the registered resservations in the database
+func (pg *PostgresDBRepository) AllReservation() ([]models.Reservation, error) {
+	var allResv []models.Reservation
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+	query := `select r.id,
+       r.first_name,
+       r.last_name,
+       r.email,
+       r.phone_number,
+       r.room_id,
+       r.check_in_date,
+       r.check_out_date,
+       r.updated_at,
+       r.created_at,
+       r.processed,
+       r.adults,
+       r.children
+       from reservation r
+         left join rooms rm on (r.room_id = rm.id)
+       order by r.check_in_date`
+	row, err := pg.DB.QueryContext(ctx, query)
+	if err != nil {
+		return allResv, err
+	}
+	defer row.Close()
+
+	for row.Next() {
+		var rs models.Reservation
+		//scan the database respectively with the query parameters
+
+		err = row.Scan(
+			&rs.ID,
+			&rs.FirstName,
+			&rs.LastName,
+			&rs.Email,
+			&rs.PhoneNumber,
+			&rs.RoomID,
+			&rs.CheckInDate,
+			&rs.CheckOutDate,
+			//&rs.Room.RoomName,
+			&rs.CreatedAt,
+			&rs.UpdatedAt,
+			&rs.Processed,
+			&rs.Adults,
+			&rs.Children,
+		)
+		if err != nil {
+			return allResv, err
+		}
+		allResv = append(allResv, rs)
+	}
+	if err = row.Err(); err != nil {
+		return allResv, err
+	}
+	return allResv, nil
+}
+
+//AllNewReservation this helps to get all the newly registered reservation in the database
+func (pg *PostgresDBRepository) AllNewReservation() ([]models.Reservation, error) {
+	var newResv []models.Reservation
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+	query := `select r.id,
+       r.first_name,
+       r.last_name,
+       r.email,
+       r.phone_number,
+       r.room_id,
+       r.check_in_date,
+       r.check_out_date,
+       r.updated_at, 
+       r.created_at,
+       r.processed,
+       r.adults,
+       r.children
+       from reservation r
+           left join rooms rm on (r.room_id = rm.id)
+           where r.processed = 0
+           order by r.check_in_date`
+	row, err := pg.DB.QueryContext(ctx, query)
+	if err != nil {
+		return newResv, err
+	}
+	defer row.Close()
+
+	for row.Next() {
+		var rs models.Reservation
+		//scan the database respectively with the query parameters
+		err = row.Scan(
+			&rs.ID,
+			&rs.FirstName,
+			&rs.LastName,
+			&rs.Email,
+			&rs.PhoneNumber,
+			&rs.RoomID,
+			&rs.CheckInDate,
+			&rs.CheckOutDate,
+			&rs.UpdatedAt,
+			&rs.CreatedAt,
+			&rs.Processed,
+			&rs.Adults,
+			&rs.Children,
+		)
+		if err != nil {
+			return newResv, err
+		}
+		newResv = append(newResv, rs)
+	}
+	if err = row.Err(); err != nil {
+		return newResv, err
+	}
+	return newResv, nil
+}
+
+func (pg *PostgresDBRepository) ShowUserReservation(id int) (models.Reservation, error) {
+	var userResv models.Reservation
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `select r.id,
+       r.first_name,
+       r.last_name,
+       r.email,
+       r.phone_number,
+       r.room_id,
+       r.check_in_date,
+       r.check_out_date,
+       r.updated_at,
+       r.created_at,
+       r.processed,
+       coalesce(r.booking_group_id, 0),
+       r.adults,
+       r.children,
+       r.cancelled_at,
+       r.refund_amount,
+       rm.room_name,
+       rm.id
+from reservation r
+         left join rooms rm on (rm.id = r.room_id)
+where r.id = $1`
+	var cancelledAt sql.NullTime
+	row := pg.DB.QueryRowContext(ctx, query, id)
+	err := row.Scan(
+		&userResv.ID,
+		&userResv.FirstName,
+		&userResv.LastName,
+		&userResv.Email,
+		&userResv.PhoneNumber,
+		&userResv.RoomID,
+		&userResv.CheckInDate,
+		&userResv.CheckOutDate,
+		&userResv.UpdatedAt,
+		&userResv.CreatedAt,
+		&userResv.Processed,
+		&userResv.BookingGroupID,
+		&userResv.Adults,
+		&userResv.Children,
+		&cancelledAt,
+		&userResv.RefundAmount,
+		&userResv.Room.RoomName,
+		&userResv.Room.ID,
+	)
+	if err != nil {
+		return userResv, err
+	}
+	userResv.CancelledAt = cancelledAt.Time
+	//if err = row.Err(); err != nil {
+	//	return userResv, err
+	//}
+	return userResv, nil
+
+}
+
+//UpdateUserReservation this modify the user reservation details from the administration
+func (pg *PostgresDBRepository) UpdateUserReservation(resv models.Reservation) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `update reservation set id= $1,first_name=$2, last_name=$3, email=$4, phone_number=$5, updated_at=$6 where id = $1`
+
+	_, err := pg.DB.ExecContext(ctx, query,
+		resv.ID, resv.FirstName, resv.LastName, resv.Email, resv.PhoneNumber, resv.UpdatedAt)
+	if err != nil {
+		log.Println("error updateing user reservation")
+		return err
+	}
+	return nil
+}
+
+//DeleteUserReservation  this delete the user reservation from the administration
+func (pg *PostgresDBRepository) DeleteUserReservation(id int) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `delete from reservation where id = $1`
+	_, err := pg.DB.ExecContext(ctx, query, id)
+	if err != nil {
+		log.Println("error cannot delete reservation as requested")
+		return err
+	}
+	return nil
+}
+
+//CancelReservation marks the reservation as cancelled with the refund it is owed and frees its room
+func (pg *PostgresDBRepository) CancelReservation(id, refund int, cancelledAt time.Time) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	tx, err := pg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	result, err := tx.ExecContext(ctx, `update reservation set cancelled_at = $1, refund_amount = $2, updated_at = $3
+              where id = $4 and cancelled_at is null`, cancelledAt, refund, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	updated, err := result.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if updated == 0 {
+		return errors.Errorf("reservation %d is already cancelled", id)
+	}
+
+	_, err = tx.ExecContext(ctx, `delete from room_restriction where reservation_id = $1`, id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//
+func (pg *PostgresDBRepository) ProcessedUpdateReservation(id int, processed int) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `update reservation set processed = $1 where id = $2`
+
+	_, err := pg.DB.ExecContext(ctx, query, processed, id)
+	if err != nil {
+		log.Println("error processing user reservation update")
+		return err
+	}
+	return nil
+}
+
+// GetRestrictionsForRoomByDate returns restrictions for a room by date range
+func (pg *PostgresDBRepository) GetRestrictionsForRoomByDate(roomID int, checkInDate, checkOutDate time.Time) ([]models.RoomRestriction, error) {
+	var restrictions []models.RoomRestriction
+
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancelCtx()
+
+	query := `
+		select id, coalesce(reservation_id, 0), restriction_id, room_id, check_in_date, check_out_date, reason, notes,
+		expires_at, updated_at
+		from room_restriction where $1 < check_out_date and $2 >= check_in_date
+		and room_id = $3 and (expires_at is null or expires_at > $4)
+`
+
+	rows, err := pg.DB.QueryContext(ctx, query, checkInDate, checkOutDate, roomID, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var r models.RoomRestriction
+		var expiresAt sql.NullTime
 		err := rows.Scan(
 			&r.ID,
 			&r.ReservationID,
@@ -437,47 +1313,982 @@ func (pg *PostgresDBRepository) GetRestrictionsForRoomByDate(roomID int, checkIn
 			&r.RoomID,
 			&r.CheckInDate,
 			&r.CheckOutDate,
+			&r.Reason,
+			&r.Notes,
+			&expiresAt,
+			&r.UpdatedAt,
+		)
+		if err != nil {
+			return nil, err
+		}
+		r.ExpiresAt = expiresAt.Time
+		restrictions = append(restrictions, r)
+	}
+
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	return restrictions, nil
+}
+
+//CalendarEntries returns the reservations, with the name of their guest, and the blocks of every room
+//with at least one night between the start date and the end date (not included)
+func (pg *PostgresDBRepository) CalendarEntries(startDate, endDate time.Time) ([]models.RoomRestriction, error) {
+	var entries []models.RoomRestriction
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `select rr.id, rr.room_id, coalesce(rr.reservation_id, 0), rr.restriction_id, rr.check_in_date,
+                     rr.check_out_date, rr.reason, rr.notes, rr.expires_at, rr.updated_at,
+                     coalesce(r.first_name, ''), coalesce(r.last_name, ''), coalesce(r.adults, 0), coalesce(r.children, 0)
+              from room_restriction rr
+              left join reservation r on (r.id = rr.reservation_id)
+              where rr.check_in_date < $2 and rr.check_out_date > $1 and (rr.expires_at is null or rr.expires_at > $3)
+              order by rr.room_id, rr.check_in_date`
+
+	rows, err := pg.DB.QueryContext(ctx, query, startDate, endDate, time.Now())
+	if err != nil {
+		return entries, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var entry models.RoomRestriction
+		var expiresAt sql.NullTime
+		err = rows.Scan(
+			&entry.ID,
+			&entry.RoomID,
+			&entry.ReservationID,
+			&entry.RestrictionID,
+			&entry.CheckInDate,
+			&entry.CheckOutDate,
+			&entry.Reason,
+			&entry.Notes,
+			&expiresAt,
+			&entry.UpdatedAt,
+			&entry.Reservation.FirstName,
+			&entry.Reservation.LastName,
+			&entry.Reservation.Adults,
+			&entry.Reservation.Children,
+		)
+		if err != nil {
+			return entries, err
+		}
+		entry.Reservation.ID = entry.ReservationID
+		entry.ExpiresAt = expiresAt.Time
+		entries = append(entries, entry)
+	}
+	return entries, rows.Err()
+}
+
+//MoveReservation moves the reservation and its room restriction to the room and dates, the move is
+//refused when the room is reserved or blocked on one of the new nights
+func (pg *PostgresDBRepository) MoveReservation(resvID, roomID int, checkInDate, checkOutDate time.Time) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	tx, err := pg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = lockRooms(ctx, tx, roomID)
+	if err != nil {
+		return err
+	}
+
+	var conflictID, conflictRestrictionID int
+	var conflictCheckIn, conflictCheckOut time.Time
+	query := `select coalesce(reservation_id, 0), restriction_id, check_in_date, check_out_date
+              from room_restriction
+              where room_id = $1 and $2 < check_out_date and $3 > check_in_date and coalesce(reservation_id, 0) <> $4
+              and (expires_at is null or expires_at > $5)
+              order by check_in_date
+              limit 1`
+	err = tx.QueryRowContext(ctx, query, roomID, checkInDate, checkOutDate, resvID, time.Now()).Scan(
+		&conflictID, &conflictRestrictionID, &conflictCheckIn, &conflictCheckOut)
+	if err == nil {
+		if conflictRestrictionID == models.HoldRestrictionID {
+			return errors.Errorf("room %d is held for a guest booking it from %s to %s", roomID,
+				conflictCheckIn.Format("2006-01-02"), conflictCheckOut.Format("2006-01-02"))
+		}
+		if conflictID > 0 {
+			return errors.Errorf("room %d is reserved from %s to %s by reservation %d", roomID,
+				conflictCheckIn.Format("2006-01-02"), conflictCheckOut.Format("2006-01-02"), conflictID)
+		}
+		return errors.Errorf("room %d is blocked from %s to %s", roomID,
+			conflictCheckIn.Format("2006-01-02"), conflictCheckOut.Format("2006-01-02"))
+	}
+	if err != sql.ErrNoRows {
+		return err
+	}
+
+	result, err := tx.ExecContext(ctx, `update reservation set room_id = $1, check_in_date = $2, check_out_date = $3,
+              updated_at = $4 where id = $5`, roomID, checkInDate, checkOutDate, time.Now(), resvID)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return errors.Errorf("reservation %d doesn't exist", resvID)
+	}
+
+	_, err = tx.ExecContext(ctx, `update room_restriction set room_id = $1, check_in_date = $2, check_out_date = $3,
+              updated_at = $4 where reservation_id = $5`, roomID, checkInDate, checkOutDate, time.Now(), resvID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//GetBlockByID returns the owner block with its room
+func (pg *PostgresDBRepository) GetBlockByID(id int) (models.RoomRestriction, error) {
+	var block models.RoomRestriction
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancelCtx()
+
+	query := `select rr.id, rr.room_id, rr.restriction_id, rr.check_in_date, rr.check_out_date, rr.reason, rr.notes,
+                     rr.created_at, rr.updated_at, r.id, r.room_name
+              from room_restriction rr
+              left join rooms r on (r.id = rr.room_id)
+              where rr.id = $1 and rr.reservation_id is null and rr.restriction_id <> $2`
+	err := pg.DB.QueryRowContext(ctx, query, id, models.HoldRestrictionID).Scan(
+		&block.ID,
+		&block.RoomID,
+		&block.RestrictionID,
+		&block.CheckInDate,
+		&block.CheckOutDate,
+		&block.Reason,
+		&block.Notes,
+		&block.CreatedAt,
+		&block.UpdatedAt,
+		&block.Room.ID,
+		&block.Room.RoomName,
+	)
+	if err != nil {
+		return block, err
+	}
+	return block, nil
+}
+
+//checkBlockOverlap returns an error when the block shares a night with a reservation or another
+//block of the same room, the room stays locked until the transaction ends
+func checkBlockOverlap(ctx context.Context, tx *sql.Tx, block models.RoomRestriction) error {
+	err := lockRooms(ctx, tx, block.RoomID)
+	if err != nil {
+		return err
+	}
+	query := `select coalesce(reservation_id, 0), restriction_id, check_in_date, check_out_date
+              from room_restriction
+              where room_id = $1 and $2 < check_out_date and $3 > check_in_date and id <> $4
+              and (expires_at is null or expires_at > $5)
+              order by check_in_date
+              limit 1`
+	var reservationID, restrictionID int
+	var checkInDate, checkOutDate time.Time
+	err = tx.QueryRowContext(ctx, query, block.RoomID, block.CheckInDate, block.CheckOutDate, block.ID, time.Now()).Scan(
+		&reservationID, &restrictionID, &checkInDate, &checkOutDate)
+	if err == sql.ErrNoRows {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if restrictionID == models.HoldRestrictionID {
+		return errors.Errorf("the block overlaps a room held for a guest booking it from %s to %s",
+			checkInDate.Format("2006-01-02"), checkOutDate.Format("2006-01-02"))
+	}
+	if reservationID > 0 {
+		return errors.Errorf("the block overlaps reservation %d from %s to %s", reservationID,
+			checkInDate.Format("2006-01-02"), checkOutDate.Format("2006-01-02"))
+	}
+	return errors.Errorf("the block overlaps another block from %s to %s",
+		checkInDate.Format("2006-01-02"), checkOutDate.Format("2006-01-02"))
+}
+
+//InsertBlock blocks the room from the check-in date up to the check-out date (not included), the
+//block is refused when it overlaps a reservation or another block
+func (pg *PostgresDBRepository) InsertBlock(block models.RoomRestriction) (int, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	tx, err := pg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	err = checkBlockOverlap(ctx, tx, block)
+	if err != nil {
+		return 0, err
+	}
+
+	newID, err := insertBlock(ctx, tx, block)
+	if err != nil {
+		return 0, err
+	}
+	return newID, tx.Commit()
+}
+
+func insertBlock(ctx context.Context, tx *sql.Tx, block models.RoomRestriction) (int, error) {
+	query := `insert into room_restriction (check_in_date, check_out_date, room_id, restriction_id, reason, notes,
+			created_at, updated_at) values ($1, $2, $3, $4, $5, $6, $7, $8) returning id`
+	var newID int
+	err := tx.QueryRowContext(ctx, query,
+		block.CheckInDate,
+		block.CheckOutDate,
+		block.RoomID,
+		models.OwnerRestrictionID,
+		block.Reason,
+		block.Notes,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
+	return newID, err
+}
+
+//UpdateCalendarBlocks removes and adds the blocks of the calendar in a single transaction. A removed
+//block must not have been updated since it was read, its UpdatedAt is checked against the database.
+//If any block was changed or overlaps a reservation nothing is saved
+func (pg *PostgresDBRepository) UpdateCalendarBlocks(adds, removes []models.RoomRestriction) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelCtx()
+
+	tx, err := pg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, block := range removes {
+		result, err := tx.ExecContext(ctx, `delete from room_restriction
+              where id = $1 and reservation_id is null and restriction_id <> $2 and updated_at = $3`, block.ID,
+			models.HoldRestrictionID, block.UpdatedAt)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err == nil && n == 0 {
+			return errors.Errorf("the block of room %d from %s was changed or removed by someone else", block.RoomID,
+				block.CheckInDate.Format("2006-01-02"))
+		}
+	}
+
+	var roomIDs []int
+	for _, block := range adds {
+		roomIDs = append(roomIDs, block.RoomID)
+	}
+	err = lockRooms(ctx, tx, roomIDs...)
+	if err != nil {
+		return err
+	}
+
+	for _, block := range adds {
+		err = checkBlockOverlap(ctx, tx, block)
+		if err != nil {
+			return errors.Wrapf(err, "room %d", block.RoomID)
+		}
+		_, err = insertBlock(ctx, tx, block)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+//UpdateBlock changes the room, the dates and the reason of the block, the new dates are refused when
+//they overlap a reservation or another block
+func (pg *PostgresDBRepository) UpdateBlock(block models.RoomRestriction) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	tx, err := pg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	err = checkBlockOverlap(ctx, tx, block)
+	if err != nil {
+		return err
+	}
+
+	query := `update room_restriction set room_id = $1, check_in_date = $2, check_out_date = $3, reason = $4,
+              notes = $5, updated_at = $6
+              where id = $7 and reservation_id is null and restriction_id <> $8`
+	result, err := tx.ExecContext(ctx, query,
+		block.RoomID,
+		block.CheckInDate,
+		block.CheckOutDate,
+		block.Reason,
+		block.Notes,
+		time.Now(),
+		block.ID,
+		models.HoldRestrictionID,
+	)
+	if err != nil {
+		return err
+	}
+	if n, err := result.RowsAffected(); err == nil && n == 0 {
+		return sql.ErrNoRows
+	}
+	return tx.Commit()
+}
+
+// DeleteBlockByID deletes an owner block, the restrictions of the reservations are left alone
+func (pg *PostgresDBRepository) DeleteBlockByID(id int) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancelCtx()
+
+	query := `delete from room_restriction where id = $1 and reservation_id is null and restriction_id <> $2`
+
+	_, err := pg.DB.ExecContext(ctx, query, id, models.HoldRestrictionID)
+	if err != nil {
+		log.Println(err)
+		return err
+	}
+	return nil
+}
+
+//InsertReservationsWithRestrictions inserts every reservation and its room restriction in a single
+//transaction, if any of the rows fails nothing is stored
+func (pg *PostgresDBRepository) InsertReservationsWithRestrictions(resvs []models.Reservation) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelCtx()
+
+	tx, err := pg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	resvStmt := `insert into reservation (first_name, last_name, email, phone_number, check_in_date,
+                         check_out_date, room_id, adults, children, created_at, updated_at)
+              values ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) returning id`
+	restrictionStmt := `insert into room_restriction (check_in_date, check_out_date, room_id, reservation_id,restriction_id,created_at,updated_at )
+values ($1,$2,$3,$4,$5,$6,$7)`
+	availableStmt := `select count(id) from room_restriction where room_id = $1 and $2 < check_out_date and $3 > check_in_date
+              and (expires_at is null or expires_at > $4)`
+
+	var roomIDs []int
+	for _, resv := range resvs {
+		roomIDs = append(roomIDs, resv.RoomID)
+	}
+	err = lockRooms(ctx, tx, roomIDs...)
+	if err != nil {
+		return err
+	}
+
+	for _, resv := range resvs {
+		var rowCount int
+		err = tx.QueryRowContext(ctx, availableStmt, resv.RoomID, resv.CheckInDate, resv.CheckOutDate, time.Now()).Scan(&rowCount)
+		if err != nil {
+			return err
+		}
+		if rowCount > 0 {
+			return i18n.NewMessage("room %d is no longer available from %s to %s", resv.RoomID,
+				resv.CheckInDate.Format("2006-01-02"), resv.CheckOutDate.Format("2006-01-02"))
+		}
+
+		var newID int
+		err = tx.QueryRowContext(ctx, resvStmt,
+			resv.FirstName,
+			resv.LastName,
+			resv.Email,
+			resv.PhoneNumber,
+			resv.CheckInDate,
+			resv.CheckOutDate,
+			resv.RoomID,
+			resv.Adults,
+			resv.Children,
+			time.Now(),
+			time.Now(),
+		).Scan(&newID)
+		if err != nil {
+			return err
+		}
+
+		_, err = tx.ExecContext(ctx, restrictionStmt,
+			resv.CheckInDate,
+			resv.CheckOutDate,
+			resv.RoomID,
+			newID,
+			models.ReservationRestrictionID,
+			time.Now(),
+			time.Now(),
 		)
 		if err != nil {
-			return nil, err
+			return err
 		}
-		restrictions = append(restrictions, r)
 	}
+	return tx.Commit()
+}
+
+//ArrivalsByDate returns the reservations checking in on the date, the cancelled ones are left out
+func (pg *PostgresDBRepository) ArrivalsByDate(date time.Time) ([]models.Reservation, error) {
+	return pg.reservationsByDate("r.check_in_date = $1", date)
+}
+
+//DeparturesByDate returns the reservations checking out on the date, the cancelled ones are left out
+func (pg *PostgresDBRepository) DeparturesByDate(date time.Time) ([]models.Reservation, error) {
+	return pg.reservationsByDate("r.check_out_date = $1", date)
+}
+
+func (pg *PostgresDBRepository) reservationsByDate(condition string, date time.Time) ([]models.Reservation, error) {
+	var resvs []models.Reservation
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `select r.id,
+       r.first_name,
+       r.last_name,
+       r.email,
+       r.phone_number,
+       r.room_id,
+       r.check_in_date,
+       r.check_out_date,
+       r.processed,
+       coalesce(rm.room_name, '')
+       from reservation r
+         left join rooms rm on (r.room_id = rm.id)
+       where ` + condition + ` and r.cancelled_at is null
+       order by rm.room_name`
+	rows, err := pg.DB.QueryContext(ctx, query, date)
+	if err != nil {
+		return resvs, err
+	}
+	defer rows.Close()
 
+	for rows.Next() {
+		var rs models.Reservation
+		err = rows.Scan(
+			&rs.ID,
+			&rs.FirstName,
+			&rs.LastName,
+			&rs.Email,
+			&rs.PhoneNumber,
+			&rs.RoomID,
+			&rs.CheckInDate,
+			&rs.CheckOutDate,
+			&rs.Processed,
+			&rs.Room.RoomName,
+		)
+		if err != nil {
+			return resvs, err
+		}
+		rs.Room.ID = rs.RoomID
+		resvs = append(resvs, rs)
+	}
 	if err = rows.Err(); err != nil {
-		return nil, err
+		return resvs, err
 	}
+	return resvs, nil
+}
 
-	return restrictions, nil
+//RoomOccupancyByDate returns every room with the reservation or block occupying it on the night of the date
+func (pg *PostgresDBRepository) RoomOccupancyByDate(date time.Time) ([]models.RoomOccupancy, error) {
+	var occupancy []models.RoomOccupancy
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `select distinct on (rm.id) rm.id,
+       rm.room_name,
+       coalesce(rr.reservation_id, 0),
+       coalesce(rr.restriction_id, 0),
+       coalesce(r.first_name, ''),
+       coalesce(r.last_name, ''),
+       coalesce(rr.check_out_date, $1)
+       from rooms rm
+         left join room_restriction rr on (rr.room_id = rm.id and rr.check_in_date <= $1 and rr.check_out_date > $1
+                                           and rr.restriction_id <> $2)
+         left join reservation r on (r.id = rr.reservation_id)
+       order by rm.id, rr.restriction_id`
+	rows, err := pg.DB.QueryContext(ctx, query, date, models.HoldRestrictionID)
+	if err != nil {
+		return occupancy, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var o models.RoomOccupancy
+		err = rows.Scan(
+			&o.Room.ID,
+			&o.Room.RoomName,
+			&o.ReservationID,
+			&o.RestrictionID,
+			&o.FirstName,
+			&o.LastName,
+			&o.CheckOutDate,
+		)
+		if err != nil {
+			return occupancy, err
+		}
+		occupancy = append(occupancy, o)
+	}
+	if err = rows.Err(); err != nil {
+		return occupancy, err
+	}
+	return occupancy, nil
 }
 
-// InsertBlockForRoom inserts a room restriction
-func (pg *PostgresDBRepository) InsertBlockForRoom(id int, checkInDate time.Time) error {
-	ctx, cancelCtx := context.WithTimeout(context.Background(), 3*time.Second)
+//OccupancyStatistics aggregates the booked nights, new bookings and average length of stay
+//for the nights from the start date up to (not including) the end date. The cancelled reservations don't count
+func (pg *PostgresDBRepository) OccupancyStatistics(startDate, endDate time.Time) (models.OccupancyStats, error) {
+	stats := models.OccupancyStats{
+		Days: int(endDate.Sub(startDate).Hours() / 24),
+	}
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelCtx()
 
-	query := `insert into room_restriction (check_in_date, check_out_date, room_id, restriction_id,
-			created_at, updated_at) values ($1, $2, $3, $4, $5, $6)`
+	query := `select
+       (select count(id) from rooms) * $3::int,
+       (select coalesce(sum(least(check_out_date, $2::date) - greatest(check_in_date, $1::date)), 0)
+          from room_restriction
+          where restriction_id = $4 and check_in_date < $2::date and check_out_date > $1::date),
+       (select count(id) from reservation where created_at >= $1 and created_at < $2 and cancelled_at is null),
+       (select coalesce(avg(check_out_date - check_in_date), 0)::float
+          from reservation where created_at >= $1 and created_at < $2 and cancelled_at is null)`
+	row := pg.DB.QueryRowContext(ctx, query, startDate, endDate, stats.Days, models.ReservationRestrictionID)
+	err := row.Scan(&stats.RoomNights, &stats.BookedNights, &stats.NewBookings, &stats.AverageStay)
+	if err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+//InsertBookingGroup reserves every room for the guest under one parent booking, each reservation holds
+//the room and the share of the guests staying in it. The reservations and their room restrictions are
+//inserted in a single transaction so either all the rooms are booked or none. The holds the guest has on
+//the rooms become the restrictions of the reservations
+func (pg *PostgresDBRepository) InsertBookingGroup(resvs []models.Reservation, holdIDs []int) (models.BookingGroup, error) {
+	group := models.BookingGroup{
+		CreatedAt: time.Now(),
+		UpdatedAt: time.Now(),
+	}
+	if len(resvs) == 0 {
+		return group, errors.New("no room to book")
+	}
+	group.Email = resvs[0].Email
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancelCtx()
 
-	_, err := pg.DB.ExecContext(ctx, query, checkInDate, checkInDate.AddDate(0, 0, 1), id, 2, time.Now(), time.Now())
+	tx, err := pg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return group, err
+	}
+	defer tx.Rollback()
+
+	//every room is locked before the first one is booked
+	var roomIDs []int
+	for _, roomResv := range resvs {
+		roomIDs = append(roomIDs, roomResv.RoomID)
+	}
+	err = lockRooms(ctx, tx, roomIDs...)
+	if err != nil {
+		return group, err
+	}
+
+	groupStmt := `insert into booking_group (email, created_at, updated_at) values ($1, $2, $3) returning id`
+	err = tx.QueryRowContext(ctx, groupStmt, group.Email, group.CreatedAt, group.UpdatedAt).Scan(&group.ID)
+	if err != nil {
+		return group, err
+	}
+
+	resvStmt := `insert into reservation (first_name, last_name, email, phone_number, check_in_date,
+                         check_out_date, room_id, booking_group_id, adults, children, created_at, updated_at)
+              values ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12) returning id`
+
+	for _, roomResv := range resvs {
+		roomResv.BookingGroupID = group.ID
+		err = tx.QueryRowContext(ctx, resvStmt,
+			roomResv.FirstName,
+			roomResv.LastName,
+			roomResv.Email,
+			roomResv.PhoneNumber,
+			roomResv.CheckInDate,
+			roomResv.CheckOutDate,
+			roomResv.RoomID,
+			roomResv.BookingGroupID,
+			roomResv.Adults,
+			roomResv.Children,
+			time.Now(),
+			time.Now(),
+		).Scan(&roomResv.ID)
+		if err != nil {
+			return group, err
+		}
+
+		err = bookRoom(ctx, tx, roomResv, holdIDs)
+		if err != nil {
+			return group, err
+		}
+		group.Reservations = append(group.Reservations, roomResv)
+	}
+
+	if err = tx.Commit(); err != nil {
+		return group, err
+	}
+	return group, nil
+}
+
+//InsertHeldReservation inserts the reservation and turns the hold the guest has on the room into its
+//restriction in a single transaction, when the hold expired the room is booked only if it is still free
+func (pg *PostgresDBRepository) InsertHeldReservation(resv models.Reservation, holdIDs []int) (int, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	tx, err := pg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	stmt := `insert into reservation (first_name, last_name, email, phone_number, check_in_date,
+                         check_out_date, room_id, adults, children, created_at, updated_at)
+              values ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11) returning id`
+	err = tx.QueryRowContext(ctx, stmt,
+		resv.FirstName,
+		resv.LastName,
+		resv.Email,
+		resv.PhoneNumber,
+		resv.CheckInDate,
+		resv.CheckOutDate,
+		resv.RoomID,
+		resv.Adults,
+		resv.Children,
+		time.Now(),
+		time.Now(),
+	).Scan(&resv.ID)
+	if err != nil {
+		return 0, err
+	}
+
+	err = bookRoom(ctx, tx, resv, holdIDs)
+	if err != nil {
+		return 0, err
+	}
+	return resv.ID, tx.Commit()
+}
+
+//bookRoom stores the restriction of the reservation, converting the matching hold of the guest when it
+//hasn't expired. Without a hold the room must still be free on every night of the stay, the room is
+//locked until the transaction ends so no other booking takes it between the check and the insert
+func bookRoom(ctx context.Context, tx *sql.Tx, resv models.Reservation, holdIDs []int) error {
+	err := lockRooms(ctx, tx, resv.RoomID)
+	if err != nil {
+		return err
+	}
+
+	now := time.Now()
+	convertStmt := `update room_restriction set restriction_id = $1, reservation_id = $2, expires_at = null, updated_at = $3
+              where id = $4 and room_id = $5 and check_in_date = $6 and check_out_date = $7
+              and restriction_id = $8 and expires_at > $3`
+	for _, holdID := range holdIDs {
+		result, err := tx.ExecContext(ctx, convertStmt, models.ReservationRestrictionID, resv.ID, now, holdID, resv.RoomID,
+			resv.CheckInDate, resv.CheckOutDate, models.HoldRestrictionID)
+		if err != nil {
+			return err
+		}
+		if n, err := result.RowsAffected(); err == nil && n > 0 {
+			return nil
+		}
+	}
+
+	var rowCount int
+	availableStmt := `select count(id) from room_restriction where room_id = $1 and $2 < check_out_date and $3 > check_in_date
+              and (expires_at is null or expires_at > $4)`
+	err = tx.QueryRowContext(ctx, availableStmt, resv.RoomID, resv.CheckInDate, resv.CheckOutDate, now).Scan(&rowCount)
 	if err != nil {
-		log.Println(err)
 		return err
 	}
+	if rowCount > 0 {
+		return i18n.NewMessage("room %d is no longer available from %s to %s", resv.RoomID,
+			resv.CheckInDate.Format("2006-01-02"), resv.CheckOutDate.Format("2006-01-02"))
+	}
+
+	restrictionStmt := `insert into room_restriction (check_in_date, check_out_date, room_id, reservation_id,restriction_id,created_at,updated_at )
+values ($1,$2,$3,$4,$5,$6,$7)`
+	_, err = tx.ExecContext(ctx, restrictionStmt,
+		resv.CheckInDate,
+		resv.CheckOutDate,
+		resv.RoomID,
+		resv.ID,
+		models.ReservationRestrictionID,
+		now,
+		now,
+	)
+	return err
+}
+
+//roomLocks the first key of the advisory locks taken on the rooms, the second one is the room id
+const roomLocks = 1
+
+//lockRooms takes the lock of every room until the transaction ends, the writes checking that a room is
+//free before they reserve, hold or block it wait for each other. The rooms are locked in the order of
+//their ids so two transactions locking the same rooms can't deadlock
+func lockRooms(ctx context.Context, tx *sql.Tx, roomIDs ...int) error {
+	ids := append([]int(nil), roomIDs...)
+	sort.Ints(ids)
+	for _, roomID := range ids {
+		_, err := tx.ExecContext(ctx, `select pg_advisory_xact_lock($1, $2)`, roomLocks, roomID)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
-// DeleteBlockByID deletes a room restriction
-func (pg *PostgresDBRepository) DeleteBlockByID(id int) error {
+//InsertHold holds the room for a guest filling the reservation form until hold.ExpiresAt, the hold is
+//refused when the room is already reserved, blocked or held on one of the nights
+func (pg *PostgresDBRepository) InsertHold(hold models.RoomRestriction) (int, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	tx, err := pg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	newID, err := insertHold(ctx, tx, hold)
+	if err != nil {
+		return 0, err
+	}
+	return newID, tx.Commit()
+}
+
+//insertHold holds the room when it is still free on every night of the hold, the room is locked until the
+//transaction ends so no other booking takes it between the check and the insert
+func insertHold(ctx context.Context, tx *sql.Tx, hold models.RoomRestriction) (int, error) {
+	err := lockRooms(ctx, tx, hold.RoomID)
+	if err != nil {
+		return 0, err
+	}
+
+	var rowCount int
+	availableStmt := `select count(id) from room_restriction where room_id = $1 and $2 < check_out_date and $3 > check_in_date
+              and (expires_at is null or expires_at > $4)`
+	err = tx.QueryRowContext(ctx, availableStmt, hold.RoomID, hold.CheckInDate, hold.CheckOutDate, time.Now()).Scan(&rowCount)
+	if err != nil {
+		return 0, err
+	}
+	if rowCount > 0 {
+		return 0, i18n.NewMessage("room %d is no longer available from %s to %s", hold.RoomID,
+			hold.CheckInDate.Format("2006-01-02"), hold.CheckOutDate.Format("2006-01-02"))
+	}
+
+	query := `insert into room_restriction (check_in_date, check_out_date, room_id, restriction_id, expires_at,
+			created_at, updated_at) values ($1, $2, $3, $4, $5, $6, $7) returning id`
+	var newID int
+	err = tx.QueryRowContext(ctx, query,
+		hold.CheckInDate,
+		hold.CheckOutDate,
+		hold.RoomID,
+		models.HoldRestrictionID,
+		hold.ExpiresAt,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+	return newID, nil
+}
+
+//ReleaseHolds frees the rooms held by a guest who left the reservation form, the holds already turned
+//into reservations are left alone
+func (pg *PostgresDBRepository) ReleaseHolds(ids []int) error {
 	ctx, cancelCtx := context.WithTimeout(context.Background(), 3*time.Second)
 	defer cancelCtx()
 
-	query := `delete from room_restriction where id = $1`
+	for _, id := range ids {
+		_, err := pg.DB.ExecContext(ctx, `delete from room_restriction where id = $1 and restriction_id = $2`, id,
+			models.HoldRestrictionID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//DeleteExpiredHolds removes the holds which expired before now and returns how many were removed
+func (pg *PostgresDBRepository) DeleteExpiredHolds(now time.Time) (int, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
 
-	_, err := pg.DB.ExecContext(ctx, query, id)
+	result, err := pg.DB.ExecContext(ctx, `delete from room_restriction where restriction_id = $1 and expires_at <= $2`,
+		models.HoldRestrictionID, now)
+	if err != nil {
+		return 0, err
+	}
+	n, err := result.RowsAffected()
+	return int(n), err
+}
+
+//paymentColumns the columns scanned by scanPayment
+const paymentColumns = `id, coalesce(reservation_id, 0), coalesce(booking_group_id, 0), provider, reference, kind, amount,
+       currency, status, created_at, updated_at`
+
+func scanPayment(row interface{ Scan(...interface{}) error }) (models.Payment, error) {
+	var p models.Payment
+	err := row.Scan(&p.ID, &p.ReservationID, &p.BookingGroupID, &p.Provider, &p.Reference, &p.Kind, &p.Amount,
+		&p.Currency, &p.Status, &p.CreatedAt, &p.UpdatedAt)
+	return p, err
+}
+
+//InsertPayment records a charge made for a reservation and returns its id
+func (pg *PostgresDBRepository) InsertPayment(payment models.Payment) (int, error) {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	stmt := `insert into payment (reservation_id, booking_group_id, provider, reference, kind, amount, currency, status,
+                     created_at, updated_at)
+              values (nullif($1, 0), nullif($2, 0), $3, $4, $5, $6, $7, $8, $9, $10) returning id`
+	var newID int
+	err := pg.DB.QueryRowContext(ctx, stmt,
+		payment.ReservationID,
+		payment.BookingGroupID,
+		payment.Provider,
+		payment.Reference,
+		payment.Kind,
+		payment.Amount,
+		payment.Currency,
+		payment.Status,
+		time.Now(),
+		time.Now(),
+	).Scan(&newID)
+	if err != nil {
+		return 0, err
+	}
+	return newID, nil
+}
+
+//UpdatePaymentStatus settles the pending payments the provider knows by the reference and returns them, the
+//deposit of a booking group has a payment per reservation. sql.ErrNoRows is returned when there is no such
+//payment or it was already settled
+func (pg *PostgresDBRepository) UpdatePaymentStatus(provider, reference, status string) ([]models.Payment, error) {
+	var payments []models.Payment
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `update payment set status = $1, updated_at = $2
+              where provider = $3 and reference = $4 and status = $5
+              returning ` + paymentColumns
+	rows, err := pg.DB.QueryContext(ctx, query, status, time.Now(), provider, reference, models.PaymentPending)
+	if err != nil {
+		return payments, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return payments, err
+		}
+		payments = append(payments, payment)
+	}
+	if err = rows.Err(); err != nil {
+		return payments, err
+	}
+	if len(payments) == 0 {
+		return payments, sql.ErrNoRows
+	}
+	sort.Slice(payments, func(i, j int) bool {
+		return payments[i].ID < payments[j].ID
+	})
+	return payments, nil
+}
+
+//PaymentsByReservation returns the payments of the reservation, including the ones taken for its
+//booking group, the oldest first
+func (pg *PostgresDBRepository) PaymentsByReservation(resvID int) ([]models.Payment, error) {
+	var payments []models.Payment
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	query := `select ` + paymentColumns + `
+              from payment
+              where reservation_id = $1
+                 or booking_group_id = (select booking_group_id from reservation where id = $1)
+              order by created_at, id`
+	rows, err := pg.DB.QueryContext(ctx, query, resvID)
+	if err != nil {
+		return payments, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		payment, err := scanPayment(rows)
+		if err != nil {
+			return payments, err
+		}
+		payments = append(payments, payment)
+	}
+	return payments, rows.Err()
+}
+
+//CancelUnpaidReservations removes the reservations the failed payment was taken for, and their room
+//restrictions, so the rooms can be booked again. The payment stays as a record of the attempt
+func (pg *PostgresDBRepository) CancelUnpaidReservations(payment models.Payment) error {
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	tx, err := pg.DB.BeginTx(ctx, nil)
 	if err != nil {
-		log.Println(err)
 		return err
 	}
-	return nil
+	defer tx.Rollback()
+
+	reservations := `select id from reservation where id = $1 or (booking_group_id = $2 and $2 > 0)`
+	_, err = tx.ExecContext(ctx, `delete from room_restriction where reservation_id in (`+reservations+`)`,
+		payment.ReservationID, payment.BookingGroupID)
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `delete from reservation where id in (`+reservations+`)`,
+		payment.ReservationID, payment.BookingGroupID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+//InvoiceForReservation returns the invoice of the reservation, the first call numbers it after the last
+//invoice so the numbers follow each other without gaps
+func (pg *PostgresDBRepository) InvoiceForReservation(resvID int, issuedAt time.Time) (models.Invoice, error) {
+	var inv models.Invoice
+	ctx, cancelCtx := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelCtx()
+
+	tx, err := pg.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return inv, err
+	}
+	defer tx.Rollback()
+
+	//two invoices created together must not read the same last number
+	_, err = tx.ExecContext(ctx, `lock table invoice in share row exclusive mode`)
+	if err != nil {
+		return inv, err
+	}
+
+	query := `select id, number, reservation_id, issued_at, created_at, updated_at from invoice where reservation_id = $1`
+	err = tx.QueryRowContext(ctx, query, resvID).Scan(&inv.ID, &inv.Number, &inv.ReservationID, &inv.IssuedAt,
+		&inv.CreatedAt, &inv.UpdatedAt)
+	if err == nil {
+		return inv, tx.Commit()
+	}
+	if err != sql.ErrNoRows {
+		return inv, err
+	}
+
+	stmt := `insert into invoice (number, reservation_id, issued_at, created_at, updated_at)
+              select coalesce(max(number), 0) + 1, $1, $2, $3, $4 from invoice
+              returning id, number, reservation_id, issued_at, created_at, updated_at`
+	err = tx.QueryRowContext(ctx, stmt, resvID, issuedAt, time.Now(), time.Now()).Scan(&inv.ID, &inv.Number,
+		&inv.ReservationID, &inv.IssuedAt, &inv.CreatedAt, &inv.UpdatedAt)
+	if err != nil {
+		return inv, err
+	}
+	return inv, tx.Commit()
 }
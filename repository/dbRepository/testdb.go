@@ -2,7 +2,9 @@ package dbRepository
 
 import (
 	_ "context"
+	"database/sql"
 	"log"
+	"strings"
 	"time"
 
 	"github.com/dev-ayaa/resvbooking/pkg/models"
@@ -11,6 +13,10 @@ import (
 
 func (tpg *TestPostgresDBRepository) AllRoom() ([]models.Room, error) {
 	var rooms []models.Room
+	rooms = append(rooms,
+		models.Room{ID: 1, RoomName: "Deluxe suite", Slug: "deluxe-suite", MaxAdults: 2, MaxChildren: 1, NightlyRate: 18500},
+		models.Room{ID: 2, RoomName: "Junior Quarter's", Slug: "junior-suite", MaxAdults: 2, NightlyRate: 12000},
+	)
 	return rooms, nil
 }
 
@@ -30,6 +36,58 @@ func (tpg *TestPostgresDBRepository) InsertRoomRestriction(resv models.RoomRestr
 	return nil
 }
 
+//InsertBookingGroup testing to reserve several rooms under one booking
+func (tpg *TestPostgresDBRepository) InsertBookingGroup(resvs []models.Reservation, holdIDs []int) (models.BookingGroup, error) {
+	group := models.BookingGroup{ID: 1}
+	for i, roomResv := range resvs {
+		if roomResv.RoomID == 14 || roomResv.RoomID == 11 {
+			return models.BookingGroup{}, errors.New("can't insert booking group")
+		}
+		roomResv.ID = i + 1
+		roomResv.BookingGroupID = group.ID
+		group.Email = roomResv.Email
+		group.Reservations = append(group.Reservations, roomResv)
+	}
+	return group, nil
+}
+
+//InsertHeldReservation testing to book the room held by the guest
+func (tpg *TestPostgresDBRepository) InsertHeldReservation(resv models.Reservation, holdIDs []int) (int, error) {
+	if resv.RoomID == 14 || resv.RoomID == 11 {
+		return 0, errors.New("room is no longer available")
+	}
+	return 1, nil
+}
+
+//InsertHold testing to hold a room, the rooms after the fourth one are taken. The id of the hold is
+//the id of the room
+func (tpg *TestPostgresDBRepository) InsertHold(hold models.RoomRestriction) (int, error) {
+	if hold.RoomID > 4 {
+		return 0, errors.New("room is no longer available")
+	}
+	return hold.RoomID, nil
+}
+
+//ReleaseHolds testing to free the held rooms
+func (tpg *TestPostgresDBRepository) ReleaseHolds(ids []int) error {
+	return nil
+}
+
+//DeleteExpiredHolds testing to sweep the expired holds
+func (tpg *TestPostgresDBRepository) DeleteExpiredHolds(now time.Time) (int, error) {
+	return 0, nil
+}
+
+//InsertReservationsWithRestrictions testing to insert imported reservations in one transaction
+func (tpg *TestPostgresDBRepository) InsertReservationsWithRestrictions(resvs []models.Reservation) error {
+	for _, resv := range resvs {
+		if resv.RoomID == 14 || resv.RoomID == 11 {
+			return errors.New("can't insert imported reservations")
+		}
+	}
+	return nil
+}
+
 //SearchRoomAvailabileByRoomID testing to check for all available with a certaion period ogf time
 func (tpg *TestPostgresDBRepository) SearchRoomAvailabileByRoomID(roomID int, checkInDate, checkOutDate time.Time) (bool, error) {
 	dateLayout := "2006-01-02"
@@ -93,26 +151,332 @@ func (tpg *TestPostgresDBRepository) SearchForAvailableRoom(checkInDate, checkOu
 		return rooms, nil
 	}
 
-	room := models.Room{
-		ID: 1,
-	}
-	rooms = append(rooms, room)
+	rooms = append(rooms,
+		models.Room{ID: 1, MaxAdults: 2, MaxChildren: 1},
+		models.Room{ID: 2, MaxAdults: 2},
+	)
 	return rooms, nil
 
 }
 
-//GetRoom Testing to get the correct room with it id from the database
+//GetRoom Testing to get the correct room with it id from the database, the rooms keep half of the stay
+//when cancelled in the last week and the fourth room is free of charge. There is no room after the fourth
+//one and the room 99 fails
 func (tpg *TestPostgresDBRepository) GetRooms(room_id int) (models.Room, error) {
 	var room models.Room
 
-	if room_id > 4 {
+	if room_id == 99 {
 		return room, errors.New("cannot get any rooms")
 	}
+	if room_id > 4 {
+		return room, sql.ErrNoRows
+	}
+	room.ID = room_id
+	room.MaxAdults = 2
+	room.MaxChildren = 1
+	if room_id != 4 {
+		room.NightlyRate = 12000
+		room.Cancellation = models.CancellationPolicy{FreeDays: 7, Penalty: models.PenaltyPercent, PenaltyPercent: 50}
+	}
 
 	return room, nil
 
 }
 
+//InsertRoomPhoto testing to add a photo to a gallery, the fourth room fails
+func (tpg *TestPostgresDBRepository) InsertRoomPhoto(photo models.RoomPhoto) (int, error) {
+	if photo.RoomID == 4 {
+		return 0, errors.New("cannot insert the photo")
+	}
+	return 1, nil
+}
+
+//UpdateRoomPhotos testing to save the captions and order of a gallery
+func (tpg *TestPostgresDBRepository) UpdateRoomPhotos(roomID int, photos []models.RoomPhoto) error {
+	if roomID > 4 {
+		return errors.New("cannot update the photos")
+	}
+	return nil
+}
+
+//DeleteRoomPhoto testing to remove a photo from a gallery
+func (tpg *TestPostgresDBRepository) DeleteRoomPhoto(roomID, photoID int) (models.RoomPhoto, error) {
+	if roomID > 4 {
+		return models.RoomPhoto{}, errors.New("cannot delete the photo")
+	}
+	return models.RoomPhoto{ID: photoID, RoomID: roomID, Path: "/uploads/room-1.jpg", ThumbnailPath: "/uploads/room-1-thumb.jpg"}, nil
+}
+
+//testBookingRules the first room needs 3 nights for arrivals in January 2022 and doesn't take
+//arrivals on 2022-02-01
+func testBookingRules() []models.BookingRule {
+	dateLayout := "2006-01-02"
+	janStart, _ := time.Parse(dateLayout, "2022-01-01")
+	janEnd, _ := time.Parse(dateLayout, "2022-01-31")
+	closed, _ := time.Parse(dateLayout, "2022-02-01")
+	return []models.BookingRule{
+		{ID: 1, RoomID: 1, StartDate: janStart, EndDate: janEnd, MinNights: 3},
+		{ID: 2, RoomID: 1, StartDate: closed, EndDate: closed, ClosedToArrival: true},
+	}
+}
+
+//BookingRules testing for the booking rules in force between two dates
+func (tpg *TestPostgresDBRepository) BookingRules(startDate, endDate time.Time) (map[int][]models.BookingRule, error) {
+	byRoom := make(map[int][]models.BookingRule)
+	for _, rule := range testBookingRules() {
+		if !rule.StartDate.After(endDate) && !rule.EndDate.Before(startDate) {
+			byRoom[rule.RoomID] = append(byRoom[rule.RoomID], rule)
+		}
+	}
+	return byRoom, nil
+}
+
+//BookingRulesByRoom testing for the booking rules of a room, the rooms after the fourth one fail
+func (tpg *TestPostgresDBRepository) BookingRulesByRoom(roomID int) ([]models.BookingRule, error) {
+	if roomID > 4 {
+		return nil, errors.New("cannot get the booking rules")
+	}
+	var rules []models.BookingRule
+	for _, rule := range testBookingRules() {
+		if rule.RoomID == roomID {
+			rules = append(rules, rule)
+		}
+	}
+	return rules, nil
+}
+
+//InsertBookingRule testing to add a booking rule, the fourth room fails
+func (tpg *TestPostgresDBRepository) InsertBookingRule(rule models.BookingRule) (int, error) {
+	if rule.RoomID == 4 {
+		return 0, errors.New("cannot insert the booking rule")
+	}
+	return 3, nil
+}
+
+//DeleteBookingRule testing to remove a booking rule
+func (tpg *TestPostgresDBRepository) DeleteBookingRule(roomID, ruleID int) error {
+	if roomID > 4 {
+		return errors.New("cannot delete the booking rule")
+	}
+	return nil
+}
+
+//testTaxRules a VAT on every night, the first room adds a city tax on its nights and a cleaning fee on its
+//stays so the free rooms stay free
+func testTaxRules() []models.TaxRule {
+	since, _ := time.Parse("2006-01-02", "2020-01-01")
+	return []models.TaxRule{
+		{ID: 1, RoomID: 1, Name: "City tax", Kind: models.ChargeTax, Basis: models.BasisFixed, Per: models.PerNight, Amount: 250, StartDate: since},
+		{ID: 2, Name: "VAT", Kind: models.ChargeTax, Basis: models.BasisPercent, Per: models.PerNight, Rate: 1000, StartDate: since},
+		{ID: 3, RoomID: 1, Name: "Cleaning", Kind: models.ChargeFee, Basis: models.BasisFixed, Per: models.PerStay, Amount: 3000,
+			StartDate: since},
+	}
+}
+
+//TaxRules testing for the taxes and fees in force between two dates
+func (tpg *TestPostgresDBRepository) TaxRules(startDate, endDate time.Time) ([]models.TaxRule, error) {
+	return testTaxRules(), nil
+}
+
+//AllTaxRules testing for the list of the taxes and fees
+func (tpg *TestPostgresDBRepository) AllTaxRules() ([]models.TaxRule, error) {
+	return testTaxRules(), nil
+}
+
+//InsertTaxRule testing to add a tax or a fee, the rules of the rooms after the fourth one fail
+func (tpg *TestPostgresDBRepository) InsertTaxRule(rule models.TaxRule) (int, error) {
+	if rule.RoomID > 4 {
+		return 0, errors.New("cannot insert the tax rule")
+	}
+	return 4, nil
+}
+
+//DeleteTaxRule testing to remove a tax or a fee, the rules after the fourth one fail
+func (tpg *TestPostgresDBRepository) DeleteTaxRule(id int) error {
+	if id > 4 {
+		return errors.New("cannot delete the tax rule")
+	}
+	return nil
+}
+
+//testPromoCodes "SUMMER" takes 15% off the stays of at least 2 nights, "WELCOME" 20.00 off any stay,
+//"EXPIRED" ended in 2021 and "USEDUP" was used as many times as allowed
+func testPromoCodes() []models.PromoCode {
+	since, _ := time.Parse("2006-01-02", "2020-01-01")
+	ended, _ := time.Parse("2006-01-02", "2021-12-31")
+	return []models.PromoCode{
+		{ID: 1, Code: "SUMMER", Basis: models.BasisPercent, Rate: 1500, MinNights: 2, MaxRedemptions: 100, Redemptions: 3,
+			StartDate: since},
+		{ID: 2, Code: "WELCOME", Basis: models.BasisFixed, Amount: 2000, StartDate: since},
+		{ID: 3, Code: "EXPIRED", Basis: models.BasisFixed, Amount: 2000, StartDate: since, EndDate: ended},
+		{ID: 4, Code: "USEDUP", Basis: models.BasisPercent, Rate: 1000, MaxRedemptions: 5, Redemptions: 5, StartDate: since},
+	}
+}
+
+//AllPromoCodes testing for the list of the promo codes
+func (tpg *TestPostgresDBRepository) AllPromoCodes() ([]models.PromoCode, error) {
+	return testPromoCodes(), nil
+}
+
+//GetPromoCodeByCode testing for the promo codes typed by the guests
+func (tpg *TestPostgresDBRepository) GetPromoCodeByCode(code string) (models.PromoCode, error) {
+	for _, promo := range testPromoCodes() {
+		if strings.EqualFold(promo.Code, code) {
+			return promo, nil
+		}
+	}
+	return models.PromoCode{}, sql.ErrNoRows
+}
+
+//InsertPromoCode testing to add a promo code, "ERROR" fails
+func (tpg *TestPostgresDBRepository) InsertPromoCode(promo models.PromoCode) (int, error) {
+	if promo.Code == "ERROR" {
+		return 0, errors.New("cannot insert the promo code")
+	}
+	return 5, nil
+}
+
+//DeletePromoCode testing to remove a promo code, the codes after the fourth one fail
+func (tpg *TestPostgresDBRepository) DeletePromoCode(id int) error {
+	if id > 4 {
+		return errors.New("cannot delete the promo code")
+	}
+	return nil
+}
+
+//RedeemPromoCode testing to count a use of a promo code, the codes used up fail
+func (tpg *TestPostgresDBRepository) RedeemPromoCode(id int) error {
+	for _, promo := range testPromoCodes() {
+		if promo.ID == id && promo.MaxRedemptions > 0 && promo.Redemptions >= promo.MaxRedemptions {
+			return errors.New("the promo code was used up")
+		}
+	}
+	return nil
+}
+
+//ReleasePromoCode testing to give back a use of a promo code
+func (tpg *TestPostgresDBRepository) ReleasePromoCode(id int) error {
+	return nil
+}
+
+//AllExchangeRates testing for the exchange rates, the prices can be displayed in euros and pounds
+func (tpg *TestPostgresDBRepository) AllExchangeRates() ([]models.ExchangeRate, error) {
+	return []models.ExchangeRate{
+		{ID: 1, Currency: "EUR", Rate: 0.92},
+		{ID: 2, Currency: "GBP", Rate: 0.786},
+	}, nil
+}
+
+//UpsertExchangeRates testing to store the exchange rates, the rates of "XXX" fail
+func (tpg *TestPostgresDBRepository) UpsertExchangeRates(rates []models.ExchangeRate) error {
+	for _, rate := range rates {
+		if rate.Currency == "XXX" {
+			return errors.New("cannot store the exchange rates")
+		}
+	}
+	return nil
+}
+
+//DeleteExchangeRate testing to remove an exchange rate, "XXX" fails
+func (tpg *TestPostgresDBRepository) DeleteExchangeRate(currency string) error {
+	if currency == "XXX" {
+		return errors.New("cannot delete the exchange rate")
+	}
+	return nil
+}
+
+//InsertReservationCharges testing to keep the price of a reservation
+func (tpg *TestPostgresDBRepository) InsertReservationCharges(resvID int, charges []models.ReservationCharge) error {
+	return nil
+}
+
+//ReservationCharges testing for the price of a reservation, the first reservation was booked with its taxes,
+//the fourth one at a lower rate with a promo code and the others before the taxes were itemised
+func (tpg *TestPostgresDBRepository) ReservationCharges(resvID int) ([]models.ReservationCharge, error) {
+	if resvID == 4 {
+		return []models.ReservationCharge{
+			{ID: 5, ReservationID: 4, Name: "Deluxe suite, 2 nights", Kind: models.ChargeRoom, Quantity: 2, Amount: 16000},
+			{ID: 6, ReservationID: 4, Name: "Promo code SUMMER", Kind: models.ChargeDiscount, Quantity: 1, Amount: -4000},
+		}, nil
+	}
+	if resvID != 1 {
+		return nil, nil
+	}
+	return []models.ReservationCharge{
+		{ID: 1, ReservationID: 1, Name: "Deluxe suite, 2 nights", Kind: models.ChargeRoom, Quantity: 2, Amount: 24000},
+		{ID: 2, ReservationID: 1, Name: "City tax", Kind: models.ChargeTax, Quantity: 2, Amount: 500},
+		{ID: 3, ReservationID: 1, Name: "VAT", Kind: models.ChargeTax, Quantity: 2, Amount: 2400},
+		{ID: 4, ReservationID: 1, Name: "Cleaning", Kind: models.ChargeFee, Quantity: 1, Amount: 3000},
+	}, nil
+}
+
+//RoomOccupiedNights testing for the occupied nights of every room, the second room is fully booked
+//after 2025-09-09 and the first room only from 2029-09-05 to 2029-09-12
+func (tpg *TestPostgresDBRepository) RoomOccupiedNights(startDate, endDate time.Time) ([]models.RoomNights, error) {
+	dateLayout := "2006-01-02"
+	testDate, _ := time.Parse(dateLayout, "2025-09-09")
+	blockStart, _ := time.Parse(dateLayout, "2029-09-05")
+	blockEnd, _ := time.Parse(dateLayout, "2029-09-12")
+
+	first := models.RoomNights{Room: models.Room{ID: 1, RoomName: "Deluxe suite", MaxAdults: 2, MaxChildren: 1}, Occupied: make(map[string]bool)}
+	second := models.RoomNights{Room: models.Room{ID: 2, RoomName: "Junior Quarter's", MaxAdults: 2}, Occupied: make(map[string]bool)}
+	for d := startDate; d.Before(endDate); d = d.AddDate(0, 0, 1) {
+		if !d.Before(blockStart) && d.Before(blockEnd) {
+			first.Occupied[d.Format(dateLayout)] = true
+		}
+		if d.After(testDate) {
+			second.Occupied[d.Format(dateLayout)] = true
+		}
+	}
+	return []models.RoomNights{first, second}, nil
+}
+
+//GetRoomBySlug testing for a room of the catalogue, the deluxe and junior suites exist and the broken suite
+//fails to load
+func (tpg *TestPostgresDBRepository) GetRoomBySlug(slug string) (models.Room, error) {
+	var room models.Room
+	switch slug {
+	case "deluxe-suite":
+		room = models.Room{ID: 1, RoomName: "Deluxe suite", Slug: slug, MaxAdults: 2, MaxChildren: 1, NightlyRate: 18500,
+			Description: "A separate bedroom and a private balcony", Amenities: "Private balcony\nMini bar"}
+		room.Photos = []models.RoomPhoto{
+			{ID: 1, RoomID: 1, Path: "/static/images/pexels-max-vakhtbovych-6970069.jpg", Position: 1},
+			{ID: 2, RoomID: 1, Path: "/uploads/room-1.jpg", ThumbnailPath: "/uploads/room-1-thumb.jpg", Caption: "Balcony at sunset", Position: 2},
+		}
+	case "junior-suite":
+		room = models.Room{ID: 2, RoomName: "Junior Quarter's", Slug: slug, MaxAdults: 2, NightlyRate: 12000}
+	case "broken-suite":
+		return room, errors.New("cannot get the room")
+	default:
+		return room, sql.ErrNoRows
+	}
+	return room, nil
+}
+
+//InsertRoom testing to add a room to the catalogue
+func (tpg *TestPostgresDBRepository) InsertRoom(room models.Room) (int, error) {
+	if room.Slug == "broken-suite" {
+		return 0, errors.New("cannot insert the room")
+	}
+	return 3, nil
+}
+
+//UpdateRoom testing to save a room of the catalogue
+func (tpg *TestPostgresDBRepository) UpdateRoom(room models.Room) error {
+	if room.ID > 4 {
+		return errors.New("cannot update the room")
+	}
+	return nil
+}
+
+//DeleteRoom testing to remove a room, the rooms after the fourth one have reservations
+func (tpg *TestPostgresDBRepository) DeleteRoom(id int) error {
+	if id > 4 {
+		return errors.Errorf("room %d has reservations or blocks and cannot be deleted", id)
+	}
+	return nil
+}
+
 //GetUserInfoByID testing to get user details in the database
 func (tpg *TestPostgresDBRepository) GetUserInfoByID(userID int) (models.User, error) {
 	var user models.User
@@ -146,9 +510,29 @@ func (tpg *TestPostgresDBRepository) AllNewReservation() ([]models.Reservation,
 	return allNewResv, nil
 }
 
+//ShowUserReservation testing for a reservation, the first one is a stay of two nights in the first room
+//starting in three days, the second one the same stay in a month, the third one was cancelled and the
+//fourth one is the stay of the first one booked with a promo code
 func (tpg *TestPostgresDBRepository) ShowUserReservation(id int) (models.Reservation, error) {
 	var userResv models.Reservation
 	if id >= 1 {
+		userResv.ID = id
+		if id == 1 {
+			checkIn := time.Now().AddDate(0, 0, 3).Truncate(24 * time.Hour)
+			userResv = models.Reservation{ID: 1, FirstName: "Graham", LastName: "Graham", Email: "graham@example.com",
+				RoomID: 1, CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 2)}
+		}
+		if id == 2 {
+			checkIn := time.Now().AddDate(0, 0, 30).Truncate(24 * time.Hour)
+			userResv = models.Reservation{ID: 2, RoomID: 1, CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 2)}
+		}
+		if id == 3 {
+			userResv = models.Reservation{ID: 3, RoomID: 1, CancelledAt: TestBlockVersion, RefundAmount: 1000}
+		}
+		if id == 4 {
+			checkIn := time.Now().AddDate(0, 0, 3).Truncate(24 * time.Hour)
+			userResv = models.Reservation{ID: 4, RoomID: 1, CheckInDate: checkIn, CheckOutDate: checkIn.AddDate(0, 0, 2)}
+		}
 		return userResv, nil
 	}
 	return userResv, errors.New("Error invalid reservation")
@@ -170,15 +554,325 @@ func (tpg *TestPostgresDBRepository) DeleteUserReservation(id int) error {
 	return nil
 }
 
+//CancelReservation testing to cancel a reservation, the reservations after the fourth one fail
+func (tpg *TestPostgresDBRepository) CancelReservation(id, refund int, cancelledAt time.Time) error {
+	if id > 4 {
+		return errors.Errorf("reservation %d is already cancelled", id)
+	}
+	return nil
+}
+
+//TestBlockVersion the last update of the blocks returned by GetRestrictionsForRoomByDate
+var TestBlockVersion = time.Date(2022, 4, 1, 10, 30, 0, 0, time.UTC)
+
+//GetRestrictionsForRoomByDate testing for the calendar, the first room is reserved from the second to
+//the fifth day and blocked from the tenth to the thirteenth day of the month
 func (tpg *TestPostgresDBRepository) GetRestrictionsForRoomByDate(roomID int, checkInDate, checkOutDate time.Time) ([]models.RoomRestriction, error) {
 	var restrictions []models.RoomRestriction
+	if roomID != 1 {
+		return restrictions, nil
+	}
+	restrictions = append(restrictions,
+		models.RoomRestriction{
+			ID:            1,
+			RoomID:        1,
+			ReservationID: 1,
+			RestrictionID: 1,
+			CheckInDate:   checkInDate.AddDate(0, 0, 1),
+			CheckOutDate:  checkInDate.AddDate(0, 0, 4),
+			UpdatedAt:     TestBlockVersion,
+		},
+		models.RoomRestriction{
+			ID:            2,
+			RoomID:        1,
+			RestrictionID: models.OwnerRestrictionID,
+			CheckInDate:   checkInDate.AddDate(0, 0, 9),
+			CheckOutDate:  checkInDate.AddDate(0, 0, 13),
+			Reason:        "maintenance",
+			UpdatedAt:     TestBlockVersion,
+		},
+		models.RoomRestriction{
+			ID:            3,
+			RoomID:        1,
+			RestrictionID: models.HoldRestrictionID,
+			CheckInDate:   checkInDate.AddDate(0, 0, 19),
+			CheckOutDate:  checkInDate.AddDate(0, 0, 21),
+			ExpiresAt:     TestBlockVersion.Add(15 * time.Minute),
+			UpdatedAt:     TestBlockVersion,
+		},
+	)
 	return restrictions, nil
 }
 
-func (tpg *TestPostgresDBRepository) InsertBlockForRoom(id int, checkInDate time.Time) error {
+//CalendarEntries testing for the calendar, the first room has a reservation and the second one a block
+//and a hold
+func (tpg *TestPostgresDBRepository) CalendarEntries(startDate, endDate time.Time) ([]models.RoomRestriction, error) {
+	errDate, _ := time.Parse("2006-01-02", "2045-09-01")
+	if !startDate.Before(errDate) {
+		return nil, errors.New("cannot get the calendar")
+	}
+	return []models.RoomRestriction{
+		{
+			ID:            1,
+			RoomID:        1,
+			ReservationID: 1,
+			RestrictionID: 1,
+			CheckInDate:   startDate.AddDate(0, 0, 2),
+			CheckOutDate:  startDate.AddDate(0, 0, 5),
+			Reservation:   models.Reservation{ID: 1, FirstName: "Graham", LastName: "Graham", Adults: 2},
+		},
+		{
+			ID:            2,
+			RoomID:        2,
+			RestrictionID: models.OwnerRestrictionID,
+			CheckInDate:   startDate.AddDate(0, 0, 9),
+			CheckOutDate:  startDate.AddDate(0, 0, 12),
+			Reason:        "maintenance",
+		},
+		{
+			ID:            3,
+			RoomID:        2,
+			RestrictionID: models.HoldRestrictionID,
+			CheckInDate:   startDate.AddDate(0, 0, 19),
+			CheckOutDate:  startDate.AddDate(0, 0, 21),
+			ExpiresAt:     TestBlockVersion.Add(15 * time.Minute),
+		},
+	}, nil
+}
+
+//MoveReservation testing to move a reservation, the rooms are booked after 2025-09-09
+func (tpg *TestPostgresDBRepository) MoveReservation(resvID, roomID int, checkInDate, checkOutDate time.Time) error {
+	testDate, _ := time.Parse("2006-01-02", "2025-09-09")
+	if resvID > 4 {
+		return errors.Errorf("reservation %d doesn't exist", resvID)
+	}
+	if checkOutDate.After(testDate) {
+		return errors.Errorf("room %d is reserved from 2025-09-09 to 2025-09-12 by reservation 2", roomID)
+	}
 	return nil
 }
 
+//InsertWaitlistEntry testing to join the waitlist, the rooms after the fourth one fail
+func (tpg *TestPostgresDBRepository) InsertWaitlistEntry(entry models.WaitlistEntry) (int, error) {
+	if entry.RoomID > 4 {
+		return 0, errors.New("cannot insert the waitlist entry")
+	}
+	return 1, nil
+}
+
+//WaitlistEntries testing for the waitlist, nobody is waiting
+func (tpg *TestPostgresDBRepository) WaitlistEntries(status string) ([]models.WaitlistEntry, error) {
+	var entries []models.WaitlistEntry
+	return entries, nil
+}
+
+//GetWaitlistEntryByToken testing for the hold links, "valid-token" is an open offer of the first room,
+//"expired-token" an offer which expired and "claimed-token" an offer already used
+func (tpg *TestPostgresDBRepository) GetWaitlistEntryByToken(token string) (models.WaitlistEntry, error) {
+	checkIn, _ := time.Parse("2006-01-02", "2030-01-10")
+	entry := models.WaitlistEntry{
+		ID:            1,
+		FirstName:     "Graham",
+		LastName:      "Graham",
+		Email:         "graham@example.com",
+		CheckInDate:   checkIn,
+		CheckOutDate:  checkIn.AddDate(0, 0, 2),
+		Adults:        2,
+		Status:        models.WaitlistOffered,
+		Token:         token,
+		OfferedRoomID: 1,
+		HoldID:        1,
+		HoldExpiresAt: time.Now().Add(time.Hour),
+		Room:          models.Room{ID: 1, RoomName: "Deluxe suite"},
+	}
+	switch token {
+	case "valid-token":
+		return entry, nil
+	case "expired-token":
+		entry.HoldExpiresAt = time.Now().Add(-time.Hour)
+		return entry, nil
+	case "claimed-token":
+		entry.Status = models.WaitlistClaimed
+		return entry, nil
+	}
+	return models.WaitlistEntry{}, sql.ErrNoRows
+}
+
+//OfferWaitlistEntry testing to hold a room for a waiting guest and offer it
+func (tpg *TestPostgresDBRepository) OfferWaitlistEntry(id, roomID int, token string, expiresAt time.Time) (int, error) {
+	return 1, nil
+}
+
+//ExpireWaitlistOffers testing to close the expired offers
+func (tpg *TestPostgresDBRepository) ExpireWaitlistOffers(now time.Time) (int, error) {
+	return 0, nil
+}
+
+//UpdateWaitlistStatus testing to change the status of an entry
+func (tpg *TestPostgresDBRepository) UpdateWaitlistStatus(id int, status string) error {
+	return nil
+}
+
+//InsertPayment testing to record a payment, payments without an amount fail
+func (tpg *TestPostgresDBRepository) InsertPayment(payment models.Payment) (int, error) {
+	if payment.Amount <= 0 {
+		return 0, errors.New("cannot insert the payment")
+	}
+	return 1, nil
+}
+
+//UpdatePaymentStatus testing for the webhooks, "fake_pending" is a pending deposit of the first reservation,
+//"fake_pending_group" the deposit of a booking group split between its two reservations and any other
+//reference is unknown or already settled
+func (tpg *TestPostgresDBRepository) UpdatePaymentStatus(provider, reference, status string) ([]models.Payment, error) {
+	payment := models.Payment{
+		ID:            1,
+		ReservationID: 1,
+		Provider:      provider,
+		Reference:     reference,
+		Kind:          models.PaymentDeposit,
+		Amount:        7200,
+		Currency:      "USD",
+		Status:        status,
+	}
+	switch reference {
+	case "fake_pending":
+		return []models.Payment{payment}, nil
+	case "fake_pending_group":
+		second := payment
+		second.ID, second.ReservationID, second.Amount = 2, 2, 3600
+		payment.BookingGroupID, second.BookingGroupID = 1, 1
+		return []models.Payment{payment, second}, nil
+	}
+	return nil, sql.ErrNoRows
+}
+
+//PaymentsByReservation testing for the payments of a reservation, the deposits of the first two and the
+//fourth one were paid
+func (tpg *TestPostgresDBRepository) PaymentsByReservation(resvID int) ([]models.Payment, error) {
+	var payments []models.Payment
+	if resvID == 1 || resvID == 2 || resvID == 4 {
+		payments = append(payments, models.Payment{ID: 1, ReservationID: resvID, Provider: "fake", Reference: "fake_paid",
+			Kind: models.PaymentDeposit, Amount: 7200, Currency: "USD", Status: models.PaymentSucceeded})
+	}
+	return payments, nil
+}
+
+//CancelUnpaidReservations testing to remove the reservations of a failed payment
+func (tpg *TestPostgresDBRepository) CancelUnpaidReservations(payment models.Payment) error {
+	return nil
+}
+
+//GetBlockByID testing for an owner block, the first block is a maintenance of the first room. The block
+//99 can't be read and the blocks after the fourth one don't exist
+func (tpg *TestPostgresDBRepository) GetBlockByID(id int) (models.RoomRestriction, error) {
+	if id == 99 {
+		return models.RoomRestriction{}, errors.New("cannot get the block")
+	}
+	if id > 4 {
+		return models.RoomRestriction{}, sql.ErrNoRows
+	}
+	checkIn, _ := time.Parse("2006-01-02", "2022-05-10")
+	return models.RoomRestriction{
+		ID:            id,
+		RoomID:        1,
+		RestrictionID: models.OwnerRestrictionID,
+		CheckInDate:   checkIn,
+		CheckOutDate:  checkIn.AddDate(0, 0, 3),
+		Reason:        "maintenance",
+		Notes:         "Repainting the bathroom",
+		Room:          models.Room{ID: 1, RoomName: "Deluxe suite"},
+	}, nil
+}
+
+//testBlockOverlap the rooms are booked after 2025-09-09 so the blocks can't start after it
+func testBlockOverlap(block models.RoomRestriction) error {
+	testDate, _ := time.Parse("2006-01-02", "2025-09-09")
+	if block.CheckOutDate.After(testDate) {
+		return errors.Errorf("the block overlaps reservation 1 from 2025-09-09 to 2025-09-12")
+	}
+	return nil
+}
+
+//InsertBlock testing to block a room
+func (tpg *TestPostgresDBRepository) InsertBlock(block models.RoomRestriction) (int, error) {
+	if block.RoomID > 4 {
+		return 0, errors.New("cannot insert the block")
+	}
+	if err := testBlockOverlap(block); err != nil {
+		return 0, err
+	}
+	return 2, nil
+}
+
+//UpdateBlock testing to change a block
+func (tpg *TestPostgresDBRepository) UpdateBlock(block models.RoomRestriction) error {
+	if block.ID > 4 || block.RoomID > 4 {
+		return errors.New("cannot update the block")
+	}
+	return testBlockOverlap(block)
+}
+
+//UpdateCalendarBlocks testing to save the calendar, the blocks of the rooms after the fourth one fail
+func (tpg *TestPostgresDBRepository) UpdateCalendarBlocks(adds, removes []models.RoomRestriction) error {
+	for _, block := range append(adds, removes...) {
+		if block.RoomID > 4 {
+			return errors.Errorf("the block of room %d from %s was changed or removed by someone else", block.RoomID,
+				block.CheckInDate.Format("2006-01-02"))
+		}
+	}
+	return nil
+}
+
+//DeleteBlockByID testing to remove a block
 func (tpg *TestPostgresDBRepository) DeleteBlockByID(id int) error {
+	if id > 4 {
+		return errors.New("cannot delete the block")
+	}
 	return nil
 }
+
+//testArrivals the reservations checking in on the day of the dashboard, one of them is cancelled
+var testArrivals = []models.Reservation{
+	{ID: 1, FirstName: "Graham", LastName: "Graham", RoomID: 1},
+	{ID: 3, FirstName: "Cancelled", LastName: "Guest", RoomID: 1, CancelledAt: TestBlockVersion},
+}
+
+//ArrivalsByDate testing for the arrivals, the cancelled reservations are left out as the database does
+func (tpg *TestPostgresDBRepository) ArrivalsByDate(date time.Time) ([]models.Reservation, error) {
+	var resvs []models.Reservation
+	for _, resv := range testArrivals {
+		if !resv.IsCancelled() {
+			resvs = append(resvs, resv)
+		}
+	}
+	return resvs, nil
+}
+
+func (tpg *TestPostgresDBRepository) DeparturesByDate(date time.Time) ([]models.Reservation, error) {
+	var resvs []models.Reservation
+	return resvs, nil
+}
+
+func (tpg *TestPostgresDBRepository) RoomOccupancyByDate(date time.Time) ([]models.RoomOccupancy, error) {
+	var occupancy []models.RoomOccupancy
+	occupancy = append(occupancy,
+		models.RoomOccupancy{Room: models.Room{ID: 1, RoomName: "Deluxe suite"}, ReservationID: 1, RestrictionID: 1},
+		models.RoomOccupancy{Room: models.Room{ID: 2, RoomName: "Junior Quarter's"}},
+	)
+	return occupancy, nil
+}
+
+func (tpg *TestPostgresDBRepository) OccupancyStatistics(startDate, endDate time.Time) (models.OccupancyStats, error) {
+	days := int(endDate.Sub(startDate).Hours() / 24)
+	return models.OccupancyStats{Days: days, RoomNights: days * 2, BookedNights: days, NewBookings: 3, AverageStay: 2.5}, nil
+}
+
+//InvoiceForReservation testing for the invoices, the number follows the id of the reservation and the
+//reservations after the fourth one fail
+func (tpg *TestPostgresDBRepository) InvoiceForReservation(resvID int, issuedAt time.Time) (models.Invoice, error) {
+	if resvID > 4 {
+		return models.Invoice{}, errors.New("cannot number the invoice")
+	}
+	return models.Invoice{ID: resvID, Number: resvID, ReservationID: resvID, IssuedAt: issuedAt}, nil
+}
@@ -9,9 +9,68 @@ type DatabaseRepository interface {
 	AllRoom() ([]models.Room, error)
 	InsertReservation(resv models.Reservation) (int, error)
 	InsertRoomRestriction(resv models.RoomRestriction) error
+	InsertReservationsWithRestrictions(resvs []models.Reservation) error
+	InsertBookingGroup(resvs []models.Reservation, holdIDs []int) (models.BookingGroup, error)
+	InsertHeldReservation(resv models.Reservation, holdIDs []int) (int, error)
 	SearchRoomAvailabileByRoomID(roomID int, checkInDate, checkOutDate time.Time) (bool, error)
 	SearchForAvailableRoom(checkInDate, checkOutDate time.Time) ([]models.Room, error)
 	GetRooms(room_id int) (models.Room, error)
+	RoomOccupiedNights(startDate, endDate time.Time) ([]models.RoomNights, error)
+
+	//Room catalogue
+	GetRoomBySlug(slug string) (models.Room, error)
+	InsertRoom(room models.Room) (int, error)
+	UpdateRoom(room models.Room) error
+	DeleteRoom(id int) error
+	InsertRoomPhoto(photo models.RoomPhoto) (int, error)
+	UpdateRoomPhotos(roomID int, photos []models.RoomPhoto) error
+	DeleteRoomPhoto(roomID, photoID int) (models.RoomPhoto, error)
+
+	//Booking rules
+	BookingRules(startDate, endDate time.Time) (map[int][]models.BookingRule, error)
+	BookingRulesByRoom(roomID int) ([]models.BookingRule, error)
+	InsertBookingRule(rule models.BookingRule) (int, error)
+	DeleteBookingRule(roomID, ruleID int) error
+
+	//Taxes and fees
+	TaxRules(startDate, endDate time.Time) ([]models.TaxRule, error)
+	AllTaxRules() ([]models.TaxRule, error)
+	InsertTaxRule(rule models.TaxRule) (int, error)
+	DeleteTaxRule(id int) error
+	InsertReservationCharges(resvID int, charges []models.ReservationCharge) error
+	ReservationCharges(resvID int) ([]models.ReservationCharge, error)
+
+	//Promo codes
+	AllPromoCodes() ([]models.PromoCode, error)
+	GetPromoCodeByCode(code string) (models.PromoCode, error)
+	InsertPromoCode(promo models.PromoCode) (int, error)
+	DeletePromoCode(id int) error
+	RedeemPromoCode(id int) error
+	ReleasePromoCode(id int) error
+
+	//Exchange rates
+	AllExchangeRates() ([]models.ExchangeRate, error)
+	UpsertExchangeRates(rates []models.ExchangeRate) error
+	DeleteExchangeRate(currency string) error
+
+	//Checkout holds
+	InsertHold(hold models.RoomRestriction) (int, error)
+	ReleaseHolds(ids []int) error
+	DeleteExpiredHolds(now time.Time) (int, error)
+
+	//Payments
+	InsertPayment(payment models.Payment) (int, error)
+	UpdatePaymentStatus(provider, reference, status string) ([]models.Payment, error)
+	PaymentsByReservation(resvID int) ([]models.Payment, error)
+	CancelUnpaidReservations(payment models.Payment) error
+
+	//Waitlist
+	InsertWaitlistEntry(entry models.WaitlistEntry) (int, error)
+	WaitlistEntries(status string) ([]models.WaitlistEntry, error)
+	GetWaitlistEntryByToken(token string) (models.WaitlistEntry, error)
+	OfferWaitlistEntry(id, roomID int, token string, expiresAt time.Time) (int, error)
+	ExpireWaitlistOffers(now time.Time) (int, error)
+	UpdateWaitlistStatus(id int, status string) error
 
 	//Users
 	GetUserInfoByID(user_id int) (models.User, error)
@@ -25,8 +84,25 @@ type DatabaseRepository interface {
 	UpdateUserReservation(resv models.Reservation) error
 	ProcessedUpdateReservation(id int, processed int) error
 	DeleteUserReservation(id int) error
+	CancelReservation(id, refund int, cancelledAt time.Time) error
+	InvoiceForReservation(resvID int, issuedAt time.Time) (models.Invoice, error)
+
+	//Dashboard statistics
+	ArrivalsByDate(date time.Time) ([]models.Reservation, error)
+	DeparturesByDate(date time.Time) ([]models.Reservation, error)
+	RoomOccupancyByDate(date time.Time) ([]models.RoomOccupancy, error)
+	OccupancyStatistics(startDate, endDate time.Time) (models.OccupancyStats, error)
 
 	GetRestrictionsForRoomByDate(roomID int, checkInDate, checkOutDate time.Time) ([]models.RoomRestriction, error)
+
+	//Interactive calendar
+	CalendarEntries(startDate, endDate time.Time) ([]models.RoomRestriction, error)
+	MoveReservation(resvID, roomID int, checkInDate, checkOutDate time.Time) error
+
+	//Owner blocks
+	GetBlockByID(id int) (models.RoomRestriction, error)
+	InsertBlock(block models.RoomRestriction) (int, error)
+	UpdateBlock(block models.RoomRestriction) error
 	DeleteBlockByID(id int) error
-	InsertBlockForRoom(id int, checkInDate time.Time) error
+	UpdateCalendarBlocks(adds, removes []models.RoomRestriction) error
 }
@@ -1,2 +1,2 @@
-go build -o resvbooking cmd/web/main.go mailchannel.go middlewares.go routes.go
-./resvbooking -dbname=postgres -dbuser=postgres -inproduction=false -usecache=false
\ No newline at end of file
+go build -o resvbooking ./cmd/web
+./resvbooking -dbname=postgres -dbuser=postgres -inproduction=false -usecache=false -paymentprovider=fake -paymentsecret=dev-secret
\ No newline at end of file